@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 
@@ -12,7 +13,11 @@ import (
 
 	"github.com/stackmon/otc-status-dashboard/internal/app"
 	"github.com/stackmon/otc-status-dashboard/internal/checker"
+	"github.com/stackmon/otc-status-dashboard/internal/cli"
 	"github.com/stackmon/otc-status-dashboard/internal/conf"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/email"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
 )
 
 func main() {
@@ -22,8 +27,25 @@ func main() {
 	}
 
 	logger := conf.NewLogger(c.LogLevel)
+
+	// the admin commands are executed instead of the server
+	if len(os.Args) > 1 {
+		if err = cli.Run(c, logger, os.Args[1:], os.Stdout); err != nil {
+			logger.Fatal("command failed", zap.Error(err))
+		}
+		return
+	}
+
 	c.Log(logger)
 
+	// the app refuses to start with the schema of another version, the migrations are applied only if it's enabled
+	schema, err := db.PrepareSchema(context.Background(), c.DB, c.MigrateOnStart)
+	if err != nil {
+		logger.Fatal("database schema is not ready, run \"app migrate up\" or set SD_MIGRATE_ON_START=true",
+			zap.Error(err))
+	}
+	logger.Info("database schema is ready", zap.Stringer("schema", schema))
+
 	s, err := app.New(c, logger)
 	if err != nil {
 		logger.Fatal("fail to init app", zap.Error(err))
@@ -35,6 +57,21 @@ func main() {
 	}
 	stopCh := make(chan struct{})
 
+	sender, err := webhook.New(c, logger)
+	if err != nil {
+		logger.Fatal("fail to init webhook sender", zap.Error(err))
+	}
+	stopSenderCh := make(chan struct{})
+
+	// the email sender is started only if the SMTP server is configured
+	var mailer *email.Sender
+	stopMailerCh := make(chan struct{})
+	if c.SMTP != "" {
+		if mailer, err = email.New(c, logger); err != nil {
+			logger.Fatal("fail to init email sender", zap.Error(err))
+		}
+	}
+
 	ctx, done := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer done()
 
@@ -44,10 +81,24 @@ func main() {
 		}
 	}()
 
+	go func() {
+		if errMetrics := s.RunMetrics(); errMetrics != nil && !errors.Is(errMetrics, http.ErrServerClosed) {
+			logger.Fatal("metrics server is failed to run", zap.Error(errMetrics))
+		}
+	}()
+
 	go func() {
 		ch.Run(stopCh)
 	}()
 
+	go func() {
+		sender.Run(stopSenderCh)
+	}()
+
+	if mailer != nil {
+		go mailer.Run(stopMailerCh)
+	}
+
 	<-ctx.Done()
 	s.Log.Info("shutdown app")
 
@@ -59,5 +110,15 @@ func main() {
 		logger.Fatal("checker shutdown failed", zap.Error(err))
 	}
 
+	if err = sender.Shutdown(stopSenderCh); err != nil {
+		logger.Fatal("webhook sender shutdown failed", zap.Error(err))
+	}
+
+	if mailer != nil {
+		if err = mailer.Shutdown(stopMailerCh); err != nil {
+			logger.Fatal("email sender shutdown failed", zap.Error(err))
+		}
+	}
+
 	logger.Info("app exited")
 }
@@ -0,0 +1,4 @@
+-- Remove fields for the maintenance review workflow
+ALTER TABLE incident DROP COLUMN IF EXISTS version;
+ALTER TABLE incident DROP COLUMN IF EXISTS contact_email;
+ALTER TABLE incident DROP COLUMN IF EXISTS creator;
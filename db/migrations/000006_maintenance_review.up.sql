@@ -0,0 +1,4 @@
+-- Add fields for the maintenance review workflow
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS creator character varying;
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS contact_email character varying;
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS version integer DEFAULT 1 NOT NULL;
@@ -0,0 +1,6 @@
+-- Remove webhook subscriptions and the delivery log
+DROP INDEX IF EXISTS ix_webhook_delivery_status_next_attempt_at;
+DROP INDEX IF EXISTS ix_webhook_delivery_webhook_id;
+
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook;
@@ -0,0 +1,30 @@
+-- Add webhook subscriptions and the delivery log
+CREATE TABLE IF NOT EXISTS webhook (
+    id serial primary key,
+    url character varying NOT NULL,
+    secret character varying NOT NULL,
+    -- comma separated list of event types, empty value means all types
+    event_types character varying DEFAULT '' NOT NULL,
+    active boolean DEFAULT true NOT NULL,
+    created_at timestamp without time zone,
+    modified_at timestamp without time zone
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id serial primary key,
+    webhook_id integer NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    incident_id integer NOT NULL,
+    action character varying(50) NOT NULL,
+    payload text NOT NULL,
+    status character varying(50) NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    next_attempt_at timestamp without time zone NOT NULL,
+    response_code integer,
+    last_error text,
+    created_at timestamp without time zone,
+    delivered_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS ix_webhook_delivery_webhook_id ON webhook_delivery USING btree (webhook_id);
+CREATE INDEX IF NOT EXISTS ix_webhook_delivery_status_next_attempt_at
+    ON webhook_delivery USING btree (status, next_attempt_at);
@@ -0,0 +1,4 @@
+-- Remove the log of events changes for the server-sent events stream
+DROP INDEX IF EXISTS ix_event_stream_created_at;
+
+DROP TABLE IF EXISTS event_stream;
@@ -0,0 +1,10 @@
+-- Add the log of events changes for the server-sent events stream
+CREATE TABLE IF NOT EXISTS event_stream (
+    id serial primary key,
+    incident_id integer NOT NULL,
+    action character varying(50) NOT NULL,
+    payload text NOT NULL,
+    created_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS ix_event_stream_created_at ON event_stream USING btree (created_at);
@@ -0,0 +1,2 @@
+-- Remove the regions table
+DROP TABLE IF EXISTS region;
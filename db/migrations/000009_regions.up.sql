@@ -0,0 +1,16 @@
+-- Add the regions table, the component attribute "region" must refer to the region name
+CREATE TABLE IF NOT EXISTS region (
+    id serial primary key,
+    name character varying(100) NOT NULL UNIQUE,
+    description character varying(500) DEFAULT '' NOT NULL,
+    created_at timestamp without time zone,
+    modified_at timestamp without time zone
+);
+
+INSERT INTO region (name, created_at, modified_at)
+VALUES ('EU-DE', now(), now()), ('EU-NL', now(), now()), ('EU-CH2', now(), now()), ('Global', now(), now())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO region (name, created_at, modified_at)
+SELECT DISTINCT value, now(), now() FROM component_attribute WHERE name = 'region' AND value <> ''
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,4 @@
+-- Remove the storage of the user tokens
+DROP INDEX IF EXISTS ix_auth_token_expires_at;
+
+DROP TABLE IF EXISTS auth_token;
@@ -0,0 +1,9 @@
+-- Add the storage of the user tokens between the authentication callback and the token request
+CREATE TABLE IF NOT EXISTS auth_token (
+    key character varying(255) primary key,
+    data text NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS ix_auth_token_expires_at ON auth_token USING btree (expires_at);
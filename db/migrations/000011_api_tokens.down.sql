@@ -0,0 +1,2 @@
+-- Remove the API tokens of the integrations
+DROP TABLE IF EXISTS api_token;
@@ -0,0 +1,15 @@
+-- Add the API tokens of the integrations, only the SHA256 hash of the token is stored
+CREATE TABLE IF NOT EXISTS api_token (
+    id serial primary key,
+    name character varying(100) NOT NULL,
+    owner character varying(255) NOT NULL,
+    token_hash character varying(64) NOT NULL UNIQUE,
+    -- comma separated lists, the empty components and regions mean no restrictions
+    scopes character varying NOT NULL,
+    components character varying DEFAULT '' NOT NULL,
+    regions character varying DEFAULT '' NOT NULL,
+    expires_at timestamp without time zone,
+    revoked_at timestamp without time zone,
+    last_used_at timestamp without time zone,
+    created_at timestamp without time zone
+);
@@ -0,0 +1,5 @@
+-- Remove the audit log and the acting user of the events changes
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE incident_status DROP COLUMN IF EXISTS author;
+ALTER TABLE incident DROP COLUMN IF EXISTS modified_by;
@@ -0,0 +1,18 @@
+-- Record the acting user of the events changes and keep the audit log of the events
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS modified_by character varying;
+ALTER TABLE incident_status ADD COLUMN IF NOT EXISTS author character varying;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id serial primary key,
+    incident_id integer NOT NULL,
+    action character varying(50) NOT NULL,
+    actor character varying DEFAULT '' NOT NULL,
+    -- JSON object with the changed fields, the values before and after the change
+    changes text NOT NULL,
+    -- JSON object with the event state after the change
+    state text NOT NULL,
+    created_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS ix_audit_log_incident_id ON audit_log USING btree (incident_id);
+CREATE INDEX IF NOT EXISTS ix_audit_log_created_at ON audit_log USING btree (created_at);
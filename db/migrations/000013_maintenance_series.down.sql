@@ -0,0 +1,9 @@
+-- Remove the recurring maintenances, the created occurrences are kept as regular maintenances
+DROP INDEX IF EXISTS ix_incident_series_occurrence;
+
+ALTER TABLE incident DROP COLUMN IF EXISTS series_detached;
+ALTER TABLE incident DROP COLUMN IF EXISTS series_occurrence;
+ALTER TABLE incident DROP COLUMN IF EXISTS series_id;
+
+DROP TABLE IF EXISTS maintenance_series_component_relation;
+DROP TABLE IF EXISTS maintenance_series;
@@ -0,0 +1,31 @@
+-- Add the recurring maintenances, the occurrences are created by the checker as maintenance events
+CREATE TABLE IF NOT EXISTS maintenance_series (
+    id serial primary key,
+    title character varying NOT NULL,
+    description character varying(500),
+    -- RFC 5545 recurrence rule, as example FREQ=WEEKLY;BYDAY=TU
+    rrule character varying NOT NULL,
+    -- the start and the end of the first occurrence, every occurrence has the same duration
+    start_date timestamp without time zone NOT NULL,
+    end_date timestamp without time zone NOT NULL,
+    contact_email character varying,
+    creator character varying,
+    cancelled_at timestamp without time zone,
+    created_at timestamp without time zone,
+    modified_at timestamp without time zone
+);
+
+-- the components of the series, every occurrence gets them
+CREATE TABLE IF NOT EXISTS maintenance_series_component_relation (
+    maintenance_series_id integer NOT NULL REFERENCES maintenance_series(id) ON DELETE CASCADE,
+    component_id integer NOT NULL REFERENCES component(id),
+    PRIMARY KEY (maintenance_series_id, component_id)
+);
+
+-- series_occurrence is the original start of the occurrence, it's kept if the occurrence is moved
+-- series_detached is set when the occurrence is changed separately from the series
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS series_id integer REFERENCES maintenance_series(id);
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS series_occurrence timestamp without time zone;
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS series_detached boolean DEFAULT false NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ix_incident_series_occurrence ON incident USING btree (series_id, series_occurrence);
@@ -0,0 +1,2 @@
+-- Remove the Alertmanager alerts, the created incidents are kept
+DROP TABLE IF EXISTS alert;
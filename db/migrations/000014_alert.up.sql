@@ -0,0 +1,19 @@
+-- Keep the Alertmanager alerts to deduplicate the repeated notifications
+CREATE TABLE IF NOT EXISTS alert (
+    id serial primary key,
+    -- the fingerprint and the start identify the alert, the fingerprint is reused when the alert fires again
+    fingerprint character varying NOT NULL,
+    starts_at timestamp without time zone NOT NULL,
+    ends_at timestamp without time zone,
+    -- firing or resolved
+    status character varying(20) NOT NULL,
+    title character varying NOT NULL,
+    impact integer NOT NULL,
+    -- comma separated list of the component IDs
+    components character varying NOT NULL,
+    created_at timestamp without time zone,
+    modified_at timestamp without time zone
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ix_alert_fingerprint_starts_at ON alert USING btree (fingerprint, starts_at);
+CREATE INDEX IF NOT EXISTS ix_alert_status ON alert USING btree (status);
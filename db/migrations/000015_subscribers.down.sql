@@ -0,0 +1,8 @@
+-- Remove the email subscribers and the outbox of the email messages
+DROP INDEX IF EXISTS ix_email_message_status_next_attempt_at;
+DROP INDEX IF EXISTS ix_email_notification_incident_id;
+DROP INDEX IF EXISTS ix_subscriber_email;
+
+DROP TABLE IF EXISTS email_message;
+DROP TABLE IF EXISTS email_notification;
+DROP TABLE IF EXISTS subscriber;
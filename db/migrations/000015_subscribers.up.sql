@@ -0,0 +1,47 @@
+-- Add the email subscribers, the notified states of the events and the outbox of the email messages
+CREATE TABLE IF NOT EXISTS subscriber (
+    id serial primary key,
+    email character varying(255) NOT NULL,
+    -- comma separated lists of the component IDs and the region names
+    components character varying DEFAULT '' NOT NULL,
+    regions character varying DEFAULT '' NOT NULL,
+    -- the tokens are kept to build the links of every message
+    confirm_token character varying(64) NOT NULL UNIQUE,
+    unsubscribe_token character varying(64) NOT NULL UNIQUE,
+    confirmed_at timestamp without time zone,
+    created_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS ix_subscriber_email ON subscriber USING btree (email);
+
+-- the next change of the event is compared with the last notified state
+CREATE TABLE IF NOT EXISTS email_notification (
+    id serial primary key,
+    incident_id integer NOT NULL,
+    kind character varying(50) NOT NULL,
+    impact integer NOT NULL,
+    status character varying(50) NOT NULL,
+    -- the latest update of the event
+    status_id integer DEFAULT 0 NOT NULL,
+    created_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS ix_email_notification_incident_id ON email_notification USING btree (incident_id);
+
+CREATE TABLE IF NOT EXISTS email_message (
+    id serial primary key,
+    subscriber_id integer NOT NULL REFERENCES subscriber(id) ON DELETE CASCADE,
+    incident_id integer,
+    kind character varying(50) NOT NULL,
+    subject character varying NOT NULL,
+    body text NOT NULL,
+    status character varying(50) NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    next_attempt_at timestamp without time zone NOT NULL,
+    last_error text,
+    created_at timestamp without time zone,
+    sent_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS ix_email_message_status_next_attempt_at
+    ON email_message USING btree (status, next_attempt_at);
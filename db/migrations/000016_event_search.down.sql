@@ -0,0 +1,3 @@
+-- Remove the full-text search indexes of the events
+DROP INDEX IF EXISTS ix_incident_status_search;
+DROP INDEX IF EXISTS ix_incident_search;
@@ -0,0 +1,7 @@
+-- Full-text search of the events, the expressions are the same as in the search queries of the app
+CREATE INDEX IF NOT EXISTS ix_incident_search ON incident USING gin (
+    (setweight(to_tsvector('english', coalesce(text, '')), 'A') ||
+     setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
+
+CREATE INDEX IF NOT EXISTS ix_incident_status_search ON incident_status USING gin (to_tsvector('english', text));
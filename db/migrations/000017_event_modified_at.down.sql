@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ix_incident_modified_at;
@@ -0,0 +1,2 @@
+-- The incremental sync of the events by the modified_since filter
+CREATE INDEX IF NOT EXISTS ix_incident_modified_at ON incident (modified_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ix_incident_merged_into_id;
+
+ALTER TABLE incident DROP COLUMN IF EXISTS merged_into_id;
@@ -0,0 +1,4 @@
+-- merged_into_id is the incident, which the closed incident is merged into
+ALTER TABLE incident ADD COLUMN IF NOT EXISTS merged_into_id integer REFERENCES incident(id);
+
+CREATE INDEX IF NOT EXISTS ix_incident_merged_into_id ON incident (merged_into_id);
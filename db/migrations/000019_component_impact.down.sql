@@ -0,0 +1 @@
+ALTER TABLE incident_component_relation DROP COLUMN IF EXISTS impact;
@@ -0,0 +1,2 @@
+-- impact is the impact of the incident on the component, NULL means the impact of the incident
+ALTER TABLE incident_component_relation ADD COLUMN IF NOT EXISTS impact integer;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations of the database schema into the binary.
+package migrations
+
+import "embed"
+
+// FS contains the up and down migrations in the golang-migrate format.
+//
+//go:embed *.sql
+var FS embed.FS //nolint:gochecknoglobals
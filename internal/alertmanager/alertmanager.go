@@ -0,0 +1,167 @@
+// Package alertmanager maps the Prometheus Alertmanager notifications to the components and the incidents.
+package alertmanager
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// DefaultRules is used if the label rules are not configured.
+const DefaultRules = "component=name,type=type,region=region"
+
+// Component attributes, which can be matched by the alert labels.
+const (
+	AttrName   = "name"
+	AttrType   = "type"
+	AttrRegion = "region"
+)
+
+const (
+	impactMinor  = 1
+	impactMajor  = 2
+	impactOutage = 3
+)
+
+var ErrInvalidRule = errors.New("invalid alert label rule")
+
+// Message is the webhook payload of the Alertmanager, version 4.
+type Message struct {
+	Version     string            `json:"version"`
+	GroupKey    string            `json:"groupKey"`
+	Status      string            `json:"status"`
+	Receiver    string            `json:"receiver"`
+	GroupLabels map[string]string `json:"groupLabels"`
+	ExternalURL string            `json:"externalURL"`
+	Alerts      []Alert           `json:"alerts" binding:"required,dive"`
+}
+
+type Alert struct {
+	Status       string            `json:"status" binding:"required,oneof=firing resolved"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Title returns the summary annotation or the alert name.
+func (a *Alert) Title() string {
+	if summary := a.Annotations["summary"]; summary != "" {
+		return summary
+	}
+	if name := a.Labels["alertname"]; name != "" {
+		return name
+	}
+	return "Alert " + a.Fingerprint
+}
+
+// Description returns the description annotation.
+func (a *Alert) Description() string {
+	return a.Annotations["description"]
+}
+
+// Impact returns the impact from the "impact" label, 1-3, or from the "severity" label:
+// critical is an outage, major is a major impact, the other values are a minor impact.
+func (a *Alert) Impact() int {
+	if impact, err := strconv.Atoi(a.Labels["impact"]); err == nil && impact >= impactMinor && impact <= impactOutage {
+		return impact
+	}
+
+	switch strings.ToLower(a.Labels["severity"]) {
+	case "critical":
+		return impactOutage
+	case "major":
+		return impactMajor
+	default:
+		return impactMinor
+	}
+}
+
+// Rule maps the alert label to the component attribute.
+type Rule struct {
+	Label string
+	Attr  string
+}
+
+type Rules []Rule
+
+// ParseRules parses the rules like "service=type,region=region", the attribute is name, type or region.
+func ParseRules(value string) (Rules, error) {
+	if value == "" {
+		value = DefaultRules
+	}
+
+	var rules Rules
+	for _, part := range strings.Split(value, ",") {
+		label, attr, ok := strings.Cut(strings.TrimSpace(part), "=")
+		label = strings.TrimSpace(label)
+		attr = strings.ToLower(strings.TrimSpace(attr))
+		if !ok || label == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRule, part)
+		}
+
+		switch attr {
+		case AttrName, AttrType, AttrRegion:
+		default:
+			return nil, fmt.Errorf("%w: unknown attribute %s", ErrInvalidRule, attr)
+		}
+
+		rules = append(rules, Rule{Label: label, Attr: attr})
+	}
+
+	return rules, nil
+}
+
+// Match returns the components, which match all mapped labels of the alert.
+// The labels are compared case-insensitively, the alert without mapped labels doesn't match any component.
+func (rs Rules) Match(labels map[string]string, components []db.Component) []db.Component {
+	conditions := make(map[string]string, len(rs))
+	for _, r := range rs {
+		if value := labels[r.Label]; value != "" {
+			conditions[r.Attr] = value
+		}
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	var matched []db.Component
+	for _, comp := range components {
+		if matchComponent(&comp, conditions) {
+			matched = append(matched, comp)
+		}
+	}
+
+	return matched
+}
+
+func matchComponent(comp *db.Component, conditions map[string]string) bool {
+	for attr, value := range conditions {
+		var actual string
+		switch attr {
+		case AttrName:
+			actual = comp.Name
+		case AttrType:
+			actual = comp.Type()
+		case AttrRegion:
+			actual = comp.Region()
+		}
+
+		if !strings.EqualFold(actual, value) {
+			return false
+		}
+	}
+
+	return true
+}
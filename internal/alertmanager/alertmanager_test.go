@@ -0,0 +1,74 @@
+package alertmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("")
+	require.NoError(t, err)
+	assert.Equal(t, Rules{{"component", AttrName}, {"type", AttrType}, {"region", AttrRegion}}, rules)
+
+	rules, err = ParseRules(" service = Type, cloud_region=region")
+	require.NoError(t, err)
+	assert.Equal(t, Rules{{"service", AttrType}, {"cloud_region", AttrRegion}}, rules)
+
+	for _, value := range []string{"service", "=type", "service=category", "service=type,"} {
+		_, err = ParseRules(value)
+		require.ErrorIs(t, err, ErrInvalidRule, value)
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	components := []db.Component{
+		testComponent(1, "Elastic Cloud Server", "ecs", "EU-DE"),
+		testComponent(2, "Elastic Cloud Server", "ecs", "EU-NL"),
+		testComponent(3, "Object Storage Service", "obs", "EU-DE"),
+	}
+	rules, err := ParseRules("service=type,region=region")
+	require.NoError(t, err)
+
+	matched := rules.Match(map[string]string{"service": "ECS", "region": "eu-de", "severity": "critical"}, components)
+	require.Len(t, matched, 1)
+	assert.Equal(t, uint(1), matched[0].ID)
+
+	matched = rules.Match(map[string]string{"service": "ecs"}, components)
+	assert.Len(t, matched, 2)
+
+	assert.Empty(t, rules.Match(map[string]string{"service": "dns"}, components))
+	assert.Empty(t, rules.Match(map[string]string{"alertname": "HighLatency"}, components))
+}
+
+func TestAlertImpact(t *testing.T) {
+	assert.Equal(t, 2, (&Alert{Labels: map[string]string{"impact": "2", "severity": "critical"}}).Impact())
+	assert.Equal(t, 3, (&Alert{Labels: map[string]string{"impact": "5", "severity": "Critical"}}).Impact())
+	assert.Equal(t, 2, (&Alert{Labels: map[string]string{"severity": "major"}}).Impact())
+	assert.Equal(t, 1, (&Alert{Labels: map[string]string{"severity": "warning"}}).Impact())
+	assert.Equal(t, 1, (&Alert{}).Impact())
+}
+
+func TestAlertTitle(t *testing.T) {
+	alert := &Alert{Labels: map[string]string{"alertname": "HighErrorRate"}, Fingerprint: "a1b2"}
+	assert.Equal(t, "HighErrorRate", alert.Title())
+
+	alert.Annotations = map[string]string{"summary": "High error rate of the API"}
+	assert.Equal(t, "High error rate of the API", alert.Title())
+
+	assert.Equal(t, "Alert a1b2", (&Alert{Fingerprint: "a1b2"}).Title())
+}
+
+func testComponent(id uint, name, compType, region string) db.Component {
+	return db.Component{
+		ID:   id,
+		Name: name,
+		Attrs: []db.ComponentAttr{
+			{Name: "type", Value: compType},
+			{Name: "region", Value: region},
+		},
+	}
+}
@@ -2,14 +2,20 @@ package api
 
 import (
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
+	"github.com/stackmon/otc-status-dashboard/internal/alertmanager"
 	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
 	"github.com/stackmon/otc-status-dashboard/internal/api/errors"
 	"github.com/stackmon/otc-status-dashboard/internal/conf"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
+	"github.com/stackmon/otc-status-dashboard/internal/stream"
 )
 
 type API struct {
@@ -18,7 +24,14 @@ type API struct {
 	log         *zap.Logger
 	oa2Prov     *auth.Provider
 	secretKeyV1 string
-	authGroup   string
+	roleGroups  *auth.RoleGroups
+	alertRules  alertmanager.Rules
+	broker      *stream.Broker
+	brokerDone  chan struct{}
+	metrics     http.Handler
+
+	// subscriptions are enabled if the SMTP server is configured
+	subscriptions bool
 }
 
 func New(cfg *conf.Config, log *zap.Logger, database *db.DB) (*API, error) {
@@ -29,26 +42,90 @@ func New(cfg *conf.Config, log *zap.Logger, database *db.DB) (*API, error) {
 	oa2Prov := &auth.Provider{Disabled: true}
 
 	if !cfg.AuthenticationDisabled {
-		var err error
+		tokenStore, err := auth.NewTokenStore(cfg.TokenStore, database)
+		if err != nil {
+			return nil, fmt.Errorf("could not initialise the token store, err: %w", err)
+		}
+
+		var jwksRefresh time.Duration
+		if cfg.JWKSRefreshInterval != "" {
+			if jwksRefresh, err = time.ParseDuration(cfg.JWKSRefreshInterval); err != nil {
+				return nil, fmt.Errorf("could not parse the JWKS refresh interval, err: %w", err)
+			}
+		}
+
 		if oa2Prov, err = auth.NewProvider(
-			cfg.Keycloak.URL, cfg.Keycloak.Realm, cfg.Keycloak.ClientID,
-			cfg.Keycloak.ClientSecret, cfg.Hostname, cfg.WebURL,
+			oidcConfig(cfg), cfg.Hostname, cfg.WebURL, jwksRefresh, tokenStore,
 		); err != nil {
 			return nil, fmt.Errorf("could not initialise the OAuth provider, err: %w", err)
 		}
 	}
 
+	alertRules, err := alertmanager.ParseRules(cfg.AlertmanagerLabels)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the Alertmanager label rules, err: %w", err)
+	}
+
 	r := gin.New()
 	r.Use(Logger(log), gin.Recovery())
 	r.Use(ErrorHandle())
 	r.Use(CORSMiddleware())
 	r.NoRoute(errors.Return404)
 
-	a := &API{r: r, db: database, log: log, oa2Prov: oa2Prov, secretKeyV1: cfg.SecretKeyV1, authGroup: cfg.AuthGroup}
+	roleGroups := &auth.RoleGroups{
+		Creators:  cfg.CreatorsGroup,
+		Operators: cfg.OperatorsGroup,
+		Admins:    cfg.AdminsGroup,
+		Claim:     cfg.GroupsClaim,
+	}
+
+	// the status metrics are registered per API instance, they are collected from its database
+	statusRegistry := prometheus.NewRegistry()
+	if err = statusRegistry.Register(metrics.NewStatusCollector(database, log)); err != nil {
+		return nil, fmt.Errorf("could not register the status metrics, err: %w", err)
+	}
+
+	a := &API{
+		r: r, db: database, log: log, oa2Prov: oa2Prov, secretKeyV1: cfg.SecretKeyV1, roleGroups: roleGroups,
+		broker: stream.NewBroker(database, log), brokerDone: make(chan struct{}),
+		metrics: metrics.Handler(statusRegistry), alertRules: alertRules, subscriptions: cfg.SMTP != "",
+	}
 	a.InitRoutes()
+
+	go a.broker.Run(a.brokerDone)
+
 	return a, nil
 }
 
+// oidcConfig returns the generic OIDC provider if the issuer is set, otherwise the Keycloak realm is used.
+func oidcConfig(cfg *conf.Config) *auth.OIDCConfig {
+	if cfg.OIDC != nil && cfg.OIDC.Issuer != "" {
+		return &auth.OIDCConfig{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+		}
+	}
+
+	kc := cfg.Keycloak
+	if kc == nil {
+		kc = &conf.Keycloak{}
+	}
+
+	return auth.KeycloakConfig(kc.URL, kc.Realm, kc.ClientID, kc.ClientSecret)
+}
+
+// Shutdown stops the background workers of the API, it closes opened streams.
+func (a *API) Shutdown() {
+	close(a.brokerDone)
+}
+
 func (a *API) Router() *gin.Engine {
 	return a.r
 }
+
+// MetricsHandler returns the handler of the Prometheus metrics, it's served by the separate listener,
+// so the metrics are not available on the public API.
+func (a *API) MetricsHandler() http.Handler {
+	return a.metrics
+}
@@ -10,9 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
-	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
@@ -22,84 +22,91 @@ import (
 
 const (
 	authCallbackURL = "auth/callback"
+	defaultTimeout  = 10
 )
 
+var ErrIssuerNotDefined = errors.New("OIDC issuer is not defined")
+
 type Provider struct {
-	Disabled       bool
-	WebURL         string
-	kc             *Keycloak
-	conf           *oauth2.Config
-	storage        *internalStorage
-	realmPublicKey *rsa.PublicKey
+	Disabled    bool
+	WebURL      string
+	conf        *OIDCConfig
+	redirectURL string
+	httpClient  *http.Client
+	storage     TokenStore
+	keys        *keySet
+
+	// mu guards the endpoints, they are discovered on the first use
+	mu        sync.Mutex
+	endpoints *Endpoints
 }
 
+// NewProvider creates the provider for the OIDC identity provider.
+// The endpoints are discovered on the first use, so the identity provider can be unavailable on the start.
+// The signing keys are refreshed every jwksRefreshInterval, the default interval is used if it's zero.
 func NewProvider(
-	keycloakBaseURL,
-	keycloakRealm,
-	keycloakClientID,
-	keycloakClientSecret,
+	conf *OIDCConfig,
 	hostname,
 	webURL string,
+	jwksRefreshInterval time.Duration,
+	store TokenStore,
 ) (*Provider, error) {
-	kc := NewKeycloak(keycloakBaseURL, keycloakRealm, keycloakClientID, keycloakClientSecret)
+	if conf.Endpoints == nil && conf.Issuer == "" {
+		return nil, ErrIssuerNotDefined
+	}
 
-	redirectURI := fmt.Sprintf("%s/%s", hostname, authCallbackURL)
+	if store == nil {
+		store = newInternalStorage(defaultTokenTTL)
+	}
 
-	conf := &oauth2.Config{
-		ClientID:     keycloakClientID,
-		ClientSecret: keycloakClientSecret,
-		RedirectURL:  redirectURI,
-		Endpoint:     kc.Endpoint(),
-		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	httpClient := &http.Client{
+		Timeout: time.Second * defaultTimeout,
 	}
+
 	return &Provider{
-		WebURL:  webURL,
-		kc:      kc,
-		conf:    conf,
-		storage: newInternalStorage(),
+		WebURL:      webURL,
+		conf:        conf,
+		redirectURL: fmt.Sprintf("%s/%s", hostname, authCallbackURL),
+		httpClient:  httpClient,
+		storage:     store,
+		keys:        newKeySet(httpClient, jwksRefreshInterval),
+		endpoints:   conf.Endpoints,
 	}, nil
 }
 
-func (p *Provider) AuthCodeURL(state string) string {
-	return p.conf.AuthCodeURL(state)
+func (p *Provider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	conf, err := p.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return conf.AuthCodeURL(state), nil
 }
 
 func (p *Provider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
-	return p.conf.Exchange(ctx, code)
+	conf, err := p.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conf.Exchange(ctx, code)
 }
 
-func (p *Provider) PutToken(key string, token TokenRepr) {
-	p.storage.Store(key, token)
+func (p *Provider) PutToken(ctx context.Context, key string, token TokenRepr) error {
+	return p.storage.Store(ctx, key, token)
 }
 
-func (p *Provider) GetToken(key string) (TokenRepr, bool) {
-	token, ok := p.storage.Get(key)
-	if !ok {
-		return TokenRepr{}, false
-	}
-	p.storage.Delete(key)
-	return token, true
+// GetToken returns the token once, it's removed from the storage.
+func (p *Provider) GetToken(ctx context.Context, key string) (TokenRepr, error) {
+	return p.storage.Pop(ctx, key)
 }
 
-func (p *Provider) GetPublicKey() (*rsa.PublicKey, error) {
-	if p.realmPublicKey != nil {
-		return p.realmPublicKey, nil
-	}
-
-	pKey, err := p.kc.fetchPublicKey()
+// GetPublicKeys returns the signing key with the given key ID, all signing keys are returned if the ID is empty.
+func (p *Provider) GetPublicKeys(ctx context.Context, kid string) ([]*rsa.PublicKey, error) {
+	endpoints, err := p.discover(ctx)
 	if err != nil {
 		return nil, err
 	}
-	p.realmPublicKey = pKey
-	return pKey, nil
-}
-
-func (p *Provider) revokeToken(refreshToken string) error {
-	return p.kc.revokeToken(refreshToken)
-}
 
-func (p *Provider) refreshToken(refreshToken string) (*TokenRepr, error) {
-	return p.kc.refreshToken(refreshToken)
+	return p.keys.get(ctx, endpoints.JWKSURL, kid)
 }
 
 func GetLoginPageHandler(prov *Provider, logger *zap.Logger) gin.HandlerFunc {
@@ -111,8 +118,14 @@ func GetLoginPageHandler(prov *Provider, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		oauthURL := prov.AuthCodeURL(state)
-		logger.Info("redirect to keycloak login page")
+		oauthURL, err := prov.AuthCodeURL(c.Request.Context(), state)
+		if err != nil {
+			logger.Error("failed to get the login page of the identity provider", zap.Error(err))
+			apiErrors.RaiseInternalErr(c, apiErrors.ErrAuthProviderUnavailable)
+			return
+		}
+
+		logger.Info("redirect to the identity provider login page")
 		c.Redirect(http.StatusSeeOther, oauthURL)
 	}
 }
@@ -127,10 +140,10 @@ type TokenRepr struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-// GetCallbackHandler is a handler for the callback from the Keycloak, it redirects to the FE url.
+// GetCallbackHandler is a handler for the callback from the identity provider, it redirects to the FE url.
 func GetCallbackHandler(prov *Provider, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		logger.Info("start to process authentication callback from keycloak")
+		logger.Info("start to process authentication callback from the identity provider")
 		code := c.Query("code")
 		state := c.Query("state")
 
@@ -164,7 +177,13 @@ func GetCallbackHandler(prov *Provider, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		prov.PutToken(statePayload.CodeChallenge, TokenRepr{AccessToken: token.AccessToken, RefreshToken: token.RefreshToken})
+		tokenRepr := TokenRepr{AccessToken: token.AccessToken, RefreshToken: token.RefreshToken}
+		if err = prov.PutToken(c.Request.Context(), statePayload.CodeChallenge, tokenRepr); err != nil {
+			logger.Error("failed to store tokens", zap.Error(err))
+			c.SetCookie("error", apiErrors.ErrAuthStoreToken.Error(), 1, "/", "", false, false)
+			c.Redirect(http.StatusBadRequest, statePayload.CallbackURL)
+			return
+		}
 		logger.Info("redirect to the client callback url")
 		c.Redirect(http.StatusSeeOther, statePayload.CallbackURL)
 	}
@@ -189,9 +208,14 @@ func PostTokenHandler(prov *Provider, logger *zap.Logger) gin.HandlerFunc {
 		codeChallenge := hex.EncodeToString(h.Sum(nil))
 
 		logger.Debug("try to get token from the storage")
-		token, ok := prov.GetToken(codeChallenge)
-		if !ok {
-			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrAuthMissingDataForCodeVerifier)
+		token, err := prov.GetToken(c.Request.Context(), codeChallenge)
+		if err != nil {
+			if errors.Is(err, ErrTokenNotFound) {
+				apiErrors.RaiseBadRequestErr(c, apiErrors.ErrAuthMissingDataForCodeVerifier)
+				return
+			}
+			logger.Error("failed to get tokens from the storage", zap.Error(err))
+			apiErrors.RaiseInternalErr(c, apiErrors.ErrAuthStoreToken)
 			return
 		}
 		logger.Info("return token to the client")
@@ -214,12 +238,12 @@ func PutLogoutHandler(prov *Provider, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		err = prov.revokeToken(req.RefreshToken)
+		err = prov.revokeToken(c.Request.Context(), req.RefreshToken)
 		if err != nil {
-			var keycloakErrorResponse KeycloakExternalError
+			var externalErr ExternalError
 			switch {
-			case errors.As(err, &keycloakErrorResponse):
-				apiErrors.RaiseBadRequestErr(c, keycloakErrorResponse)
+			case errors.As(err, &externalErr):
+				apiErrors.RaiseBadRequestErr(c, externalErr)
 			default:
 				logger.Error("failed to revoke token", zap.Error(err))
 				apiErrors.RaiseInternalErr(c, apiErrors.ErrAuthFailedLogout)
@@ -247,12 +271,12 @@ func PostRefreshHandler(prov *Provider, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		token, err := prov.refreshToken(req.RefreshToken)
+		token, err := prov.refreshToken(c.Request.Context(), req.RefreshToken)
 		if err != nil {
-			var keycloakErrorResponse KeycloakExternalError
+			var externalErr ExternalError
 			switch {
-			case errors.As(err, &keycloakErrorResponse):
-				apiErrors.RaiseBadRequestErr(c, keycloakErrorResponse)
+			case errors.As(err, &externalErr):
+				apiErrors.RaiseBadRequestErr(c, externalErr)
 			default:
 				logger.Error("failed to refresh token", zap.Error(err))
 				apiErrors.RaiseInternalErr(c, apiErrors.ErrAuthFailedRefreshToken)
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultJWKSRefreshInterval is the period of the keys refresh, the IdP can rotate the keys at any time.
+	DefaultJWKSRefreshInterval = time.Hour
+	// minJWKSRefreshInterval limits the refreshes triggered by the tokens with unknown key IDs.
+	minJWKSRefreshInterval = time.Minute
+)
+
+var (
+	ErrNoPublicKeys   = errors.New("no RSA public keys found in JWK set")
+	ErrUnknownKeyID   = errors.New("unknown key ID")
+	ErrJWKSNotDefined = errors.New("JWKS endpoint is not defined")
+)
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k JWK) rsaPublicKey() (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding N: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding E: %w", err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+// keySet keeps the RSA signing keys of the IdP by the key ID.
+// The keys are refreshed periodically and when a token is signed by an unknown key.
+// The lock is not held during the refresh, the concurrent refreshes are done by one request to the IdP.
+type keySet struct {
+	httpClient      *http.Client
+	refreshInterval time.Duration
+	now             func() time.Time
+	refreshes       singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	refreshedAt time.Time
+}
+
+func newKeySet(httpClient *http.Client, refreshInterval time.Duration) *keySet {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultJWKSRefreshInterval
+	}
+
+	return &keySet{httpClient: httpClient, refreshInterval: refreshInterval, now: time.Now}
+}
+
+// get returns the key with the given ID, all known keys are returned if the ID is empty.
+func (ks *keySet) get(ctx context.Context, jwksURL, kid string) ([]*rsa.PublicKey, error) {
+	keys, refreshedAt := ks.current()
+
+	if keys == nil || ks.now().Sub(refreshedAt) >= ks.refreshInterval {
+		fresh, err := ks.refresh(ctx, jwksURL)
+		switch {
+		case err == nil:
+			keys = fresh
+		case keys == nil:
+			return nil, err
+		}
+		// the stale keys are used if the IdP is not available
+	}
+
+	if kid == "" {
+		result := make([]*rsa.PublicKey, 0, len(keys))
+		for _, key := range keys {
+			result = append(result, key)
+		}
+		return result, nil
+	}
+
+	if key, ok := keys[kid]; ok {
+		return []*rsa.PublicKey{key}, nil
+	}
+
+	if _, refreshedAt = ks.current(); ks.now().Sub(refreshedAt) < minJWKSRefreshInterval {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, kid)
+	}
+
+	keys, err := ks.refresh(ctx, jwksURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := keys[kid]; ok {
+		return []*rsa.PublicKey{key}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, kid)
+}
+
+func (ks *keySet) current() (map[string]*rsa.PublicKey, time.Time) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	return ks.keys, ks.refreshedAt
+}
+
+// refresh fetches the keys, the failed attempts are counted too, so the IdP is not requested on every token.
+// The callers waiting for the same refresh share its result, the refresh is not canceled with one of them.
+func (ks *keySet) refresh(ctx context.Context, jwksURL string) (map[string]*rsa.PublicKey, error) {
+	ctx = context.WithoutCancel(ctx)
+	res, err, _ := ks.refreshes.Do(jwksURL, func() (any, error) {
+		keys, err := ks.fetch(ctx, jwksURL)
+
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+
+		ks.refreshedAt = ks.now()
+		if err != nil {
+			return nil, err
+		}
+		ks.keys = keys
+
+		return keys, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys, _ := res.(map[string]*rsa.PublicKey)
+	return keys, nil
+}
+
+func (ks *keySet) fetch(ctx context.Context, jwksURL string) (map[string]*rsa.PublicKey, error) {
+	if jwksURL == "" {
+		return nil, ErrJWKSNotDefined
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := ks.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching JWK set: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching JWK set: unexpected status %d", resp.StatusCode)
+	}
+
+	var jwkSet JWKSet
+	if err = json.NewDecoder(resp.Body).Decode(&jwkSet); err != nil {
+		return nil, fmt.Errorf("error decoding JWK set: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwkSet.Keys))
+	for _, jwk := range jwkSet.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, keyErr := jwk.rsaPublicKey()
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoPublicKeys
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves the keys, the keys can be rotated by the test.
+type jwksServer struct {
+	mu       sync.Mutex
+	keys     map[string]*rsa.PublicKey
+	requests int
+	fail     bool
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if s.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	set := JWKSet{}
+	for kid, key := range s.keys {
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA", Alg: "RS256", Use: "sig", Kid: kid,
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+func (s *jwksServer) set(keys map[string]*rsa.PublicKey, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.fail = fail
+}
+
+func generateKey(t *testing.T) *rsa.PublicKey {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &priv.PublicKey
+}
+
+func TestKeySetRotation(t *testing.T) {
+	ctx := t.Context()
+	first, second := generateKey(t), generateKey(t)
+
+	jwks := &jwksServer{keys: map[string]*rsa.PublicKey{"first": first}}
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ks := newKeySet(srv.Client(), time.Hour)
+	ks.now = func() time.Time { return now }
+
+	keys, err := ks.get(ctx, srv.URL, "first")
+	require.NoError(t, err)
+	assert.Equal(t, []*rsa.PublicKey{first}, keys)
+
+	// the new key is not requested again right after the refresh
+	jwks.set(map[string]*rsa.PublicKey{"first": first, "second": second}, false)
+	_, err = ks.get(ctx, srv.URL, "second")
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+	assert.Equal(t, 1, jwks.requests)
+
+	// the unknown key ID triggers the refresh
+	now = now.Add(minJWKSRefreshInterval)
+	keys, err = ks.get(ctx, srv.URL, "second")
+	require.NoError(t, err)
+	assert.Equal(t, []*rsa.PublicKey{second}, keys)
+	assert.Equal(t, 2, jwks.requests)
+
+	keys, err = ks.get(ctx, srv.URL, "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	// the keys are refreshed periodically, the removed key is not valid anymore
+	jwks.set(map[string]*rsa.PublicKey{"second": second}, false)
+	now = now.Add(time.Hour)
+	_, err = ks.get(ctx, srv.URL, "first")
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+	assert.Equal(t, 3, jwks.requests)
+
+	// the stale keys are used if the IdP is not available
+	jwks.set(nil, true)
+	now = now.Add(time.Hour)
+	keys, err = ks.get(ctx, srv.URL, "second")
+	require.NoError(t, err)
+	assert.Equal(t, []*rsa.PublicKey{second}, keys)
+}
+
+func TestKeySetErrors(t *testing.T) {
+	ctx := t.Context()
+
+	jwks := &jwksServer{fail: true}
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	_, err := newKeySet(srv.Client(), 0).get(ctx, srv.URL, "")
+	require.Error(t, err)
+
+	jwks.set(map[string]*rsa.PublicKey{}, false)
+	_, err = newKeySet(srv.Client(), 0).get(ctx, srv.URL, "")
+	require.ErrorIs(t, err, ErrNoPublicKeys)
+
+	_, err = newKeySet(srv.Client(), 0).get(ctx, "", "")
+	require.ErrorIs(t, err, ErrJWKSNotDefined)
+}
+
+func TestKeySetRefreshNotBlocking(t *testing.T) {
+	ctx := t.Context()
+	key := generateKey(t)
+
+	jwks := &jwksServer{keys: map[string]*rsa.PublicKey{"key": key}}
+	var blocked atomic.Bool
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if blocked.Load() {
+			close(started)
+			<-release
+		}
+		jwks.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ks := newKeySet(srv.Client(), time.Hour)
+	ks.now = func() time.Time { return now }
+
+	_, err := ks.get(ctx, srv.URL, "key")
+	require.NoError(t, err)
+	now = now.Add(minJWKSRefreshInterval)
+
+	// the unknown key ID triggers the refresh, which waits for the IdP
+	blocked.Store(true)
+	done := make(chan error)
+	go func() {
+		_, getErr := ks.get(ctx, srv.URL, "unknown")
+		done <- getErr
+	}()
+	<-started
+
+	// the known key is returned while the refresh is in progress
+	keys, err := ks.get(ctx, srv.URL, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []*rsa.PublicKey{key}, keys)
+
+	close(release)
+	require.ErrorIs(t, <-done, ErrUnknownKeyID)
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrRevocationNotSupported = errors.New("token revocation is not supported by the identity provider")
+	ErrTokenIssuer            = errors.New("token is issued by another issuer")
+	ErrTokenAudience          = errors.New("token is issued for another client")
+)
+
+// OIDCConfig is the configuration of the OpenID Connect identity provider.
+type OIDCConfig struct {
+	// Issuer is used to discover the endpoints from {issuer}/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Endpoints are used instead of the discovery if they are set.
+	Endpoints *Endpoints
+}
+
+// Endpoints of the identity provider.
+type Endpoints struct {
+	AuthURL       string `json:"authorization_endpoint"`
+	TokenURL      string `json:"token_endpoint"`
+	DeviceAuthURL string `json:"device_authorization_endpoint"`
+	UserInfoURL   string `json:"userinfo_endpoint"`
+	JWKSURL       string `json:"jwks_uri"`
+	RevocationURL string `json:"revocation_endpoint"`
+	// LogoutURL ends the user session by the refresh token, it's preferred to the RevocationURL.
+	// It's not a standard OIDC endpoint, it's set for Keycloak.
+	LogoutURL string `json:"-"`
+}
+
+// ExternalError is the OAuth 2.0 error response of the identity provider.
+type ExternalError struct {
+	ErrorOrig        string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e ExternalError) Error() string {
+	if e.ErrorDescription == "" {
+		return e.ErrorOrig
+	}
+	return e.ErrorDescription
+}
+
+// discover returns the endpoints of the identity provider, the discovery is done once on the first successful call.
+func (p *Provider) discover(ctx context.Context) (*Endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	op, err := oidc.NewProvider(oidc.ClientContext(ctx, p.httpClient), p.conf.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the identity provider: %w", err)
+	}
+
+	endpoints := &Endpoints{}
+	if err = op.Claims(endpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse the identity provider configuration: %w", err)
+	}
+	p.endpoints = endpoints
+
+	return endpoints, nil
+}
+
+// ValidateClaims checks that the token is issued by the identity provider for the configured client.
+// The client is accepted in the audience or in the authorized party (azp) claim,
+// the access tokens of Keycloak contain the client only in the azp claim.
+func (p *Provider) ValidateClaims(claims jwt.Claims) error {
+	if p.conf == nil || p.conf.Issuer == "" {
+		return ErrIssuerNotDefined
+	}
+
+	if err := jwt.NewValidator(jwt.WithIssuer(p.conf.Issuer)).Validate(claims); err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenIssuer, err)
+	}
+
+	audience, err := claims.GetAudience()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenAudience, err)
+	}
+	if slices.Contains(audience, p.conf.ClientID) {
+		return nil
+	}
+
+	if mapClaims, ok := claims.(jwt.MapClaims); ok {
+		if azp, _ := mapClaims["azp"].(string); azp != "" && azp == p.conf.ClientID {
+			return nil
+		}
+	}
+
+	return ErrTokenAudience
+}
+
+func (p *Provider) oauth2Config(ctx context.Context) (*oauth2.Config, error) {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.conf.ClientID,
+		ClientSecret: p.conf.ClientSecret,
+		RedirectURL:  p.redirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL: endpoints.AuthURL, DeviceAuthURL: endpoints.DeviceAuthURL, TokenURL: endpoints.TokenURL,
+		},
+		Scopes: []string{oidc.ScopeOpenID, "profile", "email"},
+	}, nil
+}
+
+func (p *Provider) revokeToken(ctx context.Context, refreshToken string) error {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := url.Values{}
+	data.Set("client_id", p.conf.ClientID)
+	data.Set("client_secret", p.conf.ClientSecret)
+
+	var endpoint string
+	switch {
+	case endpoints.LogoutURL != "":
+		endpoint = endpoints.LogoutURL
+		data.Set("refresh_token", refreshToken)
+	case endpoints.RevocationURL != "":
+		endpoint = endpoints.RevocationURL
+		data.Set("token", refreshToken)
+		data.Set("token_type_hint", "refresh_token")
+	default:
+		return ErrRevocationNotSupported
+	}
+
+	resp, err := p.postForm(ctx, endpoint, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return externalError(resp)
+}
+
+func (p *Provider) refreshToken(ctx context.Context, refreshToken string) (*TokenRepr, error) {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("client_id", p.conf.ClientID)
+	data.Set("client_secret", p.conf.ClientSecret)
+	data.Set("refresh_token", refreshToken)
+
+	resp, err := p.postForm(ctx, endpoints.TokenURL, data)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err = externalError(resp); err != nil {
+		return nil, err
+	}
+
+	var token TokenRepr
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (p *Provider) postForm(ctx context.Context, endpoint string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return p.httpClient.Do(req)
+}
+
+// externalError returns the error of the identity provider for 4xx responses.
+func externalError(resp *http.Response) error {
+	if resp.StatusCode < http.StatusBadRequest || resp.StatusCode >= http.StatusInternalServerError {
+		return nil
+	}
+
+	var errResp ExternalError
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		return err
+	}
+
+	return errResp
+}
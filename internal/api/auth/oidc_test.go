@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIdP(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"jwks_uri":               srv.URL + "/jwks",
+			"revocation_endpoint":    srv.URL + "/revoke",
+		}))
+	})
+	mux.HandleFunc("/revoke", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		if r.PostForm.Get("token") != "refresh" || r.PostForm.Get("token_type_hint") != "refresh_token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_request","error_description":"unknown token"}`))
+		}
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		if r.PostForm.Get("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Token is not active"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh"}`))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestProviderDiscovery(t *testing.T) {
+	ctx := t.Context()
+	srv := newTestIdP(t)
+
+	prov, err := NewProvider(&OIDCConfig{Issuer: srv.URL, ClientID: "client"}, "http://localhost", "", 0, nil)
+	require.NoError(t, err)
+
+	authURL, err := prov.AuthCodeURL(ctx, "state")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(authURL, srv.URL+"/authorize?"))
+	assert.Contains(t, authURL, "redirect_uri=http%3A%2F%2Flocalhost%2Fauth%2Fcallback")
+
+	require.NoError(t, prov.revokeToken(ctx, "refresh"))
+
+	var externalErr ExternalError
+	require.ErrorAs(t, prov.revokeToken(ctx, "unknown"), &externalErr)
+	assert.Equal(t, "unknown token", externalErr.Error())
+
+	token, err := prov.refreshToken(ctx, "refresh")
+	require.NoError(t, err)
+	assert.Equal(t, &TokenRepr{AccessToken: "new-access", RefreshToken: "new-refresh"}, token)
+
+	_, err = prov.refreshToken(ctx, "expired")
+	require.ErrorAs(t, err, &externalErr)
+	assert.Equal(t, "invalid_grant", externalErr.ErrorOrig)
+}
+
+func TestProviderDiscoveryFailure(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	prov, err := NewProvider(&OIDCConfig{Issuer: srv.URL}, "", "", 0, nil)
+	require.NoError(t, err)
+
+	_, err = prov.AuthCodeURL(t.Context(), "state")
+	require.Error(t, err)
+
+	_, err = NewProvider(&OIDCConfig{}, "", "", 0, nil)
+	require.ErrorIs(t, err, ErrIssuerNotDefined)
+}
+
+func TestKeycloakConfig(t *testing.T) {
+	conf := KeycloakConfig("https://kc.example.com", "realm", "client", "secret")
+	assert.Equal(t, "https://kc.example.com/realms/realm", conf.Issuer)
+	assert.Equal(t, "https://kc.example.com/realms/realm/protocol/openid-connect/certs", conf.Endpoints.JWKSURL)
+	assert.Equal(t, "https://kc.example.com/realms/realm/protocol/openid-connect/logout", conf.Endpoints.LogoutURL)
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Role is an application role, the higher value has more privileges.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleCreator
+	RoleOperator
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleCreator:
+		return "sd_creators"
+	case RoleOperator:
+		return "sd_operators"
+	case RoleAdmin:
+		return "sd_admins"
+	case RoleNone:
+	}
+
+	return "none"
+}
+
+// DefaultGroupsClaim is the JWT claim with the user groups.
+const DefaultGroupsClaim = "groups"
+
+// RoleGroups maps IdP group names from the JWT groups claim to the application roles.
+type RoleGroups struct {
+	Creators  string
+	Operators string
+	Admins    string
+	// Claim is the path of the groups claim, the nested claims are separated by dots, as example, "realm_access.roles".
+	// The "groups" claim is used if it's empty.
+	Claim string
+}
+
+// ClaimPath returns the path of the groups claim.
+func (rg *RoleGroups) ClaimPath() []string {
+	if rg.Claim == "" {
+		return []string{DefaultGroupsClaim}
+	}
+	return strings.Split(rg.Claim, ".")
+}
+
+// RoleFromGroups returns the highest privilege role for the given groups.
+// The precedence is sd_admins > sd_operators > sd_creators.
+func (rg *RoleGroups) RoleFromGroups(groups []string) Role {
+	role := RoleNone
+	for _, group := range groups {
+		var current Role
+		switch group {
+		case "":
+			continue
+		case rg.Admins:
+			current = RoleAdmin
+		case rg.Operators:
+			current = RoleOperator
+		case rg.Creators:
+			current = RoleCreator
+		}
+
+		if current > role {
+			role = current
+		}
+	}
+
+	return role
+}
+
+const (
+	roleContextKey = "role"
+	userContextKey = "user_id"
+)
+
+// SetIdentity stores the authenticated user and its role in the request context.
+func SetIdentity(c *gin.Context, role Role, userID string) {
+	c.Set(roleContextKey, role)
+	c.Set(userContextKey, userID)
+}
+
+// RoleFromContext returns the role of the authenticated user.
+// The role is not set if the handler is used without the authentication middleware, it's processed as anonymous.
+// If the authentication is disabled, the middleware sets the sd_admins role to keep the legacy behaviour.
+func RoleFromContext(c *gin.Context) Role {
+	val, exists := c.Get(roleContextKey)
+	if !exists {
+		return RoleNone
+	}
+
+	role, ok := val.(Role)
+	if !ok {
+		return RoleNone
+	}
+
+	return role
+}
+
+// UserFromContext returns the user ID of the authenticated user, it's empty for anonymous requests.
+func UserFromContext(c *gin.Context) string {
+	return c.GetString(userContextKey)
+}
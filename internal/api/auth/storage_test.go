@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTokenStore(t *testing.T) {
+	store, err := NewTokenStore("", nil)
+	require.NoError(t, err)
+	internal, ok := store.(*internalStorage)
+	require.True(t, ok)
+	assert.Equal(t, defaultTokenTTL, internal.ttl)
+
+	store, err = NewTokenStore("internal?ttl=30s", nil)
+	require.NoError(t, err)
+	internal, ok = store.(*internalStorage)
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, internal.ttl)
+
+	store, err = NewTokenStore("redis://localhost:6379/2?ttl=1m", nil)
+	require.NoError(t, err)
+	r, ok := store.(*redisStorage)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, r.ttl)
+	assert.Equal(t, 2, r.client.Options().DB)
+
+	_, err = NewTokenStore(StorePostgres, nil)
+	require.Error(t, err)
+
+	_, err = NewTokenStore("internal?ttl=-1s", nil)
+	require.Error(t, err)
+
+	_, err = NewTokenStore("memcached://localhost", nil)
+	require.ErrorIs(t, err, ErrUnsupportedTokenStore)
+}
+
+func TestInternalStorage(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newInternalStorage(time.Minute)
+	store.now = func() time.Time { return now }
+
+	token := TokenRepr{AccessToken: "access", RefreshToken: "refresh"}
+	require.NoError(t, store.Store(ctx, "first", token))
+
+	stored, err := store.Pop(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, token, stored)
+
+	// the token is read only once
+	_, err = store.Pop(ctx, "first")
+	require.ErrorIs(t, err, ErrTokenNotFound)
+
+	require.NoError(t, store.Store(ctx, "expired", token))
+	now = now.Add(time.Minute)
+	_, err = store.Pop(ctx, "expired")
+	require.ErrorIs(t, err, ErrTokenNotFound)
+
+	// the expired tokens are removed on the next store
+	require.NoError(t, store.Store(ctx, "old", token))
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, store.Store(ctx, "new", token))
+	assert.Len(t, store.m, 1)
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+// Scope is a permission of the API token.
+type Scope string
+
+const (
+	ScopeIncidentCreate    Scope = "incident:create"
+	ScopeIncidentUpdate    Scope = "incident:update"
+	ScopeMaintenanceCreate Scope = "maintenance:create"
+	ScopeMaintenanceUpdate Scope = "maintenance:update"
+	ScopeInfoCreate        Scope = "info:create"
+	ScopeInfoUpdate        Scope = "info:update"
+	// ScopeComponentsManage allows to create, update and delete components and regions.
+	ScopeComponentsManage Scope = "components:manage"
+)
+
+const (
+	// APITokenPrefix distinguishes the API tokens from JWT.
+	APITokenPrefix = "sdt_"
+	// apiTokenRole is the role of the API tokens, the permissions are limited by the scopes.
+	apiTokenRole        = RoleOperator
+	apiTokenBytes       = 32
+	apiTokenContextKey  = "api_token"
+	apiTokenUserPattern = "token:"
+)
+
+// Scopes returns all supported scopes.
+func Scopes() []Scope {
+	return []Scope{
+		ScopeIncidentCreate, ScopeIncidentUpdate,
+		ScopeMaintenanceCreate, ScopeMaintenanceUpdate,
+		ScopeInfoCreate, ScopeInfoUpdate,
+		ScopeComponentsManage,
+	}
+}
+
+func IsValidScope(scope string) bool {
+	return slices.Contains(Scopes(), Scope(scope))
+}
+
+// CreateScope returns the scope to create the event of the type.
+func CreateScope(eventType string) Scope {
+	switch eventType {
+	case event.TypeMaintenance:
+		return ScopeMaintenanceCreate
+	case event.TypeInformation:
+		return ScopeInfoCreate
+	}
+	return ScopeIncidentCreate
+}
+
+// UpdateScope returns the scope to update the event of the type.
+func UpdateScope(eventType string) Scope {
+	switch eventType {
+	case event.TypeMaintenance:
+		return ScopeMaintenanceUpdate
+	case event.TypeInformation:
+		return ScopeInfoUpdate
+	}
+	return ScopeIncidentUpdate
+}
+
+// GenerateAPIToken returns a new random token, it's shown to the user only once.
+func GenerateAPIToken() (string, error) {
+	b := make([]byte, apiTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return APITokenPrefix + hex.EncodeToString(b), nil
+}
+
+// HashAPIToken returns the SHA256 hash of the token, only the hash is stored in the database.
+func HashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// TokenAccess is the permissions of the API token used for the request.
+type TokenAccess struct {
+	ID     uint
+	Name   string
+	Scopes []Scope
+	// Components and Regions restrict the events and components, the empty lists mean no restrictions.
+	Components []uint
+	Regions    []string
+}
+
+func NewTokenAccess(token *db.APIToken) *TokenAccess {
+	scopes := make([]Scope, 0, len(token.ScopeList()))
+	for _, s := range token.ScopeList() {
+		scopes = append(scopes, Scope(s))
+	}
+
+	return &TokenAccess{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     scopes,
+		Components: token.ComponentIDs(),
+		Regions:    token.RegionList(),
+	}
+}
+
+// HasScope checks that the token has any of the scopes.
+func (ta *TokenAccess) HasScope(scopes ...Scope) bool {
+	for _, s := range scopes {
+		if slices.Contains(ta.Scopes, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Restricted returns true if the token is limited to some components or regions.
+func (ta *TokenAccess) Restricted() bool {
+	return len(ta.Components) > 0 || len(ta.Regions) > 0
+}
+
+// AllowsComponent checks the component and region restrictions of the token.
+func (ta *TokenAccess) AllowsComponent(comp *db.Component) bool {
+	if len(ta.Components) > 0 && !slices.Contains(ta.Components, comp.ID) {
+		return false
+	}
+	if len(ta.Regions) > 0 && !slices.Contains(ta.Regions, comp.Region()) {
+		return false
+	}
+	return true
+}
+
+// SetTokenIdentity stores the API token in the request context.
+// The token has the sd_operators role, its permissions are limited by the scopes.
+func SetTokenIdentity(c *gin.Context, access *TokenAccess) {
+	c.Set(apiTokenContextKey, access)
+	SetIdentity(c, apiTokenRole, apiTokenUserPattern+access.Name)
+}
+
+// TokenFromContext returns the API token of the request, it's nil for the user requests.
+func TokenFromContext(c *gin.Context) *TokenAccess {
+	val, exists := c.Get(apiTokenContextKey)
+	if !exists {
+		return nil
+	}
+
+	access, ok := val.(*TokenAccess)
+	if !ok {
+		return nil
+	}
+
+	return access
+}
+
+// CheckTokenAccess checks that the API token of the request has the scope and can change the components.
+// The user requests are not checked, their permissions are defined by the role.
+func CheckTokenAccess(c *gin.Context, scope Scope, components []db.Component) error {
+	access := TokenFromContext(c)
+	if access == nil {
+		return nil
+	}
+
+	if !access.HasScope(scope) {
+		return apiErrors.ErrAuthTokenScope
+	}
+
+	for i := range components {
+		if !access.AllowsComponent(&components[i]) {
+			return apiErrors.ErrAuthTokenComponent
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestGenerateAPIToken(t *testing.T) {
+	first, err := GenerateAPIToken()
+	require.NoError(t, err)
+	second, err := GenerateAPIToken()
+	require.NoError(t, err)
+
+	assert.True(t, IsAPIToken(first))
+	assert.True(t, strings.HasPrefix(first, APITokenPrefix))
+	assert.NotEqual(t, first, second)
+
+	assert.Len(t, HashAPIToken(first), 64)
+	assert.Equal(t, HashAPIToken(first), HashAPIToken(first))
+	assert.NotEqual(t, HashAPIToken(first), HashAPIToken(second))
+}
+
+func TestEventScopes(t *testing.T) {
+	assert.Equal(t, ScopeIncidentCreate, CreateScope(event.TypeIncident))
+	assert.Equal(t, ScopeMaintenanceCreate, CreateScope(event.TypeMaintenance))
+	assert.Equal(t, ScopeInfoCreate, CreateScope(event.TypeInformation))
+	assert.Equal(t, ScopeIncidentUpdate, UpdateScope(event.TypeIncident))
+	assert.Equal(t, ScopeMaintenanceUpdate, UpdateScope(event.TypeMaintenance))
+	assert.Equal(t, ScopeInfoUpdate, UpdateScope(event.TypeInformation))
+
+	assert.True(t, IsValidScope("components:manage"))
+	assert.False(t, IsValidScope("components:delete"))
+}
+
+func TestCheckTokenAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	compEU := db.Component{ID: 1, Attrs: []db.ComponentAttr{{Name: "region", Value: "EU-DE"}}}
+	compNL := db.Component{ID: 2, Attrs: []db.ComponentAttr{{Name: "region", Value: "EU-NL"}}}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	SetIdentity(c, RoleOperator, "user")
+	require.NoError(t, CheckTokenAccess(c, ScopeIncidentCreate, []db.Component{compEU, compNL}))
+
+	access := NewTokenAccess(&db.APIToken{
+		ID: 1, Name: "ci", Scopes: "incident:create,incident:update", Regions: "EU-DE",
+	})
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	SetTokenIdentity(c, access)
+
+	assert.Equal(t, access, TokenFromContext(c))
+	assert.Equal(t, RoleOperator, RoleFromContext(c))
+	assert.Equal(t, "token:ci", UserFromContext(c))
+
+	require.NoError(t, CheckTokenAccess(c, ScopeIncidentUpdate, []db.Component{compEU}))
+	require.ErrorIs(t, CheckTokenAccess(c, ScopeMaintenanceCreate, nil), apiErrors.ErrAuthTokenScope)
+	require.ErrorIs(t,
+		CheckTokenAccess(c, ScopeIncidentCreate, []db.Component{compEU, compNL}), apiErrors.ErrAuthTokenComponent,
+	)
+
+	access = NewTokenAccess(&db.APIToken{ID: 2, Name: "ci", Scopes: "incident:create", Components: "2"})
+	assert.True(t, access.Restricted())
+	assert.False(t, access.AllowsComponent(&compEU))
+	assert.True(t, access.AllowsComponent(&compNL))
+}
@@ -0,0 +1,5 @@
+package errors
+
+import "errors"
+
+var ErrAuditQueryInvalidFormat = errors.New("audit log query has invalid format")
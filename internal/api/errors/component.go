@@ -17,3 +17,7 @@ var ErrComponentAttrInvalidFormat = errors.New("component attribute has invalid
 var ErrComponentRegionAttrMissing = errors.New("component attribute region is missing or invalid")
 var ErrComponentTypeAttrMissing = errors.New("component attribute type is missing or invalid")
 var ErrComponentCategoryAttrMissing = errors.New("component attribute category is missing or invalid")
+var ErrComponentInUse = errors.New("component is affected by active events, finish the events or extract the component")
+var ErrAvailabilityQueryInvalidFormat = errors.New("availability query has invalid format")
+var ErrAvailabilityQueryInvalidPeriod = errors.New("availability query has invalid period, from must be before to")
+var ErrAvailabilityQueryTooManyPeriods = errors.New("availability query has too many periods, increase the granularity")
@@ -32,8 +32,36 @@ var ErrIncidentPatchOpenedStartDate = errors.New("can not change start date for
 var ErrIncidentPatchOpenedEndDateMissing = errors.New("wrong end date with resolved status")
 var ErrIncidentPatchImpactStatusWrong = errors.New("wrong status for changing impact")
 var ErrIncidentPatchImpactToZeroForbidden = errors.New("can not change impact to 0")
+var ErrIncidentMoveNotOpened = errors.New("components can be moved only between opened incidents")
+var ErrIncidentMoveSameIncident = errors.New("components can not be moved to the same incident")
+var ErrIncidentMergeNotOpened = errors.New("only opened incidents can be merged")
+var ErrIncidentMergeSameIncident = errors.New("incident can not be merged into itself")
+var ErrIncidentComponentImpactsWrongType = errors.New("component impacts can be set only for regular incidents")
+var ErrIncidentComponentImpactsUnknownComponent = errors.New("component impact is set for unknown component")
+var ErrIncidentComponentImpactsDuplicate = errors.New("component impact is set twice for the component")
+var ErrIncidentPatchComponentImpactsWithImpact = errors.New("can not change impact and component impacts together")
 
 var ErrMaintenanceEndDateEmpty = errors.New("maintenance end_date is empty")
 
+// Errors for the maintenance review workflow
+
+var ErrMaintenanceCreationForbiddenType = errors.New("only maintenance events can be created with the current role")
+var ErrMaintenanceDescriptionEmpty = errors.New("maintenance description is empty")
+var ErrMaintenanceContactEmailEmpty = errors.New("maintenance contact_email is empty")
+var ErrMaintenanceContactEmailInvalid = errors.New("maintenance contact_email has an invalid format")
+var ErrMaintenanceStartDateInPast = errors.New("maintenance start_date should be in the future")
+var ErrMaintenanceEndDateBeforeStart = errors.New("maintenance end_date should be after start_date")
+var ErrMaintenancePatchForbidden = errors.New(
+	"maintenance can be modified or cancelled only by its creator in status 'pending review'",
+)
+var ErrMaintenanceReviewStatusForbidden = errors.New(
+	"status 'pending review' and 'reviewed' can not be set manually, use the approve endpoint",
+)
+var ErrMaintenanceApproveWrongType = errors.New("only maintenance events can be approved")
+var ErrMaintenanceNotPendingReview = errors.New("maintenance is not in 'pending review' status")
+var ErrMaintenanceVersionConflict = errors.New("maintenance was modified, please review the latest version")
+
 var ErrUpdateTextEmpty = errors.New("text field is required")
 var ErrUpdateDSNotExist = errors.New("update does not exist")
+
+var ErrStreamLastEventIDInvalid = errors.New("last event id has an invalid format")
@@ -0,0 +1,8 @@
+package errors
+
+import "errors"
+
+var ErrMaintenanceSeriesDSNotExist = errors.New("maintenance series does not exist")
+var ErrMaintenanceSeriesInvalidID = errors.New("maintenance series id has invalid format")
+var ErrMaintenanceSeriesInvalidRule = errors.New("maintenance series recurrence rule is invalid")
+var ErrMaintenanceSeriesCancelled = errors.New("maintenance series is cancelled and can not be modified")
@@ -0,0 +1,9 @@
+package errors
+
+import "errors"
+
+var ErrRegionDSNotExist = errors.New("region does not exist")
+var ErrRegionExist = errors.New("region already exists")
+var ErrRegionInvalidID = errors.New("region id has invalid format")
+var ErrRegionInvalidName = errors.New("region name is empty or too long")
+var ErrRegionInUse = errors.New("region is used by components")
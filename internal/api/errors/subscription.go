@@ -0,0 +1,8 @@
+package errors
+
+import "errors"
+
+var ErrSubscriptionDSNotExist = errors.New("subscription does not exist or is expired")
+var ErrSubscriptionInvalidEmail = errors.New("subscription email is invalid")
+var ErrSubscriptionEmpty = errors.New("subscription should have at least one component or region")
+var ErrSubscriptionTokenEmpty = errors.New("subscription token is empty")
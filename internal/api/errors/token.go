@@ -0,0 +1,12 @@
+package errors
+
+import "errors"
+
+var ErrAPITokenDSNotExist = errors.New("api token does not exist")
+var ErrAPITokenInvalidID = errors.New("api token id has invalid format")
+var ErrAPITokenInvalidScope = errors.New("api token scope is invalid")
+var ErrAPITokenInvalidExpiry = errors.New("api token expiration date must be in the future")
+
+var ErrAuthTokenScope = errors.New("api token does not have the required scope")
+var ErrAuthTokenComponent = errors.New("api token is not allowed to change the component")
+var ErrAuthTokenStorage = errors.New("failed to check the api token")
@@ -0,0 +1,9 @@
+package errors
+
+import "errors"
+
+var ErrWebhookDSNotExist = errors.New("webhook does not exist")
+var ErrWebhookInvalidID = errors.New("webhook id has invalid format")
+var ErrWebhookInvalidURL = errors.New("webhook url is invalid, only http and https schemes are supported")
+var ErrWebhookInvalidEventType = errors.New("webhook event type is invalid")
+var ErrWebhookDeliveryInvalidStatus = errors.New("webhook delivery status is invalid")
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -15,11 +17,23 @@ import (
 	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
 	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
 	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/cache"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
 )
 
 const eventContextKey = "event"
 
+// apiTokenTouchInterval limits the updates of the last usage of the API token, the frequent requests
+// don't write to the database on each request.
+const apiTokenTouchInterval = time.Minute
+
+const (
+	cacheStatusHeader = "X-Cache"
+	cacheHit          = "HIT"
+	cacheMiss         = "MISS"
+)
+
 func ValidateComponentsMW(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger.Info("start to validate given components")
@@ -53,8 +67,12 @@ func ValidateComponentsMW(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 	}
 }
 
-func parseToken(tokenString string, secretKey string, prov *auth.Provider, logger *zap.Logger) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// parseToken validates the signature of the token.
+// The tokens signed by the identity provider must be issued by the configured issuer for the configured client.
+func parseToken(
+	ctx context.Context, tokenString string, secretKey string, prov *auth.Provider, logger *zap.Logger,
+) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		switch token.Method.(type) {
 		case *jwt.SigningMethodHMAC:
 			logger.Info("HMAC token detected, using secret key for validation")
@@ -64,23 +82,47 @@ func parseToken(tokenString string, secretKey string, prov *auth.Provider, logge
 			return []byte(secretKey), nil
 
 		case *jwt.SigningMethodRSA:
-			logger.Info("RSA token detected, using Keycloak public key for validation")
-			key, err := prov.GetPublicKey()
+			logger.Info("RSA token detected, using the identity provider public keys for validation")
+			kid, _ := token.Header["kid"].(string)
+			keys, err := prov.GetPublicKeys(ctx, kid)
 			if err != nil {
 				return nil, fmt.Errorf("error while getting public key: %w", err)
 			}
-			return key, nil
+
+			keySet := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, 0, len(keys))}
+			for _, key := range keys {
+				keySet.Keys = append(keySet.Keys, key)
+			}
+			return keySet, nil
 
 		default:
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+		if err = prov.ValidateClaims(token.Claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
 }
 
-func AuthenticationMW(prov *auth.Provider, logger *zap.Logger, secretKey string, userAuthGroup string) gin.HandlerFunc {
+// AuthenticationMW validates the token and stores the user identity and role in the request context.
+// API tokens (with the "sdt_" prefix) are used by integrations, their permissions are limited by the scopes.
+// HMAC tokens (signed by the secret key) are deprecated, they have the sd_admins role.
+// RSA tokens must contain one of the configured groups in the groups claim.
+func AuthenticationMW(
+	prov *auth.Provider, dbInst *db.DB, logger *zap.Logger, secretKey string, groups *auth.RoleGroups,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if prov.Disabled {
 			logger.Info("authentication is disabled")
+			auth.SetIdentity(c, auth.RoleAdmin, "")
 			c.Next()
 			return
 		}
@@ -93,70 +135,216 @@ func AuthenticationMW(prov *auth.Provider, logger *zap.Logger, secretKey string,
 			return
 		}
 
-		rawToken := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := parseToken(rawToken, secretKey, prov, logger)
-
-		if err != nil {
-			logger.Error("token parsing error", zap.Error(err))
+		if err := authenticate(c, authHeader, secretKey, prov, dbInst, groups, logger); err != nil {
+			if errors.Is(err, apiErrors.ErrAuthTokenStorage) {
+				apiErrors.RaiseInternalErr(c, apiErrors.ErrAuthTokenStorage)
+				return
+			}
 			apiErrors.RaiseNotAuthorizedErr(c, apiErrors.ErrAuthNotAuthenticated)
 			return
 		}
 
-		if !token.Valid {
-			logger.Error("token validation error", zap.Error(err))
-			apiErrors.RaiseNotAuthorizedErr(c, apiErrors.ErrAuthNotAuthenticated)
+		c.Next()
+	}
+}
+
+// OptionalAuthenticationMW is used for public endpoints.
+// It stores the user identity if a valid token is present, otherwise the request is processed as anonymous.
+func OptionalAuthenticationMW(
+	prov *auth.Provider, dbInst *db.DB, logger *zap.Logger, secretKey string, groups *auth.RoleGroups,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if prov.Disabled {
+			auth.SetIdentity(c, auth.RoleAdmin, "")
+			c.Next()
 			return
 		}
 
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); ok && !isAuthGroupInClaims(token, logger, userAuthGroup) {
-			apiErrors.RaiseNotAuthorizedErr(c, apiErrors.ErrAuthNotAuthenticated)
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			auth.SetIdentity(c, auth.RoleNone, "")
+			c.Next()
 			return
 		}
+
+		if err := authenticate(c, authHeader, secretKey, prov, dbInst, groups, logger); err != nil {
+			if errors.Is(err, apiErrors.ErrAuthTokenStorage) {
+				apiErrors.RaiseInternalErr(c, apiErrors.ErrAuthTokenStorage)
+				return
+			}
+			logger.Info("the token is invalid, process the request as anonymous")
+			auth.SetIdentity(c, auth.RoleNone, "")
+		}
+
 		c.Next()
 	}
 }
 
-func isAuthGroupInClaims(token *jwt.Token, logger *zap.Logger, userAuthGroup string) bool {
-	// Check group authorization if authGroup is configured
-	claims, ok := token.Claims.(jwt.MapClaims)
+// RequireRoleMW checks that the authenticated user has at least the given role.
+// The API tokens are checked by the scopes instead of the role, the token must have any of the given scopes.
+// The API tokens are forbidden if the scopes are not set.
+// It must be used after AuthenticationMW.
+func RequireRoleMW(minRole auth.Role, logger *zap.Logger, scopes ...auth.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := auth.TokenFromContext(c); token != nil {
+			if !token.HasScope(scopes...) {
+				logger.Warn("API token has insufficient scopes",
+					zap.String("token", token.Name), zap.Any("required_scopes", scopes),
+				)
+				apiErrors.RaiseForbiddenErr(c, apiErrors.ErrAuthTokenScope)
+				return
+			}
+
+			c.Next()
+			return
+		}
+
+		role := auth.RoleFromContext(c)
+		if role < minRole {
+			logger.Warn("user has insufficient role",
+				zap.String("role", role.String()), zap.String("required_role", minRole.String()),
+				zap.String("user_id", auth.UserFromContext(c)),
+			)
+			apiErrors.RaiseForbiddenErr(c, apiErrors.ErrAuthForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticate validates the token from the Authorization header and stores the identity in the request context.
+func authenticate(
+	c *gin.Context, authHeader, secretKey string, prov *auth.Provider, dbInst *db.DB, groups *auth.RoleGroups,
+	logger *zap.Logger,
+) error {
+	rawToken := strings.TrimPrefix(authHeader, "Bearer ")
+	if auth.IsAPIToken(rawToken) {
+		access, err := authenticateAPIToken(rawToken, dbInst, logger)
+		if err != nil {
+			return err
+		}
+
+		auth.SetTokenIdentity(c, access)
+		return nil
+	}
+
+	token, err := parseToken(c.Request.Context(), rawToken, secretKey, prov, logger)
+	if err != nil {
+		logger.Error("token parsing error", zap.Error(err))
+		return err
+	}
+
+	if !token.Valid {
+		logger.Error("token validation error")
+		return apiErrors.ErrAuthNotAuthenticated
+	}
+
+	userID, _ := token.Claims.GetSubject()
+
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		logger.Warn("the token signed by the secret key is deprecated, use the API tokens instead",
+			zap.String("user_id", userID))
+		auth.SetIdentity(c, auth.RoleAdmin, userID)
+		return nil
+	}
+
+	role := getRoleFromClaims(token, logger, groups)
+	if role == auth.RoleNone {
+		return apiErrors.ErrAuthNotAuthenticated
+	}
+
+	logger.Info("user authenticated", zap.String("user_id", userID), zap.String("role", role.String()))
+	auth.SetIdentity(c, role, userID)
+	return nil
+}
+
+// authenticateAPIToken finds the API token by its hash, the revoked and expired tokens are rejected.
+// The failed lookup of the token returns ErrAuthTokenStorage, so the request isn't rejected as unauthenticated.
+func authenticateAPIToken(rawToken string, dbInst *db.DB, logger *zap.Logger) (*auth.TokenAccess, error) {
+	if dbInst == nil {
+		return nil, apiErrors.ErrAuthNotAuthenticated
+	}
+
+	token, err := dbInst.GetAPITokenByHash(auth.HashAPIToken(rawToken))
+	if err != nil {
+		if errors.Is(err, db.ErrDBAPITokenDSNotExist) {
+			logger.Warn("API token is not found")
+			return nil, apiErrors.ErrAuthNotAuthenticated
+		}
+		logger.Error("failed to get API token", zap.Error(err))
+		return nil, fmt.Errorf("%w: %w", apiErrors.ErrAuthTokenStorage, err)
+	}
+
+	now := time.Now().UTC()
+	if !token.IsActive(now) {
+		logger.Warn("API token is revoked or expired", zap.Uint("token_id", token.ID), zap.String("token", token.Name))
+		return nil, apiErrors.ErrAuthNotAuthenticated
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if err = dbInst.TouchAPIToken(token.ID); err != nil {
+			logger.Error("failed to update the last usage of API token", zap.Uint("token_id", token.ID), zap.Error(err))
+		}
+	}
+
+	logger.Info("API token authenticated", zap.Uint("token_id", token.ID), zap.String("token", token.Name))
+	return auth.NewTokenAccess(token), nil
+}
+
+// getRoleFromClaims returns the highest role for the groups from the configured groups claim.
+func getRoleFromClaims(token *jwt.Token, logger *zap.Logger, groups *auth.RoleGroups) auth.Role {
+	claimGroups, ok := getGroupsFromClaims(token, logger, groups.ClaimPath())
 	if !ok {
-		logger.Error("failed to parse token claims")
-		return false
+		return auth.RoleNone
 	}
 
-	// Check if the "groups" claim exists
-	groupsClaim, exists := claims["groups"]
-	if !exists {
-		logger.Error("groups claim not found in token")
-		return false
+	role := groups.RoleFromGroups(claimGroups)
+	if role == auth.RoleNone {
+		logger.Warn("user does not belong to any configured group")
 	}
 
-	// Convert groups claim to string slice
-	groups, ok := groupsClaim.([]interface{})
+	return role
+}
+
+func getGroupsFromClaims(token *jwt.Token, logger *zap.Logger, path []string) ([]string, bool) {
+	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		logger.Error("groups claim is not an array")
-		return false
+		logger.Error("failed to parse token claims")
+		return nil, false
 	}
 
-	// Check if the required group is present
-	hasGroup := false
-	for _, group := range groups {
-		if groupStr, okType := group.(string); okType && strings.TrimPrefix(groupStr, "/") == userAuthGroup {
-			hasGroup = true
-			break
+	// Walk through the nested claims to the groups claim
+	var groupsClaim interface{} = map[string]interface{}(claims)
+	for _, name := range path {
+		nested, isObject := groupsClaim.(map[string]interface{})
+		if !isObject {
+			logger.Error("groups claim not found in token", zap.Strings("claim", path))
+			return nil, false
+		}
+
+		var exists bool
+		if groupsClaim, exists = nested[name]; !exists {
+			logger.Error("groups claim not found in token", zap.Strings("claim", path))
+			return nil, false
 		}
 	}
 
-	if !hasGroup {
-		logger.Warn("user does not belong to required group",
-			zap.String("required_group", userAuthGroup))
-		return false
+	// Convert groups claim to string slice
+	rawGroups, ok := groupsClaim.([]interface{})
+	if !ok {
+		logger.Error("groups claim is not an array", zap.Strings("claim", path))
+		return nil, false
 	}
 
-	logger.Info("user authorized with group membership",
-		zap.String("group", userAuthGroup))
+	groups := make([]string, 0, len(rawGroups))
+	for _, group := range rawGroups {
+		if groupStr, okType := group.(string); okType {
+			groups = append(groups, strings.TrimPrefix(groupStr, "/"))
+		}
+	}
 
-	return true
+	return groups, true
 }
 
 func CheckEventExistenceMW(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
@@ -214,6 +402,8 @@ func Logger(log *zap.Logger) gin.HandlerFunc {
 		end := time.Now().UTC()
 		latency := end.Sub(start)
 
+		metrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), latency)
+
 		fields := []zapcore.Field{
 			zap.Int("status", c.Writer.Status()),
 			zap.String("method", c.Request.Method),
@@ -244,6 +434,67 @@ func Logger(log *zap.Logger) gin.HandlerFunc {
 	}
 }
 
+// CacheMW serves the public GET responses from the read cache, the handlers are called on a cache miss.
+// The requests with the Authorization header are not cached, because they can see not public events.
+// Only successful responses are stored, the cache is invalidated by the database writes.
+// A response isn't stored if the cache is invalidated while it's built, because it can contain the old data.
+func CacheMW(readCache cache.Cache, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if readCache == nil || c.Request.Method != http.MethodGet || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key := c.Request.URL.RequestURI() + "|" + c.GetHeader("Accept")
+
+		entry, generation, err := readCache.Get(ctx, key)
+		if err == nil {
+			c.Header(cacheStatusHeader, cacheHit)
+			c.Data(http.StatusOK, entry.ContentType, entry.Body)
+			c.Abort()
+			return
+		}
+		// the generation is unknown if the cache isn't available, the response isn't stored then
+		cacheable := errors.Is(err, cache.ErrNotFound)
+		if !cacheable {
+			logger.Error("failed to read the cache", zap.String("key", key), zap.Error(err))
+		}
+
+		c.Header(cacheStatusHeader, cacheMiss)
+		w := &cacheWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if !cacheable || w.Status() != http.StatusOK || len(c.Errors) > 0 {
+			return
+		}
+
+		// the generation of the miss is passed, so the response isn't stored if a write invalidates the cache meanwhile
+		entry = &cache.Entry{ContentType: w.Header().Get("Content-Type"), Body: w.body.Bytes()}
+		if err = readCache.Set(ctx, key, generation, entry); err != nil {
+			logger.Error("failed to write the cache", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// cacheWriter keeps a copy of the response body for the cache.
+type cacheWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
@@ -251,8 +502,8 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set(
 			"Access-Control-Allow-Headers",
 			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, "+
-				"Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH")
+				"Authorization, accept, origin, Cache-Control, X-Requested-With, Last-Event-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -3,24 +3,31 @@ package api
 import (
 	"crypto/rand"
 	"crypto/rsa"
-	"reflect"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
 	"testing"
-	"unsafe"
+	"time"
 
 	"net/http"
 	"net/http/httptest"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	"github.com/stackmon/otc-status-dashboard/internal/cache"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
 )
 
-func TestIsAuthGroupInClaims(t *testing.T) {
+func TestGetRoleFromClaims(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 
 	tests := []struct {
@@ -72,13 +79,13 @@ func TestIsAuthGroupInClaims(t *testing.T) {
 				Claims: claims,
 			}
 
-			result := isAuthGroupInClaims(token, logger, tt.requiredGroup)
+			result := getRoleFromClaims(token, logger, &auth.RoleGroups{Admins: tt.requiredGroup}) == auth.RoleAdmin
 			assert.Equal(t, tt.expectedResult, result)
 		})
 	}
 }
 
-func TestIsAuthGroupInClaims_MissingGroupsClaim(t *testing.T) {
+func TestGetRoleFromClaims_MissingGroupsClaim(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 
 	claims := jwt.MapClaims{
@@ -90,11 +97,11 @@ func TestIsAuthGroupInClaims_MissingGroupsClaim(t *testing.T) {
 		Claims: claims,
 	}
 
-	result := isAuthGroupInClaims(token, logger, "admin-group")
+	result := getRoleFromClaims(token, logger, &auth.RoleGroups{Admins: "admin-group"}) == auth.RoleAdmin
 	assert.False(t, result)
 }
 
-func TestIsAuthGroupInClaims_InvalidGroupsType(t *testing.T) {
+func TestGetRoleFromClaims_InvalidGroupsType(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 
 	claims := jwt.MapClaims{
@@ -106,11 +113,11 @@ func TestIsAuthGroupInClaims_InvalidGroupsType(t *testing.T) {
 		Claims: claims,
 	}
 
-	result := isAuthGroupInClaims(token, logger, "admin-group")
+	result := getRoleFromClaims(token, logger, &auth.RoleGroups{Admins: "admin-group"}) == auth.RoleAdmin
 	assert.False(t, result)
 }
 
-func TestIsAuthGroupInClaims_GroupsWithNonStringElements(t *testing.T) {
+func TestGetRoleFromClaims_GroupsWithNonStringElements(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 
 	claims := jwt.MapClaims{
@@ -122,11 +129,11 @@ func TestIsAuthGroupInClaims_GroupsWithNonStringElements(t *testing.T) {
 		Claims: claims,
 	}
 
-	result := isAuthGroupInClaims(token, logger, "admin-group")
+	result := getRoleFromClaims(token, logger, &auth.RoleGroups{Admins: "admin-group"}) == auth.RoleAdmin
 	assert.True(t, result) // Should still find the string "admin-group"
 }
 
-func TestIsAuthGroupInClaims_InvalidClaimsType(t *testing.T) {
+func TestGetRoleFromClaims_InvalidClaimsType(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 
 	// Use a different claims type that's not MapClaims
@@ -141,14 +148,14 @@ func TestIsAuthGroupInClaims_InvalidClaimsType(t *testing.T) {
 		},
 	}
 
-	result := isAuthGroupInClaims(token, logger, "admin-group")
+	result := getRoleFromClaims(token, logger, &auth.RoleGroups{Admins: "admin-group"}) == auth.RoleAdmin
 	assert.False(t, result) // Should fail because it's not MapClaims
 }
 
-// BenchmarkIsAuthGroupInClaims benchmarks the group checking function.
+// BenchmarkGetRoleFromClaims benchmarks the group checking function.
 //
 //nolint:intrange
-func BenchmarkIsAuthGroupInClaims(b *testing.B) {
+func BenchmarkGetRoleFromClaims(b *testing.B) {
 	logger, _ := zap.NewDevelopment()
 
 	claims := jwt.MapClaims{
@@ -162,16 +169,46 @@ func BenchmarkIsAuthGroupInClaims(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = isAuthGroupInClaims(token, logger, "admin-group")
+		_ = getRoleFromClaims(token, logger, &auth.RoleGroups{Admins: "admin-group"}) == auth.RoleAdmin
 	}
 }
 
-// helper to set unexported field realmPublicKey on auth.Provider using reflect+unsafe.
-func setRealmPublicKey(prov *auth.Provider, key *rsa.PublicKey) {
-	val := reflect.ValueOf(prov).Elem()
-	field := val.FieldByName("realmPublicKey")
-	ptrToField := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
-	ptrToField.Set(reflect.ValueOf(key))
+const (
+	testIssuer   = "https://idp.example.com/realms/test"
+	testClientID = "status-dashboard"
+)
+
+// oidcClaims returns the claims of the token issued by the test provider for the test client.
+func oidcClaims(claims jwt.MapClaims) jwt.MapClaims {
+	claims["iss"] = testIssuer
+	claims["aud"] = testClientID
+	return claims
+}
+
+// newTestProvider returns the provider with the JWKS endpoint serving the given keys, the key IDs are "key-{index}".
+func newTestProvider(t *testing.T, keys ...*rsa.PublicKey) *auth.Provider {
+	t.Helper()
+
+	jwkSet := auth.JWKSet{}
+	for i, key := range keys {
+		jwkSet.Keys = append(jwkSet.Keys, auth.JWK{
+			Kty: "RSA", Alg: "RS256", Use: "sig", Kid: fmt.Sprintf("key-%d", i),
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		assert.NoError(t, json.NewEncoder(w).Encode(jwkSet))
+	}))
+	t.Cleanup(srv.Close)
+
+	prov, err := auth.NewProvider(&auth.OIDCConfig{
+		Issuer: testIssuer, ClientID: testClientID, Endpoints: &auth.Endpoints{JWKSURL: srv.URL},
+	}, "", "", 0, nil)
+	require.NoError(t, err)
+
+	return prov
 }
 
 func TestParseToken_HMAC_Success(t *testing.T) {
@@ -183,7 +220,7 @@ func TestParseToken_HMAC_Success(t *testing.T) {
 
 	logger := zaptest.NewLogger(t)
 
-	parsed, err := parseToken(signed, secret, nil, logger)
+	parsed, err := parseToken(t.Context(), signed, secret, nil, logger)
 	require.NoError(t, err, "unexpected parse error")
 	assert.True(t, parsed.Valid, "expected token to be valid")
 }
@@ -196,7 +233,7 @@ func TestParseToken_HMAC_WrongSecret(t *testing.T) {
 
 	logger := zaptest.NewLogger(t)
 
-	_, err = parseToken(signed, "wrongsecret", nil, logger)
+	_, err = parseToken(t.Context(), signed, "wrongsecret", nil, logger)
 	require.Error(t, err, "expected error when using wrong secret")
 }
 
@@ -206,17 +243,16 @@ func TestParseToken_RSA_Success(t *testing.T) {
 	require.NoError(t, err, "failed to generate rsa key")
 
 	// sign token with private key
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "rsa-user"})
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, oidcClaims(jwt.MapClaims{"sub": "rsa-user"}))
 	signed, err := token.SignedString(priv)
 	require.NoError(t, err, "failed to sign rsa token")
 
-	// provider with matching public key (set via helper)
-	prov := &auth.Provider{}
-	setRealmPublicKey(prov, &priv.PublicKey)
+	// provider with matching public key
+	prov := newTestProvider(t, &priv.PublicKey)
 
 	logger := zaptest.NewLogger(t)
 
-	parsed, err := parseToken(signed, "", prov, logger)
+	parsed, err := parseToken(t.Context(), signed, "", prov, logger)
 	require.NoError(t, err, "unexpected parse error for rsa token")
 	assert.True(t, parsed.Valid, "expected rsa token to be valid")
 }
@@ -229,16 +265,15 @@ func TestParseToken_RSA_WrongPublicKey(t *testing.T) {
 	require.NoError(t, err, "failed to generate rsa key2")
 
 	// sign with priv1 but provide pub2 to parser
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "rsa-user"})
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, oidcClaims(jwt.MapClaims{"sub": "rsa-user"}))
 	signed, err := token.SignedString(priv1)
 	require.NoError(t, err, "failed to sign rsa token")
 
-	prov := &auth.Provider{}
-	setRealmPublicKey(prov, &priv2.PublicKey)
+	prov := newTestProvider(t, &priv2.PublicKey)
 
 	logger := zaptest.NewLogger(t)
 
-	_, err = parseToken(signed, "", prov, logger)
+	_, err = parseToken(t.Context(), signed, "", prov, logger)
 	require.Error(t, err, "expected error when public key does not match signature")
 }
 
@@ -269,7 +304,7 @@ func TestAuthenticationMW_HMAC_SuccessAndFailures(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 
 	prov := &auth.Provider{}
-	mw := AuthenticationMW(prov, logger, secret, "") // no group requirement for HMAC
+	mw := AuthenticationMW(prov, nil, logger, secret, &auth.RoleGroups{}) // no group requirement for HMAC
 	w := performRequestWithAuth(mw, "Bearer "+signed)
 	assert.Equal(t, http.StatusOK, w.Code, "expected middleware to allow valid HMAC token")
 
@@ -278,7 +313,7 @@ func TestAuthenticationMW_HMAC_SuccessAndFailures(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code, "expected 401 when no Authorization header")
 
 	// failure: wrong secret configured
-	mwWrong := AuthenticationMW(prov, logger, "wrong-secret", "")
+	mwWrong := AuthenticationMW(prov, nil, logger, "wrong-secret", &auth.RoleGroups{})
 	w = performRequestWithAuth(mwWrong, "Bearer "+signed)
 	assert.Equal(t, http.StatusUnauthorized, w.Code, "expected 401 when secret does not match")
 }
@@ -293,20 +328,15 @@ func TestAuthenticationMW_RSA_WithAndWithoutGroup(t *testing.T) {
 		"sub":    "rsa-user",
 		"groups": []interface{}{"/admin-group"},
 	}
-	tokenWithGroup := jwt.NewWithClaims(jwt.SigningMethodRS256, claimsWithGroup)
+	tokenWithGroup := jwt.NewWithClaims(jwt.SigningMethodRS256, oidcClaims(claimsWithGroup))
 	signedWithGroup, err := tokenWithGroup.SignedString(priv)
 	require.NoError(t, err, "failed to sign rsa token")
 
-	prov := &auth.Provider{}
-	// set unexported realmPublicKey so prov.GetPublicKey() returns immediately
-	val := reflect.ValueOf(prov).Elem()
-	field := val.FieldByName("realmPublicKey")
-	ptrToField := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
-	ptrToField.Set(reflect.ValueOf(&priv.PublicKey))
+	prov := newTestProvider(t, &priv.PublicKey)
 
 	logger := zaptest.NewLogger(t)
 
-	mw := AuthenticationMW(prov, logger, "", "admin-group") // require "admin-group"
+	mw := AuthenticationMW(prov, nil, logger, "", &auth.RoleGroups{Admins: "admin-group"}) // require "admin-group"
 	w := performRequestWithAuth(mw, "Bearer "+signedWithGroup)
 	assert.Equal(t, http.StatusOK, w.Code, "expected middleware to allow RSA token when group present")
 
@@ -315,10 +345,411 @@ func TestAuthenticationMW_RSA_WithAndWithoutGroup(t *testing.T) {
 		"sub":    "rsa-user",
 		"groups": []interface{}{"other-group"},
 	}
-	tokenWithoutGroup := jwt.NewWithClaims(jwt.SigningMethodRS256, claimsWithoutGroup)
+	tokenWithoutGroup := jwt.NewWithClaims(jwt.SigningMethodRS256, oidcClaims(claimsWithoutGroup))
 	signedWithoutGroup, err := tokenWithoutGroup.SignedString(priv)
 	require.NoError(t, err, "failed to sign rsa token")
 
 	w = performRequestWithAuth(mw, "Bearer "+signedWithoutGroup)
 	assert.Equal(t, http.StatusUnauthorized, w.Code, "expected 401 when RSA token lacks required group")
 }
+
+func TestGetRoleFromClaims_Precedence(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	groups := &auth.RoleGroups{Creators: "creators", Operators: "operators", Admins: "admins"}
+
+	tests := []struct {
+		name     string
+		groups   []interface{}
+		expected auth.Role
+	}{
+		{name: "Creator", groups: []interface{}{"/creators"}, expected: auth.RoleCreator},
+		{name: "Operator", groups: []interface{}{"creators", "operators"}, expected: auth.RoleOperator},
+		{name: "Admin wins", groups: []interface{}{"operators", "admins", "creators"}, expected: auth.RoleAdmin},
+		{name: "Unknown groups", groups: []interface{}{"other"}, expected: auth.RoleNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &jwt.Token{Claims: jwt.MapClaims{"sub": "test-user", "groups": tt.groups}}
+			assert.Equal(t, tt.expected, getRoleFromClaims(token, logger, groups))
+		})
+	}
+}
+
+func TestRequireRoleMW(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name         string
+		role         auth.Role
+		minRole      auth.Role
+		expectedCode int
+	}{
+		{name: "Creator is forbidden for operator endpoint", role: auth.RoleCreator, minRole: auth.RoleOperator, expectedCode: http.StatusForbidden},
+		{name: "Operator is allowed for operator endpoint", role: auth.RoleOperator, minRole: auth.RoleOperator, expectedCode: http.StatusOK},
+		{name: "Admin is allowed for creator endpoint", role: auth.RoleAdmin, minRole: auth.RoleCreator, expectedCode: http.StatusOK},
+		{name: "Operator is forbidden for admin endpoint", role: auth.RoleOperator, minRole: auth.RoleAdmin, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				auth.SetIdentity(c, tt.role, "test-user")
+			}, RequireRoleMW(tt.minRole, logger), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestRequireRoleMW_WithoutAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	// the route without the authentication middleware has no identity, it's processed as anonymous
+	router := gin.New()
+	router.GET("/", RequireRoleMW(auth.RoleCreator, logger), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the disabled authentication gives the sd_admins role
+	router = gin.New()
+	router.GET("/", AuthenticationMW(&auth.Provider{Disabled: true}, nil, logger, "", &auth.RoleGroups{}),
+		RequireRoleMW(auth.RoleAdmin, logger), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequireRoleMW_APIToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name         string
+		tokenScopes  string
+		minRole      auth.Role
+		scopes       []auth.Scope
+		expectedCode int
+	}{
+		{name: "Token with the scope", tokenScopes: "incident:create", minRole: auth.RoleOperator,
+			scopes: []auth.Scope{auth.ScopeIncidentCreate}, expectedCode: http.StatusOK},
+		{name: "Token with any of the scopes", tokenScopes: "info:create", minRole: auth.RoleCreator,
+			scopes: []auth.Scope{auth.ScopeIncidentCreate, auth.ScopeInfoCreate}, expectedCode: http.StatusOK},
+		{name: "Token is checked by scopes instead of the role", tokenScopes: "components:manage",
+			minRole: auth.RoleAdmin, scopes: []auth.Scope{auth.ScopeComponentsManage}, expectedCode: http.StatusOK},
+		{name: "Token without the scope", tokenScopes: "incident:update", minRole: auth.RoleOperator,
+			scopes: []auth.Scope{auth.ScopeIncidentCreate}, expectedCode: http.StatusForbidden},
+		{name: "Token is forbidden without scopes", tokenScopes: "components:manage", minRole: auth.RoleAdmin,
+			expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				auth.SetTokenIdentity(c, auth.NewTokenAccess(&db.APIToken{ID: 1, Name: "ci", Scopes: tt.tokenScopes}))
+			}, RequireRoleMW(tt.minRole, logger, tt.scopes...), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestAuthenticationMW_APITokenWithoutDB(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+	prov := newTestProvider(t)
+
+	router := gin.New()
+	router.GET("/", AuthenticationMW(prov, nil, logger, "", &auth.RoleGroups{}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+auth.APITokenPrefix+"unknown")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticationMW_APITokenFromDB(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prov := newTestProvider(t)
+	rawToken := auth.APITokenPrefix + "token"
+	tokenColumns := []string{"id", "name", "scopes", "last_used_at"}
+
+	tests := []struct {
+		name         string
+		lastUsedAt   *time.Time
+		dbErr        error
+		touched      bool
+		expectedCode int
+	}{
+		{name: "Token without the usage is touched", touched: true, expectedCode: http.StatusOK},
+		{name: "Token used long ago is touched", lastUsedAt: ptr(time.Now().UTC().Add(-time.Hour)),
+			touched: true, expectedCode: http.StatusOK},
+		{name: "Token used recently isn't touched", lastUsedAt: ptr(time.Now().UTC().Add(-time.Second)),
+			expectedCode: http.StatusOK},
+		{name: "Database error", dbErr: fmt.Errorf("connection refused"),
+			expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			dbInst, mock, err := db.NewWithMock()
+			require.NoError(t, err)
+
+			query := mock.ExpectQuery(`^SELECT \* FROM "api_token" WHERE token_hash = \$1`).
+				WithArgs(auth.HashAPIToken(rawToken), 1)
+			if tt.dbErr != nil {
+				query.WillReturnError(tt.dbErr)
+			} else {
+				query.WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(1, "ci", "incident:create", tt.lastUsedAt))
+			}
+			if tt.touched {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "api_token" SET "last_used_at"=\$1 WHERE id = \$2`).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			router := gin.New()
+			router.GET("/", AuthenticationMW(prov, dbInst, zap.New(core), "", &auth.RoleGroups{}), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+rawToken)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			require.NoError(t, mock.ExpectationsWereMet())
+			assert.Zero(t, logs.FilterMessage("failed to update the last usage of API token").Len())
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCacheMW(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+	readCache := cache.NewMemory(10, time.Minute)
+
+	calls := 0
+	router := gin.New()
+	router.GET("/components", CacheMW(readCache, logger), func(c *gin.Context) {
+		calls++
+		if c.Query("invalidate") != "" {
+			// a write during the request, the response can contain the old data
+			require.NoError(t, readCache.Invalidate(c.Request.Context()))
+		}
+		if c.Query("fail") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"errMsg": "bad request"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/components", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+	assert.JSONEq(t, `{"calls":1}`, w.Body.String())
+
+	w = get("/components", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, cacheHit, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"calls":1}`, w.Body.String())
+
+	// the query string is a part of the key
+	w = get("/components?region=EU-DE", nil)
+	assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+	assert.JSONEq(t, `{"calls":2}`, w.Body.String())
+
+	// the requests with the Authorization header are not cached
+	w = get("/components", http.Header{"Authorization": {"Bearer token"}})
+	assert.Empty(t, w.Header().Get(cacheStatusHeader))
+	assert.JSONEq(t, `{"calls":3}`, w.Body.String())
+
+	// the errors are not cached
+	get("/components?fail=1", nil)
+	w = get("/components?fail=1", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, 5, calls)
+
+	require.NoError(t, readCache.Invalidate(t.Context()))
+	w = get("/components", nil)
+	assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+	assert.JSONEq(t, `{"calls":6}`, w.Body.String())
+
+	// the response built during the invalidation isn't stored
+	get("/components?invalidate=1", nil)
+	w = get("/components?invalidate=1", nil)
+	assert.Equal(t, cacheMiss, w.Header().Get(cacheStatusHeader))
+	assert.JSONEq(t, `{"calls":8}`, w.Body.String())
+}
+
+func TestCacheMW_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/", CacheMW(nil, zaptest.NewLogger(t)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(cacheStatusHeader))
+}
+
+func TestGetRoleFromClaims_NestedClaim(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	groups := &auth.RoleGroups{Admins: "admins", Claim: "realm_access.roles"}
+
+	token := &jwt.Token{Claims: jwt.MapClaims{
+		"sub":          "test-user",
+		"realm_access": map[string]interface{}{"roles": []interface{}{"offline_access", "admins"}},
+	}}
+	assert.Equal(t, auth.RoleAdmin, getRoleFromClaims(token, logger, groups))
+
+	// the "groups" claim is not used if the claim path is configured
+	token = &jwt.Token{Claims: jwt.MapClaims{"sub": "test-user", "groups": []interface{}{"admins"}}}
+	assert.Equal(t, auth.RoleNone, getRoleFromClaims(token, logger, groups))
+
+	token = &jwt.Token{Claims: jwt.MapClaims{"sub": "test-user", "realm_access": "admins"}}
+	assert.Equal(t, auth.RoleNone, getRoleFromClaims(token, logger, groups))
+}
+
+func TestParseToken_RSA_KeyID(t *testing.T) {
+	priv1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	priv2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	prov := newTestProvider(t, &priv1.PublicKey, &priv2.PublicKey)
+	logger := zaptest.NewLogger(t)
+
+	// the token is signed by the second key of the set
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, oidcClaims(jwt.MapClaims{"sub": "rsa-user"}))
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(priv2)
+	require.NoError(t, err)
+
+	parsed, err := parseToken(t.Context(), signed, "", prov, logger)
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	// the token without the key ID is checked by all keys
+	token = jwt.NewWithClaims(jwt.SigningMethodRS256, oidcClaims(jwt.MapClaims{"sub": "rsa-user"}))
+	signed, err = token.SignedString(priv2)
+	require.NoError(t, err)
+
+	parsed, err = parseToken(t.Context(), signed, "", prov, logger)
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	// the key ID doesn't match the signing key
+	token = jwt.NewWithClaims(jwt.SigningMethodRS256, oidcClaims(jwt.MapClaims{"sub": "rsa-user"}))
+	token.Header["kid"] = "key-0"
+	signed, err = token.SignedString(priv2)
+	require.NoError(t, err)
+
+	_, err = parseToken(t.Context(), signed, "", prov, logger)
+	require.Error(t, err)
+}
+
+func TestParseToken_RSA_IssuerAndAudience(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	prov := newTestProvider(t, &priv.PublicKey)
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		err    error
+	}{
+		{
+			name:   "client in the audience",
+			claims: jwt.MapClaims{"sub": "rsa-user", "iss": testIssuer, "aud": []string{"account", testClientID}},
+		},
+		{
+			name:   "client in the authorized party",
+			claims: jwt.MapClaims{"sub": "rsa-user", "iss": testIssuer, "aud": "account", "azp": testClientID},
+		},
+		{
+			name:   "wrong issuer",
+			claims: jwt.MapClaims{"sub": "rsa-user", "iss": "https://idp.example.com/realms/other", "aud": testClientID},
+			err:    auth.ErrTokenIssuer,
+		},
+		{
+			name:   "missing issuer",
+			claims: jwt.MapClaims{"sub": "rsa-user", "aud": testClientID},
+			err:    auth.ErrTokenIssuer,
+		},
+		{
+			name:   "wrong audience",
+			claims: jwt.MapClaims{"sub": "rsa-user", "iss": testIssuer, "aud": "other-client", "azp": "other-client"},
+			err:    auth.ErrTokenAudience,
+		},
+		{
+			name:   "missing audience",
+			claims: jwt.MapClaims{"sub": "rsa-user", "iss": testIssuer},
+			err:    auth.ErrTokenAudience,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, signErr := jwt.NewWithClaims(jwt.SigningMethodRS256, tt.claims).SignedString(priv)
+			require.NoError(t, signErr)
+
+			_, err = parseToken(t.Context(), signed, "", prov, logger)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+
+			mw := AuthenticationMW(prov, nil, logger, "", &auth.RoleGroups{})
+			assert.Equal(t, http.StatusUnauthorized, performRequestWithAuth(mw, "Bearer "+signed).Code)
+		})
+	}
+}
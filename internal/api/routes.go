@@ -6,15 +6,26 @@ import (
 	v1 "github.com/stackmon/otc-status-dashboard/internal/api/v1"
 	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
 	newRSS "github.com/stackmon/otc-status-dashboard/internal/rss"
+	"github.com/stackmon/otc-status-dashboard/internal/statuspage"
 )
 
 const (
-	authGroup = "auth"
-	v1Group   = "v1"
-	v2Group   = "v2"
+	statusPath = "status"
+	authGroup  = "auth"
+	v1Group    = "v1"
+	v2Group    = "v2"
 )
 
 func (a *API) InitRoutes() {
+	// the public GET responses are cached, the stream and the authorised requests are not cached
+	cached := CacheMW(a.db.Cache(), a.log)
+	// the API tokens need any of these scopes, the exact scope is checked by the event type in the handlers
+	createScopes := []auth.Scope{auth.ScopeIncidentCreate, auth.ScopeMaintenanceCreate, auth.ScopeInfoCreate}
+	updateScopes := []auth.Scope{auth.ScopeIncidentUpdate, auth.ScopeMaintenanceUpdate, auth.ScopeInfoUpdate}
+
+	// the server-rendered status page for the browsers without JavaScript
+	a.r.GET(statusPath, cached, statuspage.HandlePage(a.db, a.log))
+
 	authAPI := a.r.Group(authGroup)
 	{
 		authAPI.GET("login", auth.GetLoginPageHandler(a.oa2Prov, a.log))
@@ -26,81 +37,215 @@ func (a *API) InitRoutes() {
 
 	v1API := a.r.Group(v1Group)
 	{
-		v1API.GET("component_status", v1.GetComponentsStatusHandler(a.db, a.log))
+		v1API.GET("component_status", cached, v1.GetComponentsStatusHandler(a.db, a.log))
 		v1API.POST("component_status",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeIncidentCreate),
 			v1.PostComponentStatusHandler(a.db, a.log),
 		)
 
-		v1API.GET("incidents", v1.GetIncidentsHandler(a.db, a.log))
+		v1API.GET("incidents", cached, v1.GetIncidentsHandler(a.db, a.log))
 	}
 
 	v2API := a.r.Group(v2Group)
 	{
-		v2API.GET("components", v2.GetComponentsHandler(a.db, a.log))
-		v2API.POST("components", AuthenticationMW(
-			a.oa2Prov,
-			a.log,
-			a.secretKeyV1,
-			a.authGroup,
-		),
+		v2API.GET("components", cached, v2.GetComponentsHandler(a.db, a.log))
+		v2API.POST("components",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log, auth.ScopeComponentsManage),
 			v2.PostComponentHandler(a.db, a.log))
-		v2API.GET("components/:id", v2.GetComponentHandler(a.db, a.log))
+		v2API.GET("components/:id", cached, v2.GetComponentHandler(a.db, a.log))
+		v2API.PATCH("components/:id",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log, auth.ScopeComponentsManage),
+			v2.PatchComponentHandler(a.db, a.log))
+		v2API.DELETE("components/:id",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log, auth.ScopeComponentsManage),
+			v2.DeleteComponentHandler(a.db, a.log))
+
+		// Regions section.
+		v2API.GET("regions", cached, v2.GetRegionsHandler(a.db, a.log))
+		v2API.GET("regions/:id", cached, v2.GetRegionHandler(a.db, a.log))
+		v2API.POST("regions",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log, auth.ScopeComponentsManage),
+			v2.PostRegionHandler(a.db, a.log))
+		v2API.PATCH("regions/:id",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log, auth.ScopeComponentsManage),
+			v2.PatchRegionHandler(a.db, a.log))
+		v2API.DELETE("regions/:id",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log, auth.ScopeComponentsManage),
+			v2.DeleteRegionHandler(a.db, a.log))
 
 		// Incidents section. Deprecated.
 		// will be removed in a later version.
-		v2API.GET("incidents", v2.GetIncidentsHandler(a.db, a.log))
+		v2API.GET("incidents", cached,
+			OptionalAuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			v2.GetIncidentsHandler(a.db, a.log))
 		v2API.POST("incidents",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, createScopes...),
 			ValidateComponentsMW(a.db, a.log),
 			v2.PostIncidentHandler(a.db, a.log),
 		)
-		v2API.GET("incidents/:eventID", v2.GetIncidentHandler(a.db, a.log))
+		v2API.GET("incidents/:eventID", cached,
+			OptionalAuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			v2.GetIncidentHandler(a.db, a.log))
 		v2API.PATCH("incidents/:eventID",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, updateScopes...),
 			CheckEventExistenceMW(a.db, a.log),
 			v2.PatchIncidentHandler(a.db, a.log))
 		v2API.POST("incidents/:eventID/extract",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeIncidentUpdate),
 			CheckEventExistenceMW(a.db, a.log),
 			ValidateComponentsMW(a.db, a.log),
 			v2.PostIncidentExtractHandler(a.db, a.log))
+		v2API.POST("incidents/:eventID/merge",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeIncidentUpdate),
+			CheckEventExistenceMW(a.db, a.log),
+			v2.PostIncidentMergeHandler(a.db, a.log))
 		v2API.PATCH("incidents/:eventID/updates/:updateID",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, updateScopes...),
 			CheckEventExistenceMW(a.db, a.log),
 			v2.PatchEventUpdateTextHandler(a.db, a.log))
 
 		// Events section.
 		// Get /v2/events returns events page with pagination.
-		v2API.GET("events", v2.GetEventsHandler(a.db, a.log))
+		// Maintenances in the review workflow are visible only for authenticated users.
+		v2API.GET("events", cached,
+			OptionalAuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			v2.GetEventsHandler(a.db, a.log))
 		v2API.POST("events",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleCreator, a.log, createScopes...),
 			ValidateComponentsMW(a.db, a.log),
 			v2.PostIncidentHandler(a.db, a.log))
-		v2API.GET("events/:eventID",
+		v2API.GET("events/:eventID", cached,
+			OptionalAuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
 			v2.GetIncidentHandler(a.db, a.log))
 		v2API.PATCH("events/:eventID",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleCreator, a.log, updateScopes...),
 			CheckEventExistenceMW(a.db, a.log),
 			v2.PatchIncidentHandler(a.db, a.log))
+		v2API.POST("events/:eventID/approve",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeMaintenanceUpdate),
+			CheckEventExistenceMW(a.db, a.log),
+			v2.PostMaintenanceApproveHandler(a.db, a.log))
 		v2API.POST("events/:eventID/extract",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeIncidentUpdate),
 			CheckEventExistenceMW(a.db, a.log),
 			ValidateComponentsMW(a.db, a.log),
 			v2.PostIncidentExtractHandler(a.db, a.log))
+		v2API.POST("events/:eventID/merge",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeIncidentUpdate),
+			CheckEventExistenceMW(a.db, a.log),
+			v2.PostIncidentMergeHandler(a.db, a.log))
 		v2API.PATCH("events/:eventID/updates/:updateID",
-			AuthenticationMW(a.oa2Prov, a.log, a.secretKeyV1, a.authGroup),
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, updateScopes...),
 			CheckEventExistenceMW(a.db, a.log),
 			v2.PatchEventUpdateTextHandler(a.db, a.log))
+
+		// Alertmanager receiver section.
+		// The alerts open and resolve the system incidents, the API tokens need the incident scopes.
+		v2API.POST("alertmanager",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeIncidentCreate),
+			v2.PostAlertmanagerHandler(a.db, a.log, a.alertRules))
+
+		// Maintenance series section.
+		// The occurrences of the series are the maintenances in the events section.
+		v2API.GET("maintenance-series", cached,
+			OptionalAuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			v2.GetMaintenanceSeriesListHandler(a.db, a.log))
+		v2API.GET("maintenance-series/:id", cached,
+			OptionalAuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			v2.GetMaintenanceSeriesHandler(a.db, a.log))
+		v2API.POST("maintenance-series",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeMaintenanceCreate),
+			v2.PostMaintenanceSeriesHandler(a.db, a.log))
+		v2API.PATCH("maintenance-series/:id",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeMaintenanceUpdate),
+			v2.PatchMaintenanceSeriesHandler(a.db, a.log))
+		v2API.DELETE("maintenance-series/:id",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log, auth.ScopeMaintenanceUpdate),
+			v2.DeleteMaintenanceSeriesHandler(a.db, a.log))
+
+		// Stream section.
+		// Maintenances in the review workflow are streamed only for authenticated users.
+		v2API.GET("stream",
+			OptionalAuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			v2.GetStreamHandler(a.broker, a.db, a.log))
+
+		// Webhooks section.
+		webhooksAPI := v2API.Group("webhooks",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log),
+		)
+		webhooksAPI.GET("", v2.GetWebhooksHandler(a.db, a.log))
+		webhooksAPI.POST("", v2.PostWebhookHandler(a.db, a.log))
+		webhooksAPI.GET(":id", v2.GetWebhookHandler(a.db, a.log))
+		webhooksAPI.PATCH(":id", v2.PatchWebhookHandler(a.db, a.log))
+		webhooksAPI.DELETE(":id", v2.DeleteWebhookHandler(a.db, a.log))
+		webhooksAPI.GET(":id/deliveries", v2.GetWebhookDeliveriesHandler(a.db, a.log))
+
+		// API tokens section, the tokens can't manage the tokens.
+		tokensAPI := v2API.Group("tokens",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleAdmin, a.log),
+		)
+		tokensAPI.GET("", v2.GetAPITokensHandler(a.db, a.log))
+		tokensAPI.POST("", v2.PostAPITokenHandler(a.db, a.log))
+		tokensAPI.GET(":id", v2.GetAPITokenHandler(a.db, a.log))
+		tokensAPI.DELETE(":id", v2.DeleteAPITokenHandler(a.db, a.log))
+
+		// Subscriptions section, the public endpoints are registered if the SMTP server is configured.
+		if a.subscriptions {
+			v2API.POST("subscriptions", v2.PostSubscriptionHandler(a.db, a.log))
+			// the links of the messages return the pages, the subscription is changed only by POST
+			v2API.GET("subscriptions/confirm", v2.GetSubscriptionConfirmHandler(a.log))
+			v2API.POST("subscriptions/confirm", v2.PostSubscriptionConfirmHandler(a.db, a.log))
+			v2API.GET("subscriptions/unsubscribe", v2.GetUnsubscribeHandler(a.log))
+			v2API.POST("subscriptions/unsubscribe", v2.UnsubscribeHandler(a.db, a.log))
+		}
+
+		// Audit log section, the API tokens can't read the audit log.
+		v2API.GET("audit",
+			AuthenticationMW(a.oa2Prov, a.db, a.log, a.secretKeyV1, a.roleGroups),
+			RequireRoleMW(auth.RoleOperator, a.log),
+			v2.GetAuditHandler(a.db, a.log))
+
+		// Badges section, the SVG badges are embedded in the READMEs and the wikis.
+		badgesAPI := v2API.Group("badges", statuspage.BadgeCacheControlMW())
+		badgesAPI.GET("components/:id", cached, statuspage.HandleComponentBadge(a.db, a.log))
+		badgesAPI.GET("regions/:name", cached, statuspage.HandleRegionBadge(a.db, a.log))
+
 		// Availability section.
-		v2API.GET("availability", v2.GetComponentsAvailabilityHandler(a.db, a.log))
+		v2API.GET("availability", cached, v2.GetComponentsAvailabilityHandler(a.db, a.log))
 
 		// For testing purposes only.
-		v2API.GET("rss/", newRSS.HandleRSS(a.db, a.log))
+		v2API.GET("rss/", cached, newRSS.HandleRSS(a.db, a.log))
+		v2API.GET("atom/", cached, newRSS.HandleAtom(a.db, a.log))
+		v2API.GET("feed.json", cached, newRSS.HandleJSONFeed(a.db, a.log))
+		v2API.GET("calendar.ics", cached, newRSS.HandleICS(a.db, a.log))
 	}
 
 	rssFEED := a.r.Group("rss")
 	{
-		rssFEED.GET("/", rss.HandleRSS(a.db, a.log))
+		rssFEED.GET("/", cached, rss.HandleRSS(a.db, a.log))
 	}
 }
@@ -32,9 +32,16 @@ func HandleRSS(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		if region != "" && !validateRegion(region) {
-			apiErrors.RaiseStatusNotFoundErr(c, fmt.Errorf("the region '%s' is not valid", region))
-			return
+		if region != "" {
+			valid, err := validateRegion(dbInst, region)
+			if err != nil {
+				apiErrors.RaiseInternalErr(c, err)
+				return
+			}
+			if !valid {
+				apiErrors.RaiseStatusNotFoundErr(c, fmt.Errorf("the region '%s' is not valid", region))
+				return
+			}
 		}
 
 		baseURL := fmt.Sprintf("%s://%s", c.Request.URL.Scheme, c.Request.Host)
@@ -84,15 +91,9 @@ func HandleRSS(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 	}
 }
 
-// TODO: add list of valid regions to the config or to the db
-func validateRegion(region string) bool {
-	regions := [4]string{"EU-DE", "EU-NL", "EU-CH2", "Global"}
-	for _, r := range regions {
-		if r == region {
-			return true
-		}
-	}
-	return false
+// validateRegion checks if the region is registered in the regions table.
+func validateRegion(dbInst *db.DB, region string) (bool, error) {
+	return dbInst.RegionExists(region)
 }
 
 type feedParams struct {
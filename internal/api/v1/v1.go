@@ -5,15 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
 	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/audit"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
 )
 
 const (
@@ -78,10 +83,11 @@ func (s *SD2Time) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func GetIncidentsHandler(db *db.DB, logger *zap.Logger) gin.HandlerFunc {
+func GetIncidentsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger.Debug("retrieve incidents")
-		r, err := db.GetEvents()
+		// maintenances in the review workflow are not public
+		r, err := dbInst.GetEvents(&db.IncidentsParams{ExcludeStatuses: event.MaintenanceReviewStatuses()})
 		if err != nil {
 			apiErrors.RaiseInternalErr(c, err)
 			return
@@ -165,11 +171,13 @@ func GetComponentsStatusHandler(db *db.DB, logger *zap.Logger) gin.HandlerFunc {
 					endDate = &sd2T
 				}
 
+				// the impact of the incident on the component
+				impact := inc.ComponentImpact(component.ID)
 				newInc := &Incident{
 					IncidentID: IncidentID{int(inc.ID)},
 					IncidentData: IncidentData{
 						Text:      *inc.Text,
-						Impact:    inc.Impact,
+						Impact:    &impact,
 						StartDate: SD2Time(*inc.StartDate),
 						EndDate:   endDate,
 						Updates:   nil,
@@ -257,9 +265,19 @@ func PostComponentStatusHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFu
 			return
 		}
 
+		if err = auth.CheckTokenAccess(c, auth.ScopeIncidentCreate, []db.Component{*storedComponent}); err != nil {
+			apiErrors.RaiseForbiddenErr(c, err)
+			return
+		}
+
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+
 		log.Info("get opened incidents")
 		isActiveTrue := true
-		openedIncidents, err := dbInst.GetEvents(&db.IncidentsParams{IsActive: &isActiveTrue})
+		openedIncidents, err := dbInst.GetEvents(&db.IncidentsParams{
+			IsActive:        &isActiveTrue,
+			ExcludeStatuses: event.MaintenanceReviewStatuses(),
+		})
 		if err != nil {
 			apiErrors.RaiseInternalErr(c, err)
 			return
@@ -267,7 +285,7 @@ func PostComponentStatusHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFu
 
 		if len(openedIncidents) == 0 {
 			log.Info("there are no opened incidents")
-			inc, errCreation := createIncident(dbInst, log, storedComponent, &inComponent)
+			inc, errCreation := createIncident(actorDB, log, storedComponent, &inComponent)
 			if errCreation != nil {
 				apiErrors.RaiseInternalErr(c, err)
 				return
@@ -295,17 +313,20 @@ func PostComponentStatusHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFu
 					Text:       fmt.Sprintf("%s added", storedComponent.PrintAttrs()),
 					Timestamp:  time.Now().UTC(),
 				})
-				err = dbInst.ModifyIncident(incByImpact)
+				err = audit.TrackEvent(actorDB, string(webhook.ActionUpdated), incByImpact.ID, func(tx *db.DB) error {
+					return tx.ModifyIncident(incByImpact)
+				})
 				if err != nil {
 					apiErrors.RaiseInternalErr(c, err)
 					return
 				}
+				v2.NotifyEventChanged(actorDB, log, webhook.ActionUpdated, incByImpact.ID)
 				c.JSON(http.StatusCreated, toAPIIncident(incByImpact))
 				return
 			}
 
 			log.Info("there are no incidents with given component and impact, create an incident")
-			inc, errCreation := createIncident(dbInst, log, storedComponent, &inComponent)
+			inc, errCreation := createIncident(actorDB, log, storedComponent, &inComponent)
 			if errCreation != nil {
 				apiErrors.RaiseInternalErr(c, err)
 				return
@@ -327,10 +348,9 @@ func PostComponentStatusHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFu
 			return
 		}
 
-		storedIncident, err := MoveIncidentToHigherImpact(
-			dbInst, log, storedComponent,
-			incident, openedIncidents,
-			inComponent.Impact, inComponent.Text)
+		storedIncident, err := moveIncidentToHigherImpact(
+			actorDB, log, storedComponent, incident, openedIncidents, &inComponent,
+		)
 		if err != nil {
 			apiErrors.RaiseInternalErr(c, err)
 			return
@@ -385,6 +405,44 @@ func returnConflictResponse(comp *db.Component, inc *db.Incident) *ConflictRespo
 	}
 }
 
+// moveIncidentToHigherImpact moves the component to the incident with the higher impact
+// and records the changes of the incidents to the audit log in one transaction,
+// the changed incidents are notified after the commit.
+func moveIncidentToHigherImpact(
+	dbInst *db.DB, log *zap.Logger, storedComponent *db.Component,
+	incident *db.Incident, incidents []*db.Incident, inComponent *ComponentStatusPost,
+) (*db.Incident, error) {
+	var storedIncident *db.Incident
+	var changed []uint
+	err := audit.Track(dbInst, func(t *audit.Tracker) error {
+		captured := []uint{incident.ID}
+		if target := FindIncidentByImpact(inComponent.Impact, incidents); target != nil {
+			captured = append(captured, target.ID)
+		}
+		if err := t.Capture(captured...); err != nil {
+			return err
+		}
+
+		var err error
+		storedIncident, err = MoveIncidentToHigherImpact(
+			t.DB(), log, storedComponent,
+			incident, incidents,
+			inComponent.Impact, inComponent.Text)
+		if err != nil {
+			return err
+		}
+
+		changed = slices.Compact([]uint{incident.ID, storedIncident.ID})
+		return t.Record(string(webhook.ActionUpdated), changed...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v2.NotifyEventChanged(dbInst, log, webhook.ActionUpdated, changed...)
+	return storedIncident, nil
+}
+
 func createIncident(
 	dbInst *db.DB, log *zap.Logger, storedComponent *db.Component, inComponent *ComponentStatusPost,
 ) (*Incident, error) {
@@ -400,11 +458,18 @@ func createIncident(
 		Statuses:   nil,
 		Components: comps,
 	}
-	id, err := dbInst.SaveIncident(inc)
+	err := audit.Track(dbInst, func(t *audit.Tracker) error {
+		if _, err := t.DB().SaveIncident(inc); err != nil {
+			return err
+		}
+
+		return t.Record(string(webhook.ActionCreated), inc.ID)
+	})
 	if err != nil {
 		return nil, err
 	}
-	inc.ID = id
+
+	v2.NotifyEventChanged(dbInst, log, webhook.ActionCreated, inc.ID)
 	return toAPIIncident(inc), nil
 }
 
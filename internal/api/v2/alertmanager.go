@@ -0,0 +1,277 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/alertmanager"
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const (
+	alertSkippedDuplicate    = "duplicate notification"
+	alertSkippedNoComponents = "no matching components"
+	alertSkippedNoFinger     = "no fingerprint"
+	alertSkippedNoStart      = "no start date"
+	alertSkippedNotFiring    = "unknown or resolved alert"
+	alertResolvedText        = "The incident is resolved, all alerts of the components are resolved."
+	alertDescription         = "The incident is opened by the monitoring alert."
+)
+
+// AlertResult is the processing result of the alert, the skipped alerts have the reason.
+type AlertResult struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      string `json:"status"`
+	Components  []int  `json:"components,omitempty"`
+	Incidents   []int  `json:"incidents,omitempty"`
+	Skipped     string `json:"skipped,omitempty"`
+}
+
+// PostAlertmanagerHandler receives the Alertmanager webhook notifications.
+// The firing alerts open or escalate the system incidents of the matched components,
+// the resolved alerts add the status updates and resolve the incidents without the firing alerts.
+// The response is always successful for the valid payload, so the Alertmanager doesn't repeat the skipped alerts.
+func PostAlertmanagerHandler(dbInst *db.DB, logger *zap.Logger, rules alertmanager.Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var msg alertmanager.Message
+		if err := c.ShouldBindBodyWithJSON(&msg); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		components, err := dbInst.GetComponentsWithValues()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		log := logger.With(zap.String("receiver", msg.Receiver), zap.String("group_key", msg.GroupKey))
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		result := make([]*AlertResult, 0, len(msg.Alerts))
+		for i := range msg.Alerts {
+			alert := &msg.Alerts[i]
+			res := &AlertResult{Fingerprint: alert.Fingerprint, Status: alert.Status}
+
+			switch {
+			case alert.Fingerprint == "":
+				res.Skipped = alertSkippedNoFinger
+			case alert.StartsAt.IsZero():
+				// the start identifies the alert with the fingerprint, the repeated notifications can't be deduplicated
+				res.Skipped = alertSkippedNoStart
+			case alert.Status == alertmanager.StatusFiring:
+				err = processFiringAlert(c, actorDB, log, rules.Match(alert.Labels, components), alert, res)
+			default:
+				err = processResolvedAlert(c, actorDB, log, alert, res)
+			}
+			if err != nil {
+				apiErrors.RaiseInternalErr(c, err)
+				return
+			}
+
+			if res.Skipped != "" {
+				log.Info("the alert is skipped", zap.String("fingerprint", alert.Fingerprint),
+					zap.String("status", alert.Status), zap.String("reason", res.Skipped))
+			}
+			result = append(result, res)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": result})
+	}
+}
+
+// processFiringAlert opens or escalates the system incidents with the same logic as the system incident creation.
+func processFiringAlert(
+	c *gin.Context, dbInst *db.DB, log *zap.Logger,
+	components []db.Component, alert *alertmanager.Alert, res *AlertResult,
+) error {
+	if len(components) == 0 {
+		res.Skipped = alertSkippedNoComponents
+		return nil
+	}
+
+	if err := auth.CheckTokenAccess(c, auth.ScopeIncidentCreate, components); err != nil {
+		res.Skipped = err.Error()
+		return nil
+	}
+
+	ids := make([]int, len(components))
+	for i, comp := range components {
+		ids[i] = int(comp.ID)
+	}
+	res.Components = ids
+
+	startsAt := alert.StartsAt.UTC()
+	stored := &db.Alert{
+		Fingerprint: alert.Fingerprint,
+		StartsAt:    startsAt,
+		Status:      db.AlertFiring,
+		Title:       alert.Title(),
+		Impact:      alert.Impact(),
+		Components:  joinIDs(ids),
+	}
+	created, err := dbInst.CreateAlert(stored)
+	if err != nil {
+		return err
+	}
+	if !created {
+		res.Skipped = alertSkippedDuplicate
+		return nil
+	}
+
+	description := alert.Description()
+	if description == "" {
+		description = alertDescription
+	}
+	system := true
+	incData := IncidentData{
+		Title:       stored.Title,
+		Description: description,
+		Impact:      &stored.Impact,
+		Components:  ids,
+		StartDate:   startsAt,
+		System:      &system,
+		Type:        event.TypeIncident,
+	}
+
+	log.Info("the alert is firing", zap.String("fingerprint", alert.Fingerprint), zap.Ints("components", ids))
+	compResult, err := handleSystemIncidentCreation(dbInst, log, incData)
+	if err != nil {
+		// the next notification of the alert is processed again
+		if errDel := dbInst.DeleteAlert(stored.ID); errDel != nil {
+			log.Error("failed to delete the alert", zap.Error(errDel), zap.String("fingerprint", alert.Fingerprint))
+		}
+		return err
+	}
+
+	for _, r := range compResult {
+		res.Incidents = append(res.Incidents, r.IncidentID)
+	}
+
+	return nil
+}
+
+// processResolvedAlert updates the active system incidents of the alert components.
+// The incident is resolved if all its components had the alerts and none of them is firing,
+// otherwise the recovered components are added as a status update.
+func processResolvedAlert(
+	c *gin.Context, dbInst *db.DB, log *zap.Logger, alert *alertmanager.Alert, res *AlertResult,
+) error {
+	// the components are checked when the alert is firing
+	if err := auth.CheckTokenAccess(c, auth.ScopeIncidentUpdate, nil); err != nil {
+		res.Skipped = err.Error()
+		return nil
+	}
+
+	endsAt := alert.EndsAt.UTC()
+	if endsAt.IsZero() || endsAt.After(time.Now()) {
+		endsAt = time.Now().UTC()
+	}
+
+	stored, err := dbInst.ResolveAlert(alert.Fingerprint, alert.StartsAt, endsAt)
+	if err != nil {
+		if errors.Is(err, db.ErrDBAlertDSNotExist) {
+			res.Skipped = alertSkippedNotFiring
+			return nil
+		}
+		return err
+	}
+
+	res.Components = intIDs(stored.ComponentIDs())
+	log.Info("the alert is resolved",
+		zap.String("fingerprint", alert.Fingerprint), zap.Ints("components", res.Components))
+
+	handled := make(map[uint]bool)
+	for _, compID := range stored.ComponentIDs() {
+		events, errEvents := getActiveEventsForComponent(dbInst, compID)
+		if errEvents != nil {
+			return errEvents
+		}
+
+		for _, evnt := range events {
+			if evnt.Type != event.TypeIncident || !evnt.System || handled[evnt.ID] {
+				continue
+			}
+			handled[evnt.ID] = true
+
+			changed, errUpdate := updateIncidentWithResolvedAlert(dbInst, log, evnt.ID, stored, endsAt)
+			if errUpdate != nil {
+				return errUpdate
+			}
+			if changed {
+				res.Incidents = append(res.Incidents, int(evnt.ID))
+			}
+		}
+	}
+
+	return nil
+}
+
+func updateIncidentWithResolvedAlert(
+	dbInst *db.DB, log *zap.Logger, incidentID uint, alert *db.Alert, endsAt time.Time,
+) (bool, error) {
+	inc, err := dbInst.GetIncident(int(incidentID))
+	if err != nil {
+		return false, err
+	}
+
+	alerts, err := dbInst.GetAlertsSince(*inc.StartDate)
+	if err != nil {
+		return false, err
+	}
+
+	firing := make(map[uint]bool)
+	covered := make(map[uint]bool)
+	for _, a := range alerts {
+		for _, id := range a.ComponentIDs() {
+			covered[id] = true
+			if a.Status == db.AlertFiring {
+				firing[id] = true
+			}
+		}
+	}
+
+	resolve := true
+	var recovered []string
+	for _, comp := range inc.Components {
+		if firing[comp.ID] || !covered[comp.ID] {
+			resolve = false
+			continue
+		}
+		if slices.Contains(alert.ComponentIDs(), comp.ID) {
+			recovered = append(recovered, comp.PrintAttrs())
+		}
+	}
+
+	if endsAt.Before(*inc.StartDate) {
+		endsAt = time.Now().UTC()
+	}
+	patch := &PatchIncidentData{UpdateDate: endsAt, Status: inc.Status}
+	switch {
+	case resolve:
+		patch.Status = event.IncidentResolved
+		patch.Message = alertResolvedText
+	case len(recovered) > 0:
+		patch.Message = fmt.Sprintf("%s recovered, the alert %q is resolved.",
+			strings.Join(recovered, ", "), alert.Title)
+	default:
+		return false, nil
+	}
+
+	log.Info("update the incident with the resolved alert",
+		zap.Uint("incidentID", inc.ID), zap.String("status", string(patch.Status)))
+	if _, err = applyEventUpdate(dbInst, log, inc, patch); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
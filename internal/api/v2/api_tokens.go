@@ -0,0 +1,240 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+type APITokenID struct {
+	ID uint `uri:"id" binding:"required,gte=1"`
+}
+
+type APITokenData struct {
+	Name  string `json:"name" binding:"required"`
+	Owner string `json:"owner" binding:"required"`
+	// Scopes is a list of permissions, see auth.Scopes for the supported values.
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// Components and Regions restrict the token, the empty lists mean no restrictions.
+	Components []int    `json:"components,omitempty"`
+	Regions    []string `json:"regions,omitempty"`
+	// ExpiresAt is optional, the token without the expiration date is valid until it's revoked.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type APIToken struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"`
+	Scopes     []string   `json:"scopes"`
+	Components []uint     `json:"components"`
+	Regions    []string   `json:"regions"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	// Token is returned only once, after the token creation.
+	Token string `json:"token,omitempty"`
+}
+
+func toAPIToken(token *db.APIToken) *APIToken {
+	scopes := token.ScopeList()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	regions := token.RegionList()
+	if regions == nil {
+		regions = []string{}
+	}
+
+	return &APIToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Owner:      token.Owner,
+		Scopes:     scopes,
+		Components: token.ComponentIDs(),
+		Regions:    regions,
+		ExpiresAt:  token.ExpiresAt,
+		RevokedAt:  token.RevokedAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func GetAPITokensHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve api tokens")
+
+		tokens, err := dbInst.GetAPITokens()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		result := make([]*APIToken, len(tokens))
+		for i, token := range tokens {
+			result[i] = toAPIToken(token)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": result})
+	}
+}
+
+func GetAPITokenHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve api token")
+
+		var tokenID APITokenID
+		if err := c.ShouldBindUri(&tokenID); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrAPITokenInvalidID)
+			return
+		}
+
+		token, err := dbInst.GetAPIToken(tokenID.ID)
+		if err != nil {
+			raiseAPITokenErr(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, toAPIToken(token))
+	}
+}
+
+// PostAPITokenHandler issues a new token, the token value is returned only in this response.
+func PostAPITokenHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tokenData APITokenData
+		if err := c.ShouldBindBodyWithJSON(&tokenData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if err := validateAPITokenData(dbInst, &tokenData); err != nil {
+			if errors.Is(err, apiErrors.ErrAPITokenInvalidScope) ||
+				errors.Is(err, apiErrors.ErrAPITokenInvalidExpiry) ||
+				errors.Is(err, apiErrors.ErrComponentDSNotExist) ||
+				errors.Is(err, apiErrors.ErrRegionDSNotExist) {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		rawToken, err := auth.GenerateAPIToken()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		components := make([]string, len(tokenData.Components))
+		for i, id := range tokenData.Components {
+			components[i] = strconv.Itoa(id)
+		}
+
+		token := &db.APIToken{
+			Name:       tokenData.Name,
+			Owner:      tokenData.Owner,
+			TokenHash:  auth.HashAPIToken(rawToken),
+			Scopes:     strings.Join(tokenData.Scopes, ","),
+			Components: strings.Join(components, ","),
+			Regions:    strings.Join(tokenData.Regions, ","),
+			ExpiresAt:  tokenData.ExpiresAt,
+		}
+
+		if _, err = dbInst.SaveAPIToken(token); err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the api token is issued",
+			zap.Uint("tokenID", token.ID), zap.String("name", token.Name), zap.String("owner", token.Owner),
+			zap.String("user_id", auth.UserFromContext(c)),
+		)
+
+		resp := toAPIToken(token)
+		resp.Token = rawToken
+		c.JSON(http.StatusCreated, resp)
+	}
+}
+
+// DeleteAPITokenHandler revokes the token, the revoked token is kept in the list.
+func DeleteAPITokenHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tokenID APITokenID
+		if err := c.ShouldBindUri(&tokenID); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrAPITokenInvalidID)
+			return
+		}
+
+		token, err := dbInst.RevokeAPIToken(tokenID.ID)
+		if err != nil {
+			raiseAPITokenErr(c, err)
+			return
+		}
+
+		logger.Info("the api token is revoked",
+			zap.Uint("tokenID", token.ID), zap.String("name", token.Name),
+			zap.String("user_id", auth.UserFromContext(c)),
+		)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func raiseAPITokenErr(c *gin.Context, err error) {
+	if errors.Is(err, db.ErrDBAPITokenDSNotExist) {
+		apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrAPITokenDSNotExist)
+		return
+	}
+	apiErrors.RaiseInternalErr(c, err)
+}
+
+func validateAPITokenData(dbInst *db.DB, tokenData *APITokenData) error {
+	for _, scope := range tokenData.Scopes {
+		if !auth.IsValidScope(scope) {
+			return fmt.Errorf("%w: %s", apiErrors.ErrAPITokenInvalidScope, scope)
+		}
+	}
+
+	if tokenData.ExpiresAt != nil {
+		expiresAt := tokenData.ExpiresAt.UTC()
+		if !expiresAt.After(time.Now().UTC()) {
+			return apiErrors.ErrAPITokenInvalidExpiry
+		}
+		tokenData.ExpiresAt = &expiresAt
+	}
+
+	if len(tokenData.Components) > 0 {
+		dbComps, err := dbInst.GetComponentsAsMap()
+		if err != nil {
+			return err
+		}
+		for _, id := range tokenData.Components {
+			if _, ok := dbComps[id]; !ok {
+				return apiErrors.NewErrComponentDSNotExist(id)
+			}
+		}
+	}
+
+	for _, region := range tokenData.Regions {
+		exists, err := dbInst.RegionExists(region)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", apiErrors.ErrRegionDSNotExist, region)
+		}
+	}
+
+	return nil
+}
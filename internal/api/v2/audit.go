@@ -0,0 +1,88 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+type AuditQuery struct {
+	EventID uint       `form:"event_id" binding:"omitempty,gte=1"`
+	Actor   string     `form:"actor"`
+	Action  string     `form:"action"`
+	Since   *time.Time `form:"since"`
+	Until   *time.Time `form:"until"`
+	Limit   int        `form:"limit" binding:"omitempty,gte=1"`
+}
+
+// AuditRecord is the change of the event.
+type AuditRecord struct {
+	ID      uint   `json:"id"`
+	EventID uint   `json:"event_id"`
+	Action  string `json:"action"`
+	// Actor is the user name, "token:<name>" for API tokens, "system:checker" or "cli:<user>".
+	Actor string `json:"actor"`
+	// Changes are the changed fields with "before" and "after" values.
+	Changes   json.RawMessage `json:"changes"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+}
+
+// GetAuditHandler returns the audit log of the events, the latest records go first.
+func GetAuditHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query AuditQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrAuditQueryInvalidFormat)
+			return
+		}
+
+		if query.Since != nil && query.Until != nil && query.Until.Before(*query.Since) {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrAuditQueryInvalidFormat)
+			return
+		}
+
+		limit := defaultAuditLimit
+		if query.Limit > 0 {
+			limit = min(query.Limit, maxAuditLimit)
+		}
+
+		logger.Debug("retrieve audit log", zap.Any("query", query))
+		records, err := dbInst.GetAuditRecords(&db.AuditParams{
+			EventID: query.EventID,
+			Actor:   query.Actor,
+			Action:  query.Action,
+			Since:   query.Since,
+			Until:   query.Until,
+			Limit:   limit,
+		})
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		result := make([]*AuditRecord, len(records))
+		for i, r := range records {
+			result[i] = &AuditRecord{
+				ID:        r.ID,
+				EventID:   r.IncidentID,
+				Action:    r.Action,
+				Actor:     r.Actor,
+				Changes:   json.RawMessage(r.Changes),
+				CreatedAt: r.CreatedAt,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": result})
+	}
+}
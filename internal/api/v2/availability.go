@@ -0,0 +1,385 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+// Granularity of the availability report.
+const (
+	GranularityDay     = "day"
+	GranularityWeek    = "week"
+	GranularityMonth   = "month"
+	GranularityQuarter = "quarter"
+)
+
+const (
+	// defaultAvailabilityPeriods is the number of periods in the report if the "from" date is not set.
+	defaultAvailabilityPeriods = 12
+	// maxAvailabilityPeriods limits the size of the report, as example, 3 years by days.
+	maxAvailabilityPeriods = 1100
+)
+
+type ComponentAvailability struct {
+	ComponentID
+	Name         string                `json:"name"`
+	Availability []MonthlyAvailability `json:"availability"`
+	Region       string                `json:"region"`
+}
+
+// MonthlyAvailability is the availability of the component in the report period.
+// The name is kept for the backward compatibility, Year and Month are the year and the month of the period start.
+type MonthlyAvailability struct {
+	Year       int       `json:"year"`
+	Month      int       `json:"month"`      // Number of the month (1 - 12)
+	Percentage float64   `json:"percentage"` // Percent (0 - 100 / example: 95.23478)
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
+
+// AvailabilityQuery is the query of the availability report.
+// The weights are applied to the downtime of minor (1) and major (2) incidents, outages (3) always have weight 1.
+type AvailabilityQuery struct {
+	From               *time.Time `form:"from" binding:"omitempty"`
+	To                 *time.Time `form:"to" binding:"omitempty"`
+	Granularity        string     `form:"granularity" binding:"omitempty,oneof=day week month quarter"`
+	Components         *string    `form:"components"` // custom validation in parseAndSetComponents
+	Region             string     `form:"region"`
+	MinorWeight        *float64   `form:"minor_weight" binding:"omitempty,gte=0,lte=1"`
+	MajorWeight        *float64   `form:"major_weight" binding:"omitempty,gte=0,lte=1"`
+	ExcludeMaintenance bool       `form:"exclude_maintenance"`
+	IncludeDeleted     bool       `form:"include_deleted"`
+}
+
+type availabilityParams struct {
+	from        time.Time
+	to          time.Time
+	granularity string
+	// weights maps the incident impact to the part of the downtime, which is counted.
+	weights            map[int]float64
+	excludeMaintenance bool
+}
+
+type timeRange struct {
+	start time.Time
+	end   time.Time
+}
+
+func (r timeRange) hours() float64 {
+	return r.end.Sub(r.start).Hours()
+}
+
+func (r timeRange) intersect(other timeRange) (timeRange, bool) {
+	res := timeRange{start: maxTime(r.start, other.start), end: minTime(r.end, other.end)}
+	return res, res.start.Before(res.end)
+}
+
+func GetComponentsAvailabilityHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve availability of components")
+
+		var query AvailabilityQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			apiErrors.RaiseBadRequestErr(c, fmt.Errorf("%w: %w", apiErrors.ErrAvailabilityQueryInvalidFormat, err))
+			return
+		}
+
+		params, err := parseAvailabilityQuery(&query, time.Now().UTC())
+		if err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		var compFilter db.IncidentsParams
+		if err = parseAndSetComponents(query.Components, &compFilter); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrAvailabilityQueryInvalidFormat)
+			return
+		}
+
+		components, err := dbInst.GetComponentsWithIncidents(&db.ComponentsParams{IncludeDeleted: query.IncludeDeleted})
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		availability := make([]*ComponentAvailability, 0, len(components))
+		for _, comp := range components {
+			if compFilter.ComponentIDs != nil && !slices.Contains(compFilter.ComponentIDs, int(comp.ID)) {
+				continue
+			}
+			if query.Region != "" && comp.Region() != query.Region {
+				continue
+			}
+
+			compAvailability, calcErr := calculateAvailability(&comp, params)
+			if calcErr != nil {
+				apiErrors.RaiseInternalErr(c, calcErr)
+				return
+			}
+
+			sortComponentAvailability(compAvailability)
+
+			availability = append(availability, &ComponentAvailability{
+				ComponentID:  ComponentID{int(comp.ID)},
+				Region:       comp.Region(),
+				Name:         comp.Name,
+				Availability: compAvailability,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": availability})
+	}
+}
+
+// defaultAvailabilityParams returns the parameters of the default report:
+// the last 12 months including the current month, only outages are counted.
+func defaultAvailabilityParams(now time.Time) *availabilityParams {
+	params, _ := parseAvailabilityQuery(&AvailabilityQuery{}, now)
+	return params
+}
+
+func parseAvailabilityQuery(query *AvailabilityQuery, now time.Time) (*availabilityParams, error) {
+	params := &availabilityParams{
+		granularity:        query.Granularity,
+		weights:            map[int]float64{3: 1}, //nolint:mnd
+		excludeMaintenance: query.ExcludeMaintenance,
+	}
+
+	if params.granularity == "" {
+		params.granularity = GranularityMonth
+	}
+
+	// by default, the report ends with the current period and covers the 12 periods
+	if query.To != nil {
+		params.to = query.To.UTC()
+	} else {
+		params.to = nextPeriodStart(periodStart(now, params.granularity), params.granularity)
+	}
+
+	if query.From != nil {
+		params.from = query.From.UTC()
+	} else {
+		params.from = periodStart(params.to.Add(-time.Nanosecond), params.granularity)
+		for range defaultAvailabilityPeriods - 1 {
+			params.from = prevPeriodStart(params.from, params.granularity)
+		}
+	}
+
+	if !params.from.Before(params.to) {
+		return nil, apiErrors.ErrAvailabilityQueryInvalidPeriod
+	}
+
+	if len(availabilityPeriods(params)) > maxAvailabilityPeriods {
+		return nil, apiErrors.ErrAvailabilityQueryTooManyPeriods
+	}
+
+	if query.MinorWeight != nil {
+		params.weights[1] = *query.MinorWeight
+	}
+	if query.MajorWeight != nil {
+		params.weights[2] = *query.MajorWeight
+	}
+
+	return params, nil
+}
+
+func periodStart(t time.Time, granularity string) time.Time {
+	year, month, day := t.Date()
+
+	switch granularity {
+	case GranularityDay:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	case GranularityWeek:
+		// the week starts on Monday
+		offset := (int(t.Weekday()) + 6) % 7 //nolint:mnd
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, time.UTC)
+	case GranularityQuarter:
+		return time.Date(year, ((month-1)/3)*3+1, 1, 0, 0, 0, 0, time.UTC) //nolint:mnd
+	}
+
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextPeriodStart(start time.Time, granularity string) time.Time {
+	return shiftPeriod(start, granularity, 1)
+}
+
+func prevPeriodStart(start time.Time, granularity string) time.Time {
+	return shiftPeriod(start, granularity, -1)
+}
+
+func shiftPeriod(start time.Time, granularity string, n int) time.Time {
+	switch granularity {
+	case GranularityDay:
+		return start.AddDate(0, 0, n)
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7*n) //nolint:mnd
+	case GranularityQuarter:
+		return start.AddDate(0, 3*n, 0) //nolint:mnd
+	}
+
+	return start.AddDate(0, n, 0)
+}
+
+// availabilityPeriods splits the report range by the granularity,
+// the first and the last periods are cut by the report range.
+func availabilityPeriods(params *availabilityParams) []timeRange {
+	var periods []timeRange
+	report := timeRange{start: params.from, end: params.to}
+
+	for start := periodStart(params.from, params.granularity); start.Before(params.to); {
+		end := nextPeriodStart(start, params.granularity)
+		if period, ok := report.intersect(timeRange{start: start, end: end}); ok {
+			periods = append(periods, period)
+		}
+		start = end
+
+		if len(periods) > maxAvailabilityPeriods {
+			break
+		}
+	}
+
+	return periods
+}
+
+// maintenanceWindows returns the merged windows of the maintenances of the component,
+// cancelled maintenances and maintenances in the review are skipped.
+func maintenanceWindows(component *db.Component) []timeRange {
+	var windows []timeRange
+	for _, inc := range component.Incidents {
+		if inc.Type != event.TypeMaintenance || inc.StartDate == nil || inc.EndDate == nil {
+			continue
+		}
+		if inc.Status == event.MaintenanceCancelled || event.IsMaintenanceReviewStatus(inc.Status) {
+			continue
+		}
+		windows = append(windows, timeRange{start: *inc.StartDate, end: *inc.EndDate})
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].start.Before(windows[j].start)
+	})
+
+	merged := make([]timeRange, 0, len(windows))
+	for _, w := range windows {
+		last := len(merged) - 1
+		if last >= 0 && !w.start.After(merged[last].end) {
+			merged[last].end = maxTime(merged[last].end, w.end)
+			continue
+		}
+		merged = append(merged, w)
+	}
+
+	return merged
+}
+
+// overlapHours returns the hours of the range, which are covered by the windows.
+func overlapHours(r timeRange, windows []timeRange) float64 {
+	var hours float64
+	for _, w := range windows {
+		if overlap, ok := r.intersect(w); ok {
+			hours += overlap.hours()
+		}
+	}
+
+	return hours
+}
+
+func sortComponentAvailability(availabilities []MonthlyAvailability) {
+	sort.Slice(availabilities, func(i, j int) bool {
+		return availabilities[i].Start.After(availabilities[j].Start)
+	})
+}
+
+// calculateAvailability calculates the availability of the component for every period of the report.
+// The downtime of an incident is multiplied by the weight of its impact on the component.
+// If the maintenances are excluded, the maintenance windows are removed from the downtime and from the period.
+func calculateAvailability(component *db.Component, params *availabilityParams) ([]MonthlyAvailability, error) {
+	const (
+		precisionFactor = 100000
+		fullPercentage  = 100
+		roundFactor     = 0.5
+	)
+
+	if component == nil {
+		return nil, fmt.Errorf("component is nil")
+	}
+
+	if len(component.Incidents) == 0 {
+		return nil, nil
+	}
+
+	periods := availabilityPeriods(params)
+	downtime := make([]float64, len(periods))
+
+	var windows []timeRange
+	if params.excludeMaintenance {
+		windows = maintenanceWindows(component)
+	}
+
+	for _, inc := range component.Incidents {
+		if inc.EndDate == nil || inc.Impact == nil {
+			continue
+		}
+
+		weight := params.weights[inc.ComponentImpact(component.ID)]
+		if weight == 0 {
+			continue
+		}
+
+		// the incident is cut by the periods, as example, the incident started at 01:00 31/12
+		// and finished at 02:00 01/01 is split between December and January
+		incRange := timeRange{start: *inc.StartDate, end: *inc.EndDate}
+		for i, period := range periods {
+			overlap, ok := incRange.intersect(period)
+			if !ok {
+				continue
+			}
+			downtime[i] += (overlap.hours() - overlapHours(overlap, windows)) * weight
+		}
+	}
+
+	availability := make([]MonthlyAvailability, 0, len(periods))
+	for i, period := range periods {
+		percentage := float64(fullPercentage)
+		totalHours := period.hours() - overlapHours(period, windows)
+		if totalHours > 0 {
+			percentage = max(fullPercentage-(downtime[i]/totalHours*fullPercentage), 0)
+		}
+		percentage = float64(int(percentage*precisionFactor+roundFactor)) / precisionFactor
+
+		availability = append(availability, MonthlyAvailability{
+			Year:       period.start.Year(),
+			Month:      int(period.start.Month()),
+			Percentage: percentage,
+			Start:      period.start,
+			End:        period.end,
+		})
+	}
+
+	return availability, nil
+}
+
+func minTime(start, end time.Time) time.Time {
+	if start.Before(end) {
+		return start
+	}
+	return end
+}
+
+func maxTime(start, end time.Time) time.Time {
+	if start.After(end) {
+		return start
+	}
+	return end
+}
@@ -0,0 +1,360 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/recurrence"
+)
+
+type MaintenanceSeriesID struct {
+	ID uint `uri:"id" binding:"required,gte=1"`
+}
+
+type MaintenanceSeriesData struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description,omitempty"`
+	Components  []int  `json:"components" binding:"required,min=1"`
+	// Recurrence is the RFC 5545 recurrence rule, as example "FREQ=WEEKLY;BYDAY=TU".
+	Recurrence string `json:"recurrence" binding:"required"`
+	// StartDate and EndDate are the first occurrence, every occurrence has the same duration.
+	StartDate    time.Time `json:"start_date" binding:"required"`
+	EndDate      time.Time `json:"end_date" binding:"required"`
+	ContactEmail string    `json:"contact_email,omitempty"`
+}
+
+// PatchMaintenanceSeriesData changes the series, the missing fields are not changed.
+type PatchMaintenanceSeriesData struct {
+	Title        *string    `json:"title,omitempty"`
+	Description  *string    `json:"description,omitempty"`
+	Components   []int      `json:"components,omitempty"`
+	Recurrence   *string    `json:"recurrence,omitempty"`
+	StartDate    *time.Time `json:"start_date,omitempty"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	ContactEmail *string    `json:"contact_email,omitempty"`
+}
+
+type MaintenanceSeries struct {
+	ID uint `json:"id"`
+	MaintenanceSeriesData
+	// Schedule is the human-readable recurrence rule.
+	Schedule string `json:"schedule"`
+	// Creator and ContactEmail are returned only for authenticated users.
+	Creator     string     `json:"creator,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ModifiedAt  *time.Time `json:"modified_at,omitempty"`
+}
+
+func toAPIMaintenanceSeries(series *db.MaintenanceSeries, role auth.Role) *MaintenanceSeries {
+	components := make([]int, 0, len(series.ComponentIDs()))
+	for _, id := range series.ComponentIDs() {
+		components = append(components, int(id))
+	}
+
+	result := &MaintenanceSeries{
+		ID: series.ID,
+		MaintenanceSeriesData: MaintenanceSeriesData{
+			Title:      series.Title,
+			Components: components,
+			Recurrence: series.RRule,
+			StartDate:  series.StartDate,
+			EndDate:    series.EndDate,
+		},
+		Schedule:    recurrence.DescribeRule(series.RRule, series.StartDate),
+		CancelledAt: series.CancelledAt,
+		CreatedAt:   series.CreatedAt,
+		ModifiedAt:  series.ModifiedAt,
+	}
+
+	if series.Description != nil {
+		result.Description = *series.Description
+	}
+
+	if role != auth.RoleNone {
+		if series.Creator != nil {
+			result.Creator = *series.Creator
+		}
+		if series.ContactEmail != nil {
+			result.ContactEmail = *series.ContactEmail
+		}
+	}
+
+	return result
+}
+
+func GetMaintenanceSeriesListHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve maintenance series")
+
+		seriesList, err := dbInst.GetMaintenanceSeriesList()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		role := auth.RoleFromContext(c)
+		result := make([]*MaintenanceSeries, len(seriesList))
+		for i, series := range seriesList {
+			result[i] = toAPIMaintenanceSeries(series, role)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": result})
+	}
+}
+
+func GetMaintenanceSeriesHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve maintenance series by id")
+
+		series := getMaintenanceSeries(c, dbInst)
+		if series == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, toAPIMaintenanceSeries(series, auth.RoleFromContext(c)))
+	}
+}
+
+// PostMaintenanceSeriesHandler creates the series, the occurrences are created by the checker.
+func PostMaintenanceSeriesHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var seriesData MaintenanceSeriesData
+		if err := c.ShouldBindBodyWithJSON(&seriesData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		seriesData.StartDate = seriesData.StartDate.UTC()
+		seriesData.EndDate = seriesData.EndDate.UTC()
+
+		if !checkTokenAccessByIDs(c, dbInst, auth.ScopeMaintenanceCreate, seriesData.Components) {
+			return
+		}
+
+		series := &db.MaintenanceSeries{}
+		applyMaintenanceSeriesData(series, &seriesData)
+
+		if err := validateMaintenanceSeries(dbInst, series); err != nil {
+			raiseMaintenanceSeriesErr(c, err)
+			return
+		}
+
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		if _, err := actorDB.SaveMaintenanceSeries(series); err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the maintenance series is created",
+			zap.Uint("seriesID", series.ID), zap.String("rrule", series.RRule),
+			zap.String("user_id", auth.UserFromContext(c)),
+		)
+		c.JSON(http.StatusCreated, toAPIMaintenanceSeries(series, auth.RoleFromContext(c)))
+	}
+}
+
+// PatchMaintenanceSeriesHandler changes the whole series.
+// The upcoming occurrences, which are not changed separately, are updated by the checker.
+func PatchMaintenanceSeriesHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		series := getMaintenanceSeries(c, dbInst)
+		if series == nil {
+			return
+		}
+
+		var patch PatchMaintenanceSeriesData
+		if err := c.ShouldBindBodyWithJSON(&patch); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if series.CancelledAt != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrMaintenanceSeriesCancelled)
+			return
+		}
+
+		components := intIDs(series.ComponentIDs())
+		if !checkTokenAccessByIDs(c, dbInst, auth.ScopeMaintenanceUpdate, slices.Concat(components, patch.Components)) {
+			return
+		}
+
+		patchMaintenanceSeries(series, &patch)
+
+		if err := validateMaintenanceSeries(dbInst, series); err != nil {
+			raiseMaintenanceSeriesErr(c, err)
+			return
+		}
+
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		if err := actorDB.ModifyMaintenanceSeries(series); err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the maintenance series is modified",
+			zap.Uint("seriesID", series.ID), zap.String("user_id", auth.UserFromContext(c)),
+		)
+		c.JSON(http.StatusOK, toAPIMaintenanceSeries(series, auth.RoleFromContext(c)))
+	}
+}
+
+// DeleteMaintenanceSeriesHandler cancels the series, the planned occurrences are cancelled by the checker.
+// The cancelled series is kept in the list.
+func DeleteMaintenanceSeriesHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		series := getMaintenanceSeries(c, dbInst)
+		if series == nil {
+			return
+		}
+
+		if !checkTokenAccessByIDs(c, dbInst, auth.ScopeMaintenanceUpdate, intIDs(series.ComponentIDs())) {
+			return
+		}
+
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		if _, err := actorDB.CancelMaintenanceSeries(series.ID); err != nil {
+			raiseMaintenanceSeriesErr(c, err)
+			return
+		}
+
+		logger.Info("the maintenance series is cancelled",
+			zap.Uint("seriesID", series.ID), zap.String("user_id", auth.UserFromContext(c)),
+		)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func getMaintenanceSeries(c *gin.Context, dbInst *db.DB) *db.MaintenanceSeries {
+	var seriesID MaintenanceSeriesID
+	if err := c.ShouldBindUri(&seriesID); err != nil {
+		apiErrors.RaiseBadRequestErr(c, apiErrors.ErrMaintenanceSeriesInvalidID)
+		return nil
+	}
+
+	series, err := dbInst.GetMaintenanceSeries(seriesID.ID)
+	if err != nil {
+		raiseMaintenanceSeriesErr(c, err)
+		return nil
+	}
+
+	return series
+}
+
+func raiseMaintenanceSeriesErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrDBMaintenanceSeriesDSNotExist):
+		apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrMaintenanceSeriesDSNotExist)
+	case errors.Is(err, apiErrors.ErrMaintenanceSeriesInvalidRule),
+		errors.Is(err, apiErrors.ErrMaintenanceEndDateBeforeStart),
+		errors.Is(err, apiErrors.ErrMaintenanceContactEmailInvalid),
+		errors.Is(err, apiErrors.ErrComponentDSNotExist):
+		apiErrors.RaiseBadRequestErr(c, err)
+	default:
+		apiErrors.RaiseInternalErr(c, err)
+	}
+}
+
+func applyMaintenanceSeriesData(series *db.MaintenanceSeries, data *MaintenanceSeriesData) {
+	series.Title = data.Title
+	series.Description = optionalString(data.Description)
+	series.Components = componentsByIDs(data.Components)
+	series.RRule = data.Recurrence
+	series.StartDate = data.StartDate
+	series.EndDate = data.EndDate
+	series.ContactEmail = optionalString(data.ContactEmail)
+}
+
+func patchMaintenanceSeries(series *db.MaintenanceSeries, patch *PatchMaintenanceSeriesData) {
+	if patch.Title != nil {
+		series.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		series.Description = optionalString(*patch.Description)
+	}
+	if len(patch.Components) > 0 {
+		series.Components = componentsByIDs(patch.Components)
+	}
+	if patch.Recurrence != nil {
+		series.RRule = *patch.Recurrence
+	}
+	if patch.StartDate != nil {
+		series.StartDate = patch.StartDate.UTC()
+	}
+	if patch.EndDate != nil {
+		series.EndDate = patch.EndDate.UTC()
+	}
+	if patch.ContactEmail != nil {
+		series.ContactEmail = optionalString(*patch.ContactEmail)
+	}
+}
+
+// validateMaintenanceSeries checks the series and normalises the recurrence rule.
+func validateMaintenanceSeries(dbInst *db.DB, series *db.MaintenanceSeries) error {
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return fmt.Errorf("%w: %s", apiErrors.ErrMaintenanceSeriesInvalidRule, err.Error())
+	}
+	series.RRule = rule.String()
+
+	if !series.EndDate.After(series.StartDate) {
+		return apiErrors.ErrMaintenanceEndDateBeforeStart
+	}
+
+	if series.ContactEmail != nil {
+		if _, err = mail.ParseAddress(*series.ContactEmail); err != nil {
+			return apiErrors.ErrMaintenanceContactEmailInvalid
+		}
+	}
+
+	dbComps, err := dbInst.GetComponentsAsMap()
+	if err != nil {
+		return err
+	}
+	for _, id := range series.ComponentIDs() {
+		if _, ok := dbComps[int(id)]; !ok {
+			return apiErrors.NewErrComponentDSNotExist(int(id))
+		}
+	}
+
+	return nil
+}
+
+func joinIDs(ids []int) string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+
+	return strings.Join(values, ",")
+}
+
+func componentsByIDs(ids []int) []db.Component {
+	components := make([]db.Component, len(ids))
+	for i, id := range ids {
+		components[i] = db.Component{ID: uint(id)}
+	}
+
+	return components
+}
+
+func intIDs(ids []uint) []int {
+	result := make([]int, len(ids))
+	for i, id := range ids {
+		result[i] = int(id)
+	}
+
+	return result
+}
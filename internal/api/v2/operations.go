@@ -0,0 +1,153 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/audit"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
+)
+
+// The functions in this file are the event operations without the HTTP layer.
+// They apply the same rules as the handlers and are used by the admin CLI.
+
+// CreateEvent validates and creates the event as it's done by the API for the given role,
+// the components of a new incident are moved from the opened incidents.
+func CreateEvent(
+	dbInst *db.DB, log *zap.Logger, incData IncidentData, role auth.Role,
+) ([]*ProcessComponentResp, error) {
+	normalizeEventDates(&incData)
+
+	if err := validateEventCreation(incData); err != nil {
+		return nil, err
+	}
+
+	if role == auth.RoleCreator && incData.Type != event.TypeMaintenance {
+		return nil, apiErrors.ErrMaintenanceCreationForbiddenType
+	}
+
+	if err := validateMaintenanceSubmission(incData, role); err != nil {
+		return nil, err
+	}
+
+	incData.Status = ""
+	if role == auth.RoleCreator {
+		incData.Status = event.MaintenancePendingReview
+	}
+
+	return createEventWithComponents(dbInst, log.With(zap.Any("incidentData", incData)), incData)
+}
+
+// UpdateEvent adds the status update to the event, the event fields are changed by the update data.
+func UpdateEvent(dbInst *db.DB, log *zap.Logger, stored *db.Incident, incData PatchIncidentData) (*db.Incident, error) {
+	normalizePatchDates(&incData)
+
+	if err := checkPatchData(&incData, stored); err != nil {
+		return nil, err
+	}
+
+	return applyEventUpdate(dbInst, log, stored, &incData)
+}
+
+// ExtractComponents moves the components of the incident to a new incident with the same impact.
+func ExtractComponents(dbInst *db.DB, log *zap.Logger, stored *db.Incident, componentIDs []int) (*db.Incident, error) {
+	movedComponents, err := componentsToExtract(stored, componentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return extractComponents(dbInst, log, stored, movedComponents)
+}
+
+// MoveComponent moves the component from one opened incident to another,
+// the source incident is closed if the component is the last one.
+func MoveComponent(dbInst *db.DB, log *zap.Logger, componentID uint, from, to *db.Incident) (*db.Incident, error) {
+	for _, inc := range []*db.Incident{from, to} {
+		if inc.Type != event.TypeIncident || inc.EndDate != nil {
+			return nil, fmt.Errorf("%w: %d", apiErrors.ErrIncidentMoveNotOpened, inc.ID)
+		}
+	}
+
+	if from.ID == to.ID {
+		return nil, apiErrors.ErrIncidentMoveSameIncident
+	}
+
+	var comp *db.Component
+	for i := range from.Components {
+		if from.Components[i].ID == componentID {
+			comp = &from.Components[i]
+			break
+		}
+	}
+	if comp == nil {
+		return nil, fmt.Errorf("component %d is not in the incident", componentID)
+	}
+
+	for _, c := range to.Components {
+		if c.ID == componentID {
+			return nil, fmt.Errorf("component %d is already in the incident %d", componentID, to.ID)
+		}
+	}
+
+	closeOld := len(from.Components) == 1
+	inc, err := moveComponent(dbInst, comp, from, to, closeOld)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info(
+		"the component is moved", zap.Uint("componentID", componentID),
+		zap.Uint("fromID", from.ID), zap.Uint("toID", to.ID), zap.Bool("closed", closeOld),
+	)
+	NotifyEventChanged(dbInst, log, webhook.ActionUpdated, from.ID, to.ID)
+
+	return inc, nil
+}
+
+// MergeEvents merges the source incident into the target incident, the source is closed by system.
+func MergeEvents(dbInst *db.DB, log *zap.Logger, source, target *db.Incident) (*db.Incident, error) {
+	for _, inc := range []*db.Incident{source, target} {
+		if inc.Type != event.TypeIncident || inc.EndDate != nil {
+			return nil, fmt.Errorf("%w: %d", apiErrors.ErrIncidentMergeNotOpened, inc.ID)
+		}
+	}
+
+	if source.ID == target.ID {
+		return nil, apiErrors.ErrIncidentMergeSameIncident
+	}
+
+	var inc *db.Incident
+	err := audit.Track(dbInst, func(t *audit.Tracker) error {
+		if err := t.Capture(source.ID, target.ID); err != nil {
+			return err
+		}
+
+		var err error
+		if inc, err = t.DB().MergeIncidents(source.ID, target.ID); err != nil {
+			return err
+		}
+
+		if err = t.Record(string(webhook.ActionMerged), source.ID); err != nil {
+			return err
+		}
+		return t.Record(string(webhook.ActionUpdated), target.ID)
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrDBIncidentNotOpened) {
+			return nil, fmt.Errorf("%w: %w", apiErrors.ErrIncidentMergeNotOpened, err)
+		}
+		return nil, err
+	}
+
+	log.Info("the incident is merged", zap.Uint("sourceID", source.ID), zap.Uint("targetID", target.ID))
+	NotifyEventChanged(dbInst, log, webhook.ActionMerged, source.ID)
+	NotifyEventChanged(dbInst, log, webhook.ActionUpdated, target.ID)
+
+	return inc, nil
+}
@@ -0,0 +1,187 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+const maxRegionNameLength = 100
+
+type RegionID struct {
+	ID uint `uri:"id" binding:"required,gte=1"`
+}
+
+type RegionData struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+type PatchRegionData struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+func GetRegionsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve regions")
+
+		regions, err := dbInst.GetRegions()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": regions})
+	}
+}
+
+func GetRegionHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve region")
+
+		region := getRegion(c, dbInst)
+		if region == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, region)
+	}
+}
+
+func PostRegionHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var regionData RegionData
+		if err := c.ShouldBindBodyWithJSON(&regionData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		name, err := validateRegionName(regionData.Name)
+		if err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		region := &db.Region{
+			Name:        name,
+			Description: regionData.Description,
+		}
+
+		if _, err = dbInst.SaveRegion(region); err != nil {
+			if errors.Is(err, db.ErrDBRegionExists) {
+				apiErrors.RaiseConflictErr(c, apiErrors.ErrRegionExist)
+				return
+			}
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the region is created", zap.Uint("regionID", region.ID), zap.String("name", region.Name))
+		c.JSON(http.StatusCreated, region)
+	}
+}
+
+// PatchRegionHandler updates the region, the region attribute of the components is renamed together with the region.
+func PatchRegionHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region := getRegion(c, dbInst)
+		if region == nil {
+			return
+		}
+
+		var regionData PatchRegionData
+		if err := c.ShouldBindBodyWithJSON(&regionData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if regionData.Name != nil {
+			name, err := validateRegionName(*regionData.Name)
+			if err != nil {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
+			region.Name = name
+		}
+
+		if regionData.Description != nil {
+			region.Description = *regionData.Description
+		}
+
+		if err := dbInst.ModifyRegion(region); err != nil {
+			switch {
+			case errors.Is(err, db.ErrDBRegionExists):
+				apiErrors.RaiseConflictErr(c, apiErrors.ErrRegionExist)
+			case errors.Is(err, db.ErrDBRegionDSNotExist):
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrRegionDSNotExist)
+			default:
+				apiErrors.RaiseInternalErr(c, err)
+			}
+			return
+		}
+
+		logger.Info("the region is updated", zap.Uint("regionID", region.ID), zap.String("name", region.Name))
+		c.JSON(http.StatusOK, region)
+	}
+}
+
+func DeleteRegionHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var regionID RegionID
+		if err := c.ShouldBindUri(&regionID); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrRegionInvalidID)
+			return
+		}
+
+		if err := dbInst.DeleteRegion(regionID.ID); err != nil {
+			switch {
+			case errors.Is(err, db.ErrDBRegionDSNotExist):
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrRegionDSNotExist)
+			case errors.Is(err, db.ErrDBRegionInUse):
+				apiErrors.RaiseConflictErr(c, apiErrors.ErrRegionInUse)
+			default:
+				apiErrors.RaiseInternalErr(c, err)
+			}
+			return
+		}
+
+		logger.Info("the region is deleted", zap.Uint("regionID", regionID.ID))
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func getRegion(c *gin.Context, dbInst *db.DB) *db.Region {
+	var regionID RegionID
+	if err := c.ShouldBindUri(&regionID); err != nil {
+		apiErrors.RaiseBadRequestErr(c, apiErrors.ErrRegionInvalidID)
+		return nil
+	}
+
+	region, err := dbInst.GetRegion(regionID.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBRegionDSNotExist) {
+			apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrRegionDSNotExist)
+			return nil
+		}
+		apiErrors.RaiseInternalErr(c, err)
+		return nil
+	}
+
+	return region
+}
+
+func validateRegionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxRegionNameLength {
+		return "", apiErrors.ErrRegionInvalidName
+	}
+
+	return name, nil
+}
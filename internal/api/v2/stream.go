@@ -0,0 +1,183 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/stream"
+)
+
+const (
+	streamHeartbeatPeriod = time.Second * 30
+	lastEventIDHeader     = "Last-Event-ID"
+)
+
+type StreamQuery struct {
+	Types      *string `form:"type"`       // custom validation in parseAndSetTypes
+	Components *string `form:"components"` // custom validation in parseAndSetComponents
+	Region     string  `form:"region"`
+	// LastEventID is used if the client can't set the Last-Event-ID header.
+	LastEventID *uint `form:"last_event_id"`
+}
+
+// GetStreamHandler pushes events changes as server-sent events.
+// The stream can be resumed with the Last-Event-ID header, the messages are stored for stream.Retention period.
+func GetStreamHandler(broker *stream.Broker, dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query StreamQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrIncidentFQueryInvalidFormat)
+			return
+		}
+
+		filter, err := parseStreamFilter(dbInst, &query)
+		if err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+		filter.HideNotPublic = auth.RoleFromContext(c) == auth.RoleNone
+
+		lastID, resume, err := parseLastEventID(c, &query)
+		if err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		// subscribe before the replay to not lose messages between them
+		messages, unsubscribe := broker.Subscribe()
+		defer unsubscribe()
+
+		var replay []*stream.Message
+		if resume {
+			replay, err = broker.Replay(lastID)
+			if err != nil {
+				apiErrors.RaiseInternalErr(c, err)
+				return
+			}
+		}
+
+		logger.Debug("start the stream", zap.Any("filter", filter), zap.Uint("lastEventID", lastID))
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		for _, msg := range replay {
+			lastID = msg.ID
+			if filter.Match(msg) {
+				writeStreamMessage(c, logger, msg)
+			}
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeatPeriod)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				logger.Debug("the stream is closed by the client")
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					logger.Debug("the stream is closed by the server")
+					return
+				}
+				if msg.ID <= lastID {
+					continue
+				}
+				lastID = msg.ID
+				if filter.Match(msg) {
+					writeStreamMessage(c, logger, msg)
+				}
+			case <-heartbeat.C:
+				_, _ = fmt.Fprint(c.Writer, ": heartbeat\n\n")
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func parseStreamFilter(dbInst *db.DB, query *StreamQuery) (*stream.Filter, error) {
+	params := &db.IncidentsParams{}
+	if err := parseAndSetTypes(query.Types, params); err != nil {
+		return nil, err
+	}
+	if err := parseAndSetComponents(query.Components, params); err != nil {
+		return nil, err
+	}
+
+	filter := &stream.Filter{Types: params.Types}
+
+	if params.ComponentIDs != nil {
+		filter.ComponentIDs = make([]uint, len(params.ComponentIDs))
+		for i, id := range params.ComponentIDs {
+			filter.ComponentIDs[i] = uint(id)
+		}
+	}
+
+	if query.Region == "" {
+		return filter, nil
+	}
+
+	components, err := dbInst.GetComponentsWithValues()
+	if err != nil {
+		return nil, err
+	}
+
+	regionIDs := make([]uint, 0)
+	for _, comp := range components {
+		if comp.Region() != query.Region {
+			continue
+		}
+		// the region narrows the components list, if it's set
+		if filter.ComponentIDs != nil && !slices.Contains(filter.ComponentIDs, comp.ID) {
+			continue
+		}
+		regionIDs = append(regionIDs, comp.ID)
+	}
+	filter.ComponentIDs = regionIDs
+
+	return filter, nil
+}
+
+// parseLastEventID returns the ID of the last received message and true, if the stream should be resumed.
+func parseLastEventID(c *gin.Context, query *StreamQuery) (uint, bool, error) {
+	header := c.GetHeader(lastEventIDHeader)
+	if header == "" {
+		if query.LastEventID != nil {
+			return *query.LastEventID, true, nil
+		}
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseUint(header, 10, 0)
+	if err != nil {
+		return 0, false, apiErrors.ErrStreamLastEventIDInvalid
+	}
+
+	return uint(id), true, nil
+}
+
+func writeStreamMessage(c *gin.Context, logger *zap.Logger, msg *stream.Message) {
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		logger.Error("failed to marshal the stream message", zap.Error(err), zap.Uint("id", msg.ID))
+		return
+	}
+
+	_, _ = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Payload.Action, data)
+	c.Writer.Flush()
+}
@@ -0,0 +1,347 @@
+package v2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/email"
+)
+
+const (
+	subscriptionTokenLength = 32
+	// the confirmation isn't sent again for the same email in this period
+	subscriptionResendDelay = time.Minute * 10
+
+	subscriptionPendingMsg   = "the confirmation email is sent, please follow the link to activate the subscription"
+	subscriptionConfirmedMsg = "the subscription is confirmed"
+	subscriptionCancelledMsg = "the subscription is cancelled"
+)
+
+const subscriptionPageText = `<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Action}}<form method="post" action="{{.Action}}"><button type="submit">{{.Button}}</button></form>
+{{else}}<p>{{.Message}}</p>
+{{end}}</body>
+</html>
+`
+
+//nolint:gochecknoglobals
+var subscriptionPageTemplate = template.Must(template.New("subscription").Parse(subscriptionPageText))
+
+type SubscriptionData struct {
+	Email string `json:"email" binding:"required"`
+	// Components and Regions are the subscribed components and the regions of the components,
+	// at least one of them is required.
+	Components []int    `json:"components,omitempty"`
+	Regions    []string `json:"regions,omitempty"`
+}
+
+type SubscriptionToken struct {
+	Token string `form:"token"`
+}
+
+type Subscription struct {
+	Email       string     `json:"email"`
+	Components  []uint     `json:"components"`
+	Regions     []string   `json:"regions"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
+
+func toSubscription(sub *db.Subscriber) *Subscription {
+	regions := sub.RegionList()
+	if regions == nil {
+		regions = []string{}
+	}
+
+	return &Subscription{
+		Email:       sub.Email,
+		Components:  sub.ComponentIDs(),
+		Regions:     regions,
+		ConfirmedAt: sub.ConfirmedAt,
+	}
+}
+
+// PostSubscriptionHandler stores the unconfirmed subscription and enqueues the confirmation message.
+// The response is the same for the known and the new emails, the subscription is active after the confirmation.
+func PostSubscriptionHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var subData SubscriptionData
+		if err := c.ShouldBindBodyWithJSON(&subData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		address, err := mail.ParseAddress(subData.Email)
+		if err != nil || address.Address != subData.Email {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrSubscriptionInvalidEmail)
+			return
+		}
+
+		components, err := validateSubscriptionData(dbInst, &subData)
+		if err != nil {
+			if errors.Is(err, apiErrors.ErrSubscriptionEmpty) ||
+				errors.Is(err, apiErrors.ErrComponentDSNotExist) ||
+				errors.Is(err, apiErrors.ErrRegionDSNotExist) {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		recent, err := hasRecentSubscription(dbInst, subData.Email)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+		if recent {
+			logger.Info("the confirmation is already sent recently, skip the subscription")
+			c.JSON(http.StatusAccepted, gin.H{"message": subscriptionPendingMsg})
+			return
+		}
+
+		sub, err := newSubscriber(&subData)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		confirmation, err := email.NewConfirmationMessage(components, subData.Regions)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		if err = dbInst.SaveSubscriber(sub, confirmation); err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the subscription is created", zap.Uint("subscriberID", sub.ID))
+		c.JSON(http.StatusAccepted, gin.H{"message": subscriptionPendingMsg})
+	}
+}
+
+// GetSubscriptionConfirmHandler returns the page, which confirms the subscription by the POST request.
+// The link of the message doesn't confirm the subscription, the mail scanners open the links automatically.
+func GetSubscriptionConfirmHandler(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bindSubscriptionToken(c)
+		if !ok {
+			return
+		}
+
+		logger.Debug("return the confirmation page")
+		renderSubscriptionPage(c, http.StatusOK, &subscriptionPage{
+			Title: "Confirm the subscription", Action: subscriptionAction(token), Button: "Confirm",
+		})
+	}
+}
+
+// PostSubscriptionConfirmHandler activates the subscription by the token from the confirmation message,
+// the previous subscriptions of the email are replaced.
+func PostSubscriptionConfirmHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bindSubscriptionToken(c)
+		if !ok {
+			return
+		}
+
+		sub, err := dbInst.ConfirmSubscriber(token)
+		if err != nil {
+			raiseSubscriptionErr(c, err)
+			return
+		}
+
+		logger.Info("the subscription is confirmed", zap.Uint("subscriberID", sub.ID))
+		if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+			renderSubscriptionPage(c, http.StatusOK, &subscriptionPage{
+				Title: "Confirm the subscription", Message: subscriptionConfirmedMsg,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, toSubscription(sub))
+	}
+}
+
+// GetUnsubscribeHandler returns the page, which cancels the subscription by the POST request.
+func GetUnsubscribeHandler(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bindSubscriptionToken(c)
+		if !ok {
+			return
+		}
+
+		logger.Debug("return the unsubscribe page")
+		renderSubscriptionPage(c, http.StatusOK, &subscriptionPage{
+			Title: "Unsubscribe", Action: subscriptionAction(token), Button: "Unsubscribe",
+		})
+	}
+}
+
+// UnsubscribeHandler removes the subscription by the token from the messages.
+// It's used by the unsubscribe page and by the one-click unsubscribe of the email clients (RFC 8058).
+func UnsubscribeHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bindSubscriptionToken(c)
+		if !ok {
+			return
+		}
+
+		sub, err := dbInst.DeleteSubscriber(token)
+		if err != nil {
+			raiseSubscriptionErr(c, err)
+			return
+		}
+
+		logger.Info("the subscription is cancelled", zap.Uint("subscriberID", sub.ID))
+		if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+			renderSubscriptionPage(c, http.StatusOK, &subscriptionPage{
+				Title: "Unsubscribe", Message: subscriptionCancelledMsg,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": subscriptionCancelledMsg})
+	}
+}
+
+// subscriptionPage is the page of the subscription link, it has the form or the result message.
+type subscriptionPage struct {
+	Title   string
+	Action  string
+	Button  string
+	Message string
+}
+
+// subscriptionAction returns the relative URL of the form, the form is sent to the same path.
+func subscriptionAction(token string) string {
+	return "?" + url.Values{"token": {token}}.Encode()
+}
+
+func renderSubscriptionPage(c *gin.Context, code int, page *subscriptionPage) {
+	var body bytes.Buffer
+	if err := subscriptionPageTemplate.Execute(&body, page); err != nil {
+		apiErrors.RaiseInternalErr(c, err)
+		return
+	}
+
+	c.Data(code, "text/html; charset=utf-8", body.Bytes())
+}
+
+func bindSubscriptionToken(c *gin.Context) (string, bool) {
+	var token SubscriptionToken
+	if err := c.ShouldBindQuery(&token); err != nil || token.Token == "" {
+		apiErrors.RaiseBadRequestErr(c, apiErrors.ErrSubscriptionTokenEmpty)
+		return "", false
+	}
+
+	return token.Token, true
+}
+
+func raiseSubscriptionErr(c *gin.Context, err error) {
+	if errors.Is(err, db.ErrDBSubscriberDSNotExist) {
+		apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrSubscriptionDSNotExist)
+		return
+	}
+	apiErrors.RaiseInternalErr(c, err)
+}
+
+// validateSubscriptionData checks the components and the regions, it returns the subscribed components.
+func validateSubscriptionData(dbInst *db.DB, subData *SubscriptionData) ([]db.Component, error) {
+	if len(subData.Components) == 0 && len(subData.Regions) == 0 {
+		return nil, apiErrors.ErrSubscriptionEmpty
+	}
+
+	components := make([]db.Component, 0, len(subData.Components))
+	for _, id := range subData.Components {
+		comp, err := dbInst.GetComponent(id)
+		if err != nil {
+			if errors.Is(err, db.ErrDBComponentDSNotExist) {
+				return nil, apiErrors.NewErrComponentDSNotExist(id)
+			}
+			return nil, err
+		}
+		components = append(components, *comp)
+	}
+
+	for _, region := range subData.Regions {
+		exists, err := dbInst.RegionExists(region)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", apiErrors.ErrRegionDSNotExist, region)
+		}
+	}
+
+	return components, nil
+}
+
+// hasRecentSubscription checks if the confirmation is sent to the email in the resend period.
+func hasRecentSubscription(dbInst *db.DB, address string) (bool, error) {
+	subscribers, err := dbInst.GetSubscribersByEmail(address)
+	if err != nil {
+		return false, err
+	}
+
+	since := time.Now().UTC().Add(-subscriptionResendDelay)
+	for _, sub := range subscribers {
+		if sub.ConfirmedAt == nil && sub.CreatedAt != nil && sub.CreatedAt.After(since) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func newSubscriber(subData *SubscriptionData) (*db.Subscriber, error) {
+	confirmToken, err := generateSubscriptionToken()
+	if err != nil {
+		return nil, err
+	}
+	unsubscribeToken, err := generateSubscriptionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]string, len(subData.Components))
+	for i, id := range subData.Components {
+		components[i] = strconv.Itoa(id)
+	}
+
+	now := time.Now().UTC()
+	return &db.Subscriber{
+		Email:            subData.Email,
+		Components:       strings.Join(components, ","),
+		Regions:          strings.Join(subData.Regions, ","),
+		ConfirmToken:     confirmToken,
+		UnsubscribeToken: unsubscribeToken,
+		CreatedAt:        &now,
+	}, nil
+}
+
+func generateSubscriptionToken() (string, error) {
+	b := make([]byte, subscriptionTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,16 +3,24 @@ package v2
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
-	"sort"
+	"net/mail"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
 	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/audit"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/email"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/recurrence"
+	"github.com/stackmon/otc-status-dashboard/internal/stream"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
 )
 
 const (
@@ -50,24 +58,70 @@ type IncidentData struct {
 	Updates []EventUpdateData `json:"updates,omitempty"`
 	// Status does not take into account OutDatedSystem status.
 	Status event.Status `json:"status,omitempty"`
+	// ContactEmail is required for maintenances created by sd_creators.
+	ContactEmail string `json:"contact_email,omitempty"`
+	// Creator, ModifiedBy and Version are read only fields, they are ignored in the requests.
+	// Creator, ModifiedBy, ContactEmail and the authors of updates are returned only for authenticated users.
+	Creator    string `json:"creator,omitempty"`
+	ModifiedBy string `json:"modified_by,omitempty"`
+	Version    int    `json:"version,omitempty"`
+	// SeriesID and Recurrence are read only fields, they are set for the occurrences of the recurring maintenance.
+	// Recurrence is the human-readable recurrence rule of the series.
+	SeriesID   *uint  `json:"series_id,omitempty"`
+	Recurrence string `json:"recurrence,omitempty"`
+	// MergedInto is a read only field, it's the incident, which the closed incident is merged into.
+	MergedInto *uint `json:"merged_into,omitempty"`
+	// ComponentImpacts are the impacts on the components of the incident, the impact of the incident is the max of them.
+	// The components without the impact in the request get the impact of the incident.
+	ComponentImpacts []ComponentImpact `json:"component_impacts,omitempty" binding:"omitempty,dive"`
+}
+
+// ComponentImpact is the impact of the incident on the component.
+type ComponentImpact struct {
+	Component int  `json:"component" binding:"required"`
+	Impact    *int `json:"impact" binding:"required,gte=1,lte=3"`
 }
 
 type Incident struct {
 	IncidentID
 	IncidentData
+	// Highlight is a read only field, it's returned only for the search query.
+	Highlight *EventHighlight `json:"highlight,omitempty"`
+}
+
+// EventHighlight contains the snippets of the event, which match the search query.
+// The snippets are HTML escaped and the matched words are wrapped in <mark> tags.
+type EventHighlight struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Updates     []UpdateHighlight `json:"updates,omitempty"`
+}
+
+// UpdateHighlight is the snippet of the matched update, the ID is the ID of the update in the event.
+type UpdateHighlight struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
 }
 
 type APIGetIncidentsQuery struct {
-	Types      *string       `form:"type" binding:"omitempty"` // custom validation in parseAndSetTypes
-	IsActive   *bool         `form:"active" binding:"omitempty"`
-	Status     *event.Status `form:"status"` // custom validation in validateAndSetStatus
-	StartDate  *time.Time    `form:"start_date" binding:"omitempty"`
-	EndDate    *time.Time    `form:"end_date" binding:"omitempty"`
-	Impact     *int          `form:"impact" binding:"omitempty,gte=0,lte=3"`
-	System     *bool         `form:"system" binding:"omitempty"`
-	Components *string       `form:"components"` // custom validation in parseAndSetComponents
-	Page       *int          `form:"page" binding:"omitempty,gte=1"`
-	Limit      *int          `form:"limit"` // custom validation in validateAndSetLimit
+	Types         *string       `form:"type" binding:"omitempty"` // custom validation in parseAndSetTypes
+	IsActive      *bool         `form:"active" binding:"omitempty"`
+	Status        *event.Status `form:"status"` // custom validation in validateAndSetStatus
+	StartDate     *time.Time    `form:"start_date" binding:"omitempty"`
+	EndDate       *time.Time    `form:"end_date" binding:"omitempty"`
+	Overlap       *bool         `form:"overlap" binding:"omitempty"`
+	At            *time.Time    `form:"at" binding:"omitempty"`
+	Impact        *int          `form:"impact" binding:"omitempty,gte=0,lte=3"`
+	System        *bool         `form:"system" binding:"omitempty"`
+	Components    *string       `form:"components"` // custom validation in parseAndSetComponents
+	SeriesID      *uint         `form:"series_id" binding:"omitempty,gte=1"`
+	Search        *string       `form:"search"` // custom validation in validateAndSetSearch
+	ModifiedSince *time.Time    `form:"modified_since" binding:"omitempty"`
+	Page          *int          `form:"page" binding:"omitempty,gte=1"`
+	Limit         *int          `form:"limit"` // custom validation in validateAndSetLimit
+	Sort          *string       `form:"sort"`  // custom validation in validateAndSetSort
+	Order         *string       `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor        *string       `form:"cursor"` // custom validation in validateAndSetCursor
 }
 
 func bindIncidentsQuery(c *gin.Context) (*APIGetIncidentsQuery, error) {
@@ -80,6 +134,11 @@ func bindIncidentsQuery(c *gin.Context) (*APIGetIncidentsQuery, error) {
 	if query.StartDate != nil && query.EndDate != nil && query.EndDate.Before(*query.StartDate) {
 		return nil, apiErrors.ErrIncidentFQueryInvalidFormat
 	}
+
+	// the point in time can't be combined with the time window
+	if query.At != nil && (query.StartDate != nil || query.EndDate != nil) {
+		return nil, apiErrors.ErrIncidentFQueryInvalidFormat
+	}
 	return &query, nil
 }
 
@@ -90,10 +149,14 @@ func parseFilterParams(c *gin.Context) (*db.IncidentsParams, error) {
 	}
 
 	params := &db.IncidentsParams{
-		StartDate: query.StartDate,
-		EndDate:   query.EndDate,
-		Impact:    query.Impact,
-		IsSystem:  query.System,
+		StartDate:     query.StartDate,
+		EndDate:       query.EndDate,
+		Impact:        query.Impact,
+		IsSystem:      query.System,
+		SeriesID:      query.SeriesID,
+		ModifiedSince: query.ModifiedSince,
+		Overlap:       query.Overlap != nil && *query.Overlap,
+		At:            query.At,
 	}
 
 	if query.IsActive != nil {
@@ -122,6 +185,11 @@ func parseFilterParams(c *gin.Context) (*db.IncidentsParams, error) {
 		return nil, err
 	}
 
+	err = validateAndSetSearch(query.Search, params)
+	if err != nil {
+		return nil, err
+	}
+
 	return params, nil
 }
 
@@ -147,7 +215,13 @@ func parsePaginationParams(c *gin.Context, params *db.IncidentsParams) error {
 		limit = *params.Limit
 	}
 	params.Limit = &limit
-	return nil
+
+	err = validateAndSetSort(query.Sort, query.Order, params)
+	if err != nil {
+		return err
+	}
+
+	return validateAndSetCursor(query, params)
 }
 
 func GetIncidentsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
@@ -159,6 +233,8 @@ func GetIncidentsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
+		hideNotPublicEvents(c, params)
+
 		logger.Debug("retrieve incidents with params", zap.Any("params", params))
 		r, err := dbInst.GetEvents(params)
 		if err != nil {
@@ -175,7 +251,7 @@ func GetIncidentsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 
 		incidents := make([]*Incident, len(r))
 		for i, inc := range r {
-			incidents[i] = toAPIEvent(inc)
+			incidents[i] = toAPIEventForRole(inc, auth.RoleFromContext(c))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"data": incidents})
@@ -196,6 +272,15 @@ func GetEventsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
+		hideNotPublicEvents(c, params)
+
+		limit := *params.Limit
+		if params.After != nil {
+			// the extra event shows, that the next page exists
+			fetchLimit := limit + 1
+			params.Limit = &fetchLimit
+		}
+
 		logger.Debug("retrieve events with params", zap.Any("params", params))
 		r, total, err := dbInst.GetEventsWithCount(params)
 		if err != nil {
@@ -203,6 +288,7 @@ func GetEventsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			apiErrors.RaiseInternalErr(c, err)
 			return
 		}
+		params.Limit = &limit
 
 		if total == 0 {
 			logger.Debug(
@@ -213,38 +299,45 @@ func GetEventsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
+		var hasNext bool
+		if params.After != nil {
+			hasNext = len(r) > limit
+			r = r[:min(len(r), limit)]
+		} else {
+			hasNext = int64(*params.Page*limit) < total
+		}
+
 		events := make([]*Incident, len(r))
 		for i, inc := range r {
-			events[i] = toAPIEvent(inc)
+			events[i] = toAPIEventForRole(inc, auth.RoleFromContext(c))
 		}
 
-		page := 1
-		if params.Page != nil {
-			page = *params.Page
+		if params.Search != "" {
+			if err = setEventHighlights(dbInst, params.Search, r, events); err != nil {
+				logger.Error("failed to retrieve search highlights", zap.Error(err))
+				apiErrors.RaiseInternalErr(c, err)
+				return
+			}
 		}
 
-		limit := defaultIncidentLimit
-		if params.Limit != nil {
-			limit = *params.Limit
+		pagination := gin.H{
+			"recordsPerPage": limit,
+			"totalRecords":   total,
+		}
+		if params.After == nil {
+			pagination["pageIndex"] = *params.Page
+			pagination["totalPages"] = int((total + int64(limit) - 1) / int64(limit))
 		}
 
-		recordsPerPage := limit
-		totalPages := 1
-		if limit == 0 {
-			recordsPerPage = int(total)
-			page = 1
-		} else if total > 0 {
-			totalPages = int((total + int64(limit) - 1) / int64(limit))
+		// the cursor of the next page is returned for both paginations, so the client can switch to the cursors,
+		// the events sorted by the search rank don't have the cursor
+		if sort, ok := params.EventsSort(); ok && hasNext && len(r) > 0 {
+			pagination["nextCursor"] = db.NewEventsCursor(r[len(r)-1], sort).Encode()
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"data": events,
-			"pagination": gin.H{
-				"pageIndex":      page,
-				"recordsPerPage": recordsPerPage,
-				"totalRecords":   total,
-				"totalPages":     totalPages,
-			},
+			"data":       events,
+			"pagination": pagination,
 		})
 	}
 }
@@ -268,8 +361,66 @@ func GetIncidentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, toAPIEvent(r))
+		role := auth.RoleFromContext(c)
+		if role == auth.RoleNone && event.IsMaintenanceReviewStatus(r.Status) {
+			apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrIncidentDSNotExist)
+			return
+		}
+
+		c.JSON(http.StatusOK, toAPIEventForRole(r, role))
+	}
+}
+
+// setEventHighlights sets the search snippets of the events,
+// the updates are referenced by their IDs in the event like in mapEventUpdates.
+func setEventHighlights(dbInst *db.DB, search string, r []*db.Incident, events []*Incident) error {
+	ids := make([]uint, len(r))
+	for i, inc := range r {
+		ids[i] = inc.ID
+	}
+
+	highlights, err := dbInst.GetEventHighlights(search, ids)
+	if err != nil {
+		return err
+	}
+
+	for i, inc := range r {
+		h, ok := highlights[inc.ID]
+		if !ok {
+			continue
+		}
+
+		events[i].Highlight = &EventHighlight{Title: h.Title, Description: h.Description}
+		for updID, s := range inc.Statuses {
+			if text, matched := h.Updates[s.ID]; matched {
+				events[i].Highlight.Updates = append(events[i].Highlight.Updates, UpdateHighlight{ID: updID, Text: text})
+			}
+		}
+	}
+
+	return nil
+}
+
+// hideNotPublicEvents excludes maintenances in the review workflow for anonymous users.
+func hideNotPublicEvents(c *gin.Context, params *db.IncidentsParams) {
+	if auth.RoleFromContext(c) == auth.RoleNone {
+		params.ExcludeStatuses = event.MaintenanceReviewStatuses()
+	}
+}
+
+// toAPIEventForRole hides the creator, the authors of changes and the contact email for anonymous users.
+func toAPIEventForRole(inc *db.Incident, role auth.Role) *Incident {
+	apiEvent := toAPIEvent(inc)
+	if role == auth.RoleNone {
+		apiEvent.Creator = ""
+		apiEvent.ModifiedBy = ""
+		apiEvent.ContactEmail = ""
+		for i := range apiEvent.Updates {
+			apiEvent.Updates[i].Author = ""
+		}
 	}
+
+	return apiEvent
 }
 
 func toAPIEvent(inc *db.Incident) *Incident {
@@ -285,20 +436,49 @@ func toAPIEvent(inc *db.Incident) *Incident {
 		description = *inc.Description
 	}
 
+	var creator, modifiedBy, contactEmail string
+	if inc.Creator != nil {
+		creator = *inc.Creator
+	}
+	if inc.ModifiedBy != nil {
+		modifiedBy = *inc.ModifiedBy
+	}
+	if inc.ContactEmail != nil {
+		contactEmail = *inc.ContactEmail
+	}
+
 	incData := IncidentData{
-		Title:       *inc.Text,
-		Description: description,
-		Impact:      inc.Impact,
-		Components:  components,
-		StartDate:   *inc.StartDate,
-		EndDate:     inc.EndDate,
-		System:      &inc.System,
-		Updates:     updates,
-		Status:      inc.Status,
-		Type:        inc.Type,
+		Title:        *inc.Text,
+		Description:  description,
+		Impact:       inc.Impact,
+		Components:   components,
+		StartDate:    *inc.StartDate,
+		EndDate:      inc.EndDate,
+		System:       &inc.System,
+		Updates:      updates,
+		Status:       inc.Status,
+		Type:         inc.Type,
+		ContactEmail: contactEmail,
+		Creator:      creator,
+		ModifiedBy:   modifiedBy,
+		Version:      inc.Version,
+		SeriesID:     inc.SeriesID,
+		MergedInto:   inc.MergedIntoID,
+	}
+
+	if inc.Series != nil {
+		incData.Recurrence = recurrence.DescribeRule(inc.Series.RRule, inc.Series.StartDate)
+	}
+
+	if inc.HasComponentImpacts() {
+		incData.ComponentImpacts = make([]ComponentImpact, len(inc.Components))
+		for i, comp := range inc.Components {
+			impact := inc.ComponentImpact(comp.ID)
+			incData.ComponentImpacts[i] = ComponentImpact{Component: int(comp.ID), Impact: &impact}
+		}
 	}
 
-	return &Incident{IncidentID{ID: int(inc.ID)}, incData}
+	return &Incident{IncidentID: IncidentID{ID: int(inc.ID)}, IncidentData: incData}
 }
 
 // PostIncidentHandler creates an incident.
@@ -310,35 +490,37 @@ func PostIncidentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		incData.StartDate = incData.StartDate.UTC()
-		if incData.EndDate != nil {
-			*incData.EndDate = incData.EndDate.UTC()
-		}
+		normalizeEventDates(&incData)
 
 		if err := validateEventCreation(incData); err != nil {
 			apiErrors.RaiseBadRequestErr(c, err)
 			return
 		}
 
-		log := logger.With(zap.Any("incidentData", incData))
-		log.Info("start to prepare for an incident creation")
+		role := auth.RoleFromContext(c)
+		if role == auth.RoleCreator && incData.Type != event.TypeMaintenance {
+			apiErrors.RaiseForbiddenErr(c, apiErrors.ErrMaintenanceCreationForbiddenType)
+			return
+		}
 
-		if incData.System == nil {
-			var system bool
-			incData.System = &system
+		if err := validateMaintenanceSubmission(incData, role); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
 		}
 
-		var result []*ProcessComponentResp
-		var err error
-		// Route to appropriate handler based on system field
-		if *incData.System {
-			log.Info("system incident detected, using system incident creation logic")
-			result, err = handleSystemIncidentCreation(dbInst, log, incData)
-		} else {
-			log.Info("regular incident detected, using regular incident creation logic")
-			result, err = handleRegularIncidentCreation(dbInst, log, incData)
+		if !checkTokenAccessByIDs(c, dbInst, auth.CreateScope(incData.Type), incData.Components) {
+			return
 		}
 
+		// The status is set by the system, maintenances created by sd_creators have to be reviewed.
+		incData.Status = ""
+		if role == auth.RoleCreator {
+			incData.Status = event.MaintenancePendingReview
+		}
+		incData.Creator = auth.UserFromContext(c)
+
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		result, err := createEventWithComponents(actorDB, logger.With(zap.Any("incidentData", incData)), incData)
 		if err != nil {
 			if errors.Is(err, apiErrors.ErrIncidentSystemCreationWrongType) {
 				apiErrors.RaiseBadRequestErr(c, err)
@@ -352,6 +534,34 @@ func PostIncidentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 	}
 }
 
+func normalizeEventDates(incData *IncidentData) {
+	incData.StartDate = incData.StartDate.UTC()
+	if incData.EndDate != nil {
+		*incData.EndDate = incData.EndDate.UTC()
+	}
+}
+
+// createEventWithComponents creates the validated event.
+func createEventWithComponents(
+	dbInst *db.DB, log *zap.Logger, incData IncidentData,
+) ([]*ProcessComponentResp, error) {
+	log.Info("start to prepare for an incident creation")
+
+	if incData.System == nil {
+		var system bool
+		incData.System = &system
+	}
+
+	// Route to appropriate handler based on system field
+	if *incData.System {
+		log.Info("system incident detected, using system incident creation logic")
+		return handleSystemIncidentCreation(dbInst, log, incData)
+	}
+
+	log.Info("regular incident detected, using regular incident creation logic")
+	return handleRegularIncidentCreation(dbInst, log, incData)
+}
+
 // handleSystemIncidentCreation handles creation of system incidents.
 func handleSystemIncidentCreation(
 	dbInst *db.DB, log *zap.Logger, incData IncidentData,
@@ -420,8 +630,9 @@ func processSystemIncidentComponent(
 func getActiveEventsForComponent(dbInst *db.DB, componentID uint) ([]*db.Incident, error) {
 	active := true
 	params := &db.IncidentsParams{
-		IsActive: &active,
-		Types:    []string{event.TypeIncident, event.TypeMaintenance},
+		IsActive:        &active,
+		Types:           []string{event.TypeIncident, event.TypeMaintenance},
+		ExcludeStatuses: event.MaintenanceReviewStatuses(),
 	}
 	return dbInst.GetEventsByComponentID(componentID, params)
 }
@@ -566,10 +777,13 @@ func addComponentToSystemIncident(
 				Text:       fmt.Sprintf("%s added to the incident by system.", comp.PrintAttrs()),
 				Timestamp:  time.Now().UTC(),
 			})
-			err := dbInst.ModifyIncident(sysInc)
+			err := audit.TrackEvent(dbInst, string(webhook.ActionUpdated), sysInc.ID, func(tx *db.DB) error {
+				return tx.ModifyIncident(sysInc)
+			})
 			if err != nil {
 				return nil, err
 			}
+			NotifyEventChanged(dbInst, log, webhook.ActionUpdated, sysInc.ID)
 			return sysInc, nil
 		}
 	}
@@ -591,7 +805,7 @@ func addComponentToSystemIncident(
 		Components:  []db.Component{*comp},
 	}
 
-	if err := createEvent(dbInst, log, &incIn); err != nil {
+	if err := createEvent(dbInst, log, &incIn, nil); err != nil {
 		return nil, err
 	}
 
@@ -628,10 +842,11 @@ func moveComponentFromToSystemIncidents(
 				closeOld = true
 			}
 
-			inc, err := dbInst.MoveComponentFromOldToAnotherIncident(comp, oldInc, sysInc, closeOld)
+			inc, err := moveComponent(dbInst, comp, oldInc, sysInc, closeOld)
 			if err != nil {
 				return nil, err
 			}
+			NotifyEventChanged(dbInst, log, webhook.ActionUpdated, oldInc.ID, inc.ID)
 			return inc, nil
 		}
 	}
@@ -646,11 +861,15 @@ func moveComponentFromToSystemIncidents(
 			"the source incident has only 1 target component with the lower impact, we can just update its impact",
 			zap.Uint("componentID", comp.ID), zap.Uint("incidentID", oldInc.ID),
 		)
-		inc, err := dbInst.IncreaseIncidentImpact(oldInc, *incData.Impact)
+		err := audit.TrackEvent(dbInst, string(webhook.ActionUpdated), oldInc.ID, func(tx *db.DB) error {
+			_, err := tx.IncreaseIncidentImpact(oldInc, *incData.Impact)
+			return err
+		})
 		if err != nil {
 			return nil, err
 		}
-		return inc, nil
+		NotifyEventChanged(dbInst, log, webhook.ActionUpdated, oldInc.ID)
+		return oldInc, nil
 	}
 
 	log.Info(
@@ -659,22 +878,41 @@ func moveComponentFromToSystemIncidents(
 		zap.Int("impact", *incData.Impact),
 	)
 
-	inc, err := dbInst.ExtractComponentsToNewIncident(
-		[]db.Component{*comp},
-		oldInc,
-		*incData.Impact,
-		incData.Title,
-		&incData.Description,
-	)
+	var inc *db.Incident
+	err := audit.Track(dbInst, func(t *audit.Tracker) error {
+		if err := t.Capture(oldInc.ID); err != nil {
+			return err
+		}
+
+		var err error
+		inc, err = t.DB().ExtractComponentsToNewIncident(
+			[]db.Component{*comp},
+			oldInc,
+			*incData.Impact,
+			incData.Title,
+			&incData.Description,
+		)
+		if err != nil {
+			return err
+		}
+
+		// Update the new incident to mark it as a system incident
+		inc.System = true
+		if err = t.DB().ModifyIncident(inc); err != nil {
+			return err
+		}
+
+		if err = t.Record(string(webhook.ActionUpdated), oldInc.ID); err != nil {
+			return err
+		}
+		return t.Record(string(webhook.ActionExtracted), inc.ID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Update the new incident to mark it as a system incident
-	inc.System = true
-	if err = dbInst.ModifyIncident(inc); err != nil {
-		return nil, err
-	}
+	NotifyEventChanged(dbInst, log, webhook.ActionUpdated, oldInc.ID)
+	NotifyEventChanged(dbInst, log, webhook.ActionExtracted, inc.ID)
 
 	return inc, nil
 }
@@ -688,15 +926,24 @@ func handleRegularIncidentCreation(
 		components[i] = db.Component{ID: uint(comp)}
 	}
 
+	componentImpacts := eventComponentImpacts(incData)
+	if componentImpacts != nil {
+		maxImpact := slices.Max(slices.Collect(maps.Values(componentImpacts)))
+		incData.Impact = &maxImpact
+	}
+
 	incIn := db.Incident{
-		Text:        &incData.Title,
-		Description: &incData.Description,
-		StartDate:   &incData.StartDate,
-		EndDate:     incData.EndDate,
-		Impact:      incData.Impact,
-		System:      *incData.System,
-		Type:        incData.Type,
-		Components:  components,
+		Text:         &incData.Title,
+		Description:  &incData.Description,
+		StartDate:    &incData.StartDate,
+		EndDate:      incData.EndDate,
+		Impact:       incData.Impact,
+		System:       *incData.System,
+		Type:         incData.Type,
+		Components:   components,
+		Status:       incData.Status,
+		Creator:      optionalString(incData.Creator),
+		ContactEmail: optionalString(incData.ContactEmail),
 	}
 
 	log.Info("get active events from the database")
@@ -708,7 +955,7 @@ func handleRegularIncidentCreation(
 
 	log.Info("opened incidents and maintenances retrieved", zap.Any("openedIncidents", openedIncidents))
 
-	if err = createEvent(dbInst, log, &incIn); err != nil {
+	if err = createEvent(dbInst, log, &incIn, componentImpacts); err != nil {
 		return nil, err
 	}
 
@@ -721,6 +968,24 @@ func handleRegularIncidentCreation(
 	return processComponentMovement(dbInst, log, &incIn, openedIncidents)
 }
 
+// eventComponentImpacts returns the impacts on all components of the new incident,
+// the components without the impact get the impact of the incident. It's nil without the component impacts.
+func eventComponentImpacts(incData IncidentData) map[uint]int {
+	if len(incData.ComponentImpacts) == 0 {
+		return nil
+	}
+
+	impacts := make(map[uint]int, len(incData.Components))
+	for _, id := range incData.Components {
+		impacts[uint(id)] = *incData.Impact
+	}
+	for _, ci := range incData.ComponentImpacts {
+		impacts[uint(ci.Component)] = *ci.Impact
+	}
+
+	return impacts
+}
+
 // shouldSkipComponentMovement determines if component movement logic should be skipped.
 func shouldSkipComponentMovement(openedIncidents []*db.Incident, incData IncidentData) bool {
 	return len(openedIncidents) == 0 || *incData.Impact == 0 || incData.Type == event.TypeInformation
@@ -815,10 +1080,11 @@ func tryMoveComponentIfFound(
 			log.Info("found the component in the opened incident", zap.Any("component", comp), zap.Any("incident", inc))
 
 			closeInc := len(inc.Components) == 1
-			incident, err := dbInst.MoveComponentFromOldToAnotherIncident(comp, inc, incIn, closeInc)
+			incident, err := moveComponent(dbInst, comp, inc, incIn, closeInc)
 			if err != nil {
 				return false, err
 			}
+			NotifyEventChanged(dbInst, log, webhook.ActionUpdated, inc.ID)
 			compResult.IncidentID = int(incident.ID)
 			return true, nil
 		}
@@ -845,6 +1111,10 @@ func validateEventCreation(incData IncidentData) error {
 		return err
 	}
 
+	if err := validateEventCreationComponentImpacts(incData); err != nil {
+		return err
+	}
+
 	if len(incData.Updates) != 0 {
 		return apiErrors.ErrIncidentUpdatesShouldBeEmpty
 	}
@@ -864,6 +1134,34 @@ func validateEventCreationImpact(incData IncidentData) error {
 	return nil
 }
 
+func validateEventCreationComponentImpacts(incData IncidentData) error {
+	if len(incData.ComponentImpacts) == 0 {
+		return nil
+	}
+
+	if incData.Type != event.TypeIncident || incData.System != nil && *incData.System {
+		return apiErrors.ErrIncidentComponentImpactsWrongType
+	}
+
+	return checkComponentImpacts(incData.ComponentImpacts, incData.Components)
+}
+
+// checkComponentImpacts checks that the impacts are set only once and only for the components of the incident.
+func checkComponentImpacts(impacts []ComponentImpact, components []int) error {
+	seen := make(map[int]bool, len(impacts))
+	for _, ci := range impacts {
+		if !slices.Contains(components, ci.Component) {
+			return fmt.Errorf("%w: %d", apiErrors.ErrIncidentComponentImpactsUnknownComponent, ci.Component)
+		}
+		if seen[ci.Component] {
+			return fmt.Errorf("%w: %d", apiErrors.ErrIncidentComponentImpactsDuplicate, ci.Component)
+		}
+		seen[ci.Component] = true
+	}
+
+	return nil
+}
+
 func validateEventCreationTimes(incData IncidentData) error {
 	// you can't create an incident with the end_date
 	if incData.Type == event.TypeIncident && incData.EndDate != nil {
@@ -882,7 +1180,71 @@ func validateEventCreationTimes(incData IncidentData) error {
 	return nil
 }
 
-func createEvent(dbInst *db.DB, log *zap.Logger, inc *db.Incident) error {
+// validateMaintenanceSubmission checks the maintenance fields, which are required for the review workflow.
+// The contact email is validated for all roles, but it's required only for sd_creators.
+func validateMaintenanceSubmission(incData IncidentData, role auth.Role) error {
+	if incData.ContactEmail != "" {
+		if _, err := mail.ParseAddress(incData.ContactEmail); err != nil {
+			return apiErrors.ErrMaintenanceContactEmailInvalid
+		}
+	}
+
+	if role != auth.RoleCreator || incData.Type != event.TypeMaintenance {
+		return nil
+	}
+
+	if incData.Description == "" {
+		return apiErrors.ErrMaintenanceDescriptionEmpty
+	}
+
+	if incData.ContactEmail == "" {
+		return apiErrors.ErrMaintenanceContactEmailEmpty
+	}
+
+	if !incData.StartDate.After(time.Now().UTC()) {
+		return apiErrors.ErrMaintenanceStartDateInPast
+	}
+
+	if !incData.EndDate.After(incData.StartDate) {
+		return apiErrors.ErrMaintenanceEndDateBeforeStart
+	}
+
+	return nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// createEvent saves the event with the initial status and records it to the audit log in one transaction,
+// the component impacts are set if they aren't nil.
+func createEvent(dbInst *db.DB, log *zap.Logger, inc *db.Incident, componentImpacts map[uint]int) error {
+	err := audit.Track(dbInst, func(t *audit.Tracker) error {
+		if err := saveEvent(t.DB(), log, inc); err != nil {
+			return err
+		}
+
+		if componentImpacts != nil {
+			if err := t.DB().SetComponentImpacts(inc, componentImpacts); err != nil {
+				return err
+			}
+		}
+
+		return t.Record(string(webhook.ActionCreated), inc.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	NotifyEventChanged(dbInst, log, webhook.ActionCreated, inc.ID)
+
+	return nil
+}
+
+func saveEvent(dbInst *db.DB, log *zap.Logger, inc *db.Incident) error {
 	log.Info("start to save an event to the database")
 	id, err := dbInst.SaveIncident(inc)
 	if err != nil {
@@ -912,6 +1274,10 @@ func createEvent(dbInst *db.DB, log *zap.Logger, inc *db.Incident) error {
 	case event.TypeMaintenance:
 		statusText = event.MaintenancePlannedStatusText()
 		status = event.MaintenancePlanned
+		if inc.Status == event.MaintenancePendingReview {
+			statusText = event.MaintenancePendingReviewStatusText()
+			status = event.MaintenancePendingReview
+		}
 	case event.TypeIncident:
 		statusText = event.IncidentDetectedStatusText()
 		status = event.IncidentDetected
@@ -925,12 +1291,7 @@ func createEvent(dbInst *db.DB, log *zap.Logger, inc *db.Incident) error {
 	})
 	inc.Status = status
 
-	err = dbInst.ModifyIncident(inc)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return dbInst.ModifyIncident(inc)
 }
 
 type PatchIncidentData struct {
@@ -943,6 +1304,8 @@ type PatchIncidentData struct {
 	StartDate   *time.Time   `json:"start_date,omitempty"`
 	EndDate     *time.Time   `json:"end_date,omitempty"`
 	Type        string       `json:"type,omitempty" binding:"omitempty,oneof=maintenance info incident"`
+	// ComponentImpacts change the impacts on the components, they can't be changed together with the Impact.
+	ComponentImpacts []ComponentImpact `json:"component_impacts,omitempty" binding:"omitempty,dive"`
 }
 
 func PatchIncidentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
@@ -957,53 +1320,154 @@ func PatchIncidentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		incData.UpdateDate = incData.UpdateDate.UTC()
-		if incData.StartDate != nil {
-			*incData.StartDate = incData.StartDate.UTC()
+		normalizePatchDates(&incData)
+
+		if !checkTokenAccess(c, auth.UpdateScope(storedIncident.Type), storedIncident.Components) {
+			return
 		}
-		if incData.EndDate != nil {
-			*incData.EndDate = incData.EndDate.UTC()
+		if incData.Type != "" && !checkTokenAccess(c, auth.UpdateScope(incData.Type), storedIncident.Components) {
+			return
 		}
 
-		if err := checkPatchData(&incData, storedIncident); err != nil {
-			apiErrors.RaiseBadRequestErr(c, err)
+		if err := checkPatchPermissions(c, &incData, storedIncident); err != nil {
+			if errors.Is(err, apiErrors.ErrMaintenanceReviewStatusForbidden) {
+				apiErrors.RaiseConflictErr(c, err)
+				return
+			}
+			apiErrors.RaiseForbiddenErr(c, err)
 			return
 		}
 
-		updateFields(&incData, storedIncident)
-
-		status := db.IncidentStatus{
-			IncidentID: storedIncident.ID,
-			Status:     incData.Status,
-			Text:       incData.Message,
-			Timestamp:  incData.UpdateDate,
+		if err := checkPatchData(&incData, storedIncident); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
 		}
 
-		storedIncident.Statuses = append(storedIncident.Statuses, status)
-		storedIncident.Status = incData.Status
-
-		err := dbInst.ModifyIncident(storedIncident)
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		inc, err := applyEventUpdate(actorDB, logger, storedIncident, &incData)
 		if err != nil {
 			apiErrors.RaiseInternalErr(c, err)
 			return
 		}
 
+		c.JSON(http.StatusOK, toAPIEvent(inc))
+	}
+}
+
+func normalizePatchDates(incData *PatchIncidentData) {
+	incData.UpdateDate = incData.UpdateDate.UTC()
+	if incData.StartDate != nil {
+		*incData.StartDate = incData.StartDate.UTC()
+	}
+	if incData.EndDate != nil {
+		*incData.EndDate = incData.EndDate.UTC()
+	}
+}
+
+// applyEventUpdate saves the checked update of the event and returns the updated event.
+func applyEventUpdate(
+	dbInst *db.DB, log *zap.Logger, stored *db.Incident, incData *PatchIncidentData,
+) (*db.Incident, error) {
+	impactChanged := incData.Impact != nil && *incData.Impact != *stored.Impact
+	updateFields(incData, stored)
+	stored.Version++
+
+	status := db.IncidentStatus{
+		IncidentID: stored.ID,
+		Status:     incData.Status,
+		Text:       incData.Message,
+		Timestamp:  incData.UpdateDate,
+	}
+
+	stored.Statuses = append(stored.Statuses, status)
+	stored.Status = incData.Status
+
+	err := audit.TrackEvent(dbInst, string(webhook.ActionUpdated), stored.ID, func(tx *db.DB) error {
+		if err := tx.ModifyIncident(stored); err != nil {
+			return err
+		}
+
+		if err := updateComponentImpacts(tx, stored, incData, impactChanged); err != nil {
+			return err
+		}
+
 		if incData.Status == event.IncidentReopened {
-			err = dbInst.ReOpenIncident(storedIncident)
-			if err != nil {
-				apiErrors.RaiseInternalErr(c, err)
-				return
-			}
+			return tx.ReOpenIncident(stored)
 		}
 
-		inc, errDB := dbInst.GetIncident(int(storedIncident.ID))
-		if errDB != nil {
-			apiErrors.RaiseInternalErr(c, errDB)
-			return
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inc, err := dbInst.GetIncident(int(stored.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	NotifyEventChanged(dbInst, log, webhook.ActionUpdated, inc.ID)
+
+	return inc, nil
+}
+
+// updateComponentImpacts changes the impacts on the components by the update,
+// the new impact of the incident is set for all components.
+func updateComponentImpacts(dbInst *db.DB, stored *db.Incident, incData *PatchIncidentData, impactChanged bool) error {
+	if len(incData.ComponentImpacts) != 0 {
+		impacts := make(map[uint]int, len(incData.ComponentImpacts))
+		for _, ci := range incData.ComponentImpacts {
+			impacts[uint(ci.Component)] = *ci.Impact
 		}
+		return dbInst.SetComponentImpacts(stored, impacts)
+	}
 
-		c.JSON(http.StatusOK, toAPIEvent(inc))
+	if impactChanged && stored.HasComponentImpacts() {
+		return dbInst.ResetComponentImpacts(stored)
+	}
+
+	return nil
+}
+
+// checkPatchPermissions enforces the maintenance review workflow rules.
+// sd_creators can modify or cancel only their own maintenances in "pending review" status.
+// The review statuses can't be set manually by anyone except sd_admins.
+func checkPatchPermissions(c *gin.Context, incoming *PatchIncidentData, stored *db.Incident) error {
+	role := auth.RoleFromContext(c)
+	if role == auth.RoleAdmin {
+		return nil
+	}
+
+	if role == auth.RoleCreator {
+		if stored.Type != event.TypeMaintenance || stored.Status != event.MaintenancePendingReview {
+			return apiErrors.ErrMaintenancePatchForbidden
+		}
+
+		if stored.Creator == nil || *stored.Creator != auth.UserFromContext(c) {
+			return apiErrors.ErrMaintenancePatchForbidden
+		}
+
+		if incoming.Type != "" && incoming.Type != event.TypeMaintenance {
+			return apiErrors.ErrMaintenancePatchForbidden
+		}
+
+		if incoming.Status != event.MaintenancePendingReview && incoming.Status != event.MaintenanceCancelled {
+			return apiErrors.ErrMaintenancePatchForbidden
+		}
+
+		return nil
+	}
+
+	if event.IsMaintenanceReviewStatus(incoming.Status) && incoming.Status != stored.Status {
+		return apiErrors.ErrMaintenanceReviewStatusForbidden
 	}
+
+	if event.IsMaintenanceReviewStatus(stored.Status) && incoming.Status != stored.Status &&
+		incoming.Status != event.MaintenanceCancelled {
+		return apiErrors.ErrMaintenanceReviewStatusForbidden
+	}
+
+	return nil
 }
 
 func validateEffectiveTypeAndImpact(effectiveType string, effectiveImpact int) error {
@@ -1053,6 +1517,10 @@ func checkPatchData(incoming *PatchIncidentData, stored *db.Incident) error {
 		return err
 	}
 
+	if err := checkPatchComponentImpacts(incoming, stored); err != nil {
+		return err
+	}
+
 	if stored.Type == event.TypeIncident {
 		return checkPatchDataForIncident(incoming, stored)
 	}
@@ -1060,7 +1528,43 @@ func checkPatchData(incoming *PatchIncidentData, stored *db.Incident) error {
 	return nil
 }
 
-func checkPatchDataForIncident(incoming *PatchIncidentData, stored *db.Incident) error {
+// checkPatchComponentImpacts checks the impacts on the components of the regular incident,
+// the change of the impact of the opened incident requires the "impact changed" status.
+func checkPatchComponentImpacts(incoming *PatchIncidentData, stored *db.Incident) error {
+	if len(incoming.ComponentImpacts) == 0 {
+		return nil
+	}
+
+	if stored.Type != event.TypeIncident || stored.System || incoming.Type != "" && incoming.Type != event.TypeIncident {
+		return apiErrors.ErrIncidentComponentImpactsWrongType
+	}
+
+	if incoming.Impact != nil {
+		return apiErrors.ErrIncidentPatchComponentImpactsWithImpact
+	}
+
+	components := make([]int, len(stored.Components))
+	for i, comp := range stored.Components {
+		components[i] = int(comp.ID)
+	}
+	if err := checkComponentImpacts(incoming.ComponentImpacts, components); err != nil {
+		return err
+	}
+
+	if stored.EndDate != nil {
+		return nil
+	}
+
+	for _, ci := range incoming.ComponentImpacts {
+		if *ci.Impact != stored.ComponentImpact(uint(ci.Component)) && incoming.Status != event.IncidentImpactChanged {
+			return apiErrors.ErrIncidentPatchImpactStatusWrong
+		}
+	}
+
+	return nil
+}
+
+func checkPatchDataForIncident(incoming *PatchIncidentData, stored *db.Incident) error {
 	if stored.EndDate != nil {
 		if !event.IsIncidentClosedStatus(incoming.Status) {
 			return apiErrors.ErrIncidentPatchClosedStatus
@@ -1116,6 +1620,11 @@ func updateFields(income *PatchIncidentData, stored *db.Incident) {
 		stored.Type = income.Type
 	}
 
+	// the occurrence of the recurring maintenance, which is changed separately, isn't updated with the series
+	if stored.SeriesID != nil {
+		stored.SeriesDetached = true
+	}
+
 	stored.Status = income.Status
 
 	if income.Status == event.IncidentReopened {
@@ -1148,36 +1657,134 @@ func PostIncidentExtractHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFu
 			zap.Uint("incident_id", storedInc.ID),
 		)
 
-		var movedComponents []db.Component
-		var movedCounter int
-		for _, incCompID := range incData.Components {
-			present := false
-			for _, storedComp := range storedInc.Components {
-				if incCompID == int(storedComp.ID) {
-					present = true
-					movedComponents = append(movedComponents, storedComp)
-					movedCounter++
-					break
-				}
+		movedComponents, err := componentsToExtract(storedInc, incData.Components)
+		if err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if !checkTokenAccess(c, auth.ScopeIncidentUpdate, storedInc.Components) {
+			return
+		}
+
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		inc, err := extractComponents(actorDB, logger, storedInc, movedComponents)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, toAPIEvent(inc))
+	}
+}
+
+// componentsToExtract returns the components of the incident by the IDs,
+// at least one component should stay in the incident.
+func componentsToExtract(stored *db.Incident, componentIDs []int) ([]db.Component, error) {
+	var movedComponents []db.Component
+	var movedCounter int
+	for _, incCompID := range componentIDs {
+		present := false
+		for _, storedComp := range stored.Components {
+			if incCompID == int(storedComp.ID) {
+				present = true
+				movedComponents = append(movedComponents, storedComp)
+				movedCounter++
+				break
 			}
-			if !present {
-				apiErrors.RaiseBadRequestErr(c, fmt.Errorf("component %d is not in the incident", incCompID))
+		}
+		if !present {
+			return nil, fmt.Errorf("component %d is not in the incident", incCompID)
+		}
+	}
+
+	if movedCounter == len(stored.Components) {
+		return nil, fmt.Errorf("can not move all components to the new incident, keep at least one")
+	}
+
+	return movedComponents, nil
+}
+
+func extractComponents(
+	dbInst *db.DB, log *zap.Logger, stored *db.Incident, movedComponents []db.Component,
+) (*db.Incident, error) {
+	var inc *db.Incident
+	err := audit.Track(dbInst, func(t *audit.Tracker) error {
+		if err := t.Capture(stored.ID); err != nil {
+			return err
+		}
+
+		var err error
+		inc, err = t.DB().ExtractComponentsToNewIncident(
+			movedComponents,
+			stored,
+			*stored.Impact,
+			*stored.Text,
+			stored.Description)
+		if err != nil {
+			return err
+		}
+
+		if err = t.Record(string(webhook.ActionUpdated), stored.ID); err != nil {
+			return err
+		}
+		return t.Record(string(webhook.ActionExtracted), inc.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	NotifyEventChanged(dbInst, log, webhook.ActionUpdated, stored.ID)
+	NotifyEventChanged(dbInst, log, webhook.ActionExtracted, inc.ID)
+
+	return inc, nil
+}
+
+type PostIncidentMergeData struct {
+	// Target is the incident, which gets the components and the updates of the merged incident.
+	Target int `json:"target" binding:"required,gte=1"`
+}
+
+// PostIncidentMergeHandler merges the incident into the target incident and returns the target.
+func PostIncidentMergeHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		storedInc := getEventFromContext(c, logger)
+		if storedInc == nil {
+			return
+		}
+
+		var mergeData PostIncidentMergeData
+		if err := c.ShouldBindBodyWithJSON(&mergeData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		logger.Debug(
+			"merge the incident", zap.Uint("incident_id", storedInc.ID), zap.Int("target_id", mergeData.Target),
+		)
+
+		target, err := dbInst.GetIncident(mergeData.Target)
+		if err != nil {
+			if errors.Is(err, db.ErrDBIncidentDSNotExist) {
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrIncidentDSNotExist)
 				return
 			}
+			apiErrors.RaiseInternalErr(c, err)
+			return
 		}
 
-		if movedCounter == len(storedInc.Components) {
-			apiErrors.RaiseBadRequestErr(c, fmt.Errorf("can not move all components to the new incident, keep at least one"))
+		if !checkTokenAccess(c, auth.ScopeIncidentUpdate, storedInc.Components) ||
+			!checkTokenAccess(c, auth.ScopeIncidentUpdate, target.Components) {
 			return
 		}
 
-		inc, err := dbInst.ExtractComponentsToNewIncident(
-			movedComponents,
-			storedInc,
-			*storedInc.Impact,
-			*storedInc.Text,
-			storedInc.Description)
+		inc, err := MergeEvents(dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c)), logger, storedInc, target)
 		if err != nil {
+			if errors.Is(err, apiErrors.ErrIncidentMergeNotOpened) ||
+				errors.Is(err, apiErrors.ErrIncidentMergeSameIncident) {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
 			apiErrors.RaiseInternalErr(c, err)
 			return
 		}
@@ -1186,19 +1793,79 @@ func PostIncidentExtractHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFu
 	}
 }
 
+type PostMaintenanceApproveData struct {
+	// Version is the version of the maintenance, which was reviewed by the operator.
+	Version int `json:"version" binding:"required,gte=1"`
+}
+
+// PostMaintenanceApproveHandler moves the maintenance from "pending review" to "reviewed" status.
+// The checker moves the reviewed maintenance to "planned" status.
+func PostMaintenanceApproveHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		storedInc := getEventFromContext(c, logger)
+		if storedInc == nil {
+			return
+		}
+
+		var approveData PostMaintenanceApproveData
+		if err := c.ShouldBindBodyWithJSON(&approveData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if storedInc.Type != event.TypeMaintenance {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrMaintenanceApproveWrongType)
+			return
+		}
+
+		if !checkTokenAccess(c, auth.ScopeMaintenanceUpdate, storedInc.Components) {
+			return
+		}
+
+		userID := auth.UserFromContext(c)
+		logger.Info(
+			"approve the maintenance",
+			zap.Uint("eventID", storedInc.ID), zap.Int("version", approveData.Version), zap.String("user_id", userID),
+		)
+
+		text := event.MaintenanceReviewedStatusText()
+		if userID != "" {
+			text = fmt.Sprintf("%s Approved by %s.", text, userID)
+		}
+
+		actorDB := dbInst.WithActor(c.Request.Context(), userID)
+		var inc *db.Incident
+		err := audit.TrackEvent(actorDB, string(webhook.ActionUpdated), storedInc.ID, func(tx *db.DB) error {
+			var err error
+			inc, err = tx.ApproveMaintenance(storedInc.ID, approveData.Version, text)
+			return err
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrDBMaintenanceNotPendingReview):
+				apiErrors.RaiseConflictErr(c, apiErrors.ErrMaintenanceNotPendingReview)
+			case errors.Is(err, db.ErrDBMaintenanceVersionConflict):
+				apiErrors.RaiseConflictErr(c, apiErrors.ErrMaintenanceVersionConflict)
+			case errors.Is(err, db.ErrDBIncidentDSNotExist):
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrIncidentDSNotExist)
+			default:
+				apiErrors.RaiseInternalErr(c, err)
+			}
+			return
+		}
+
+		logger.Info("the maintenance is reviewed", zap.Uint("eventID", inc.ID), zap.String("user_id", userID))
+		NotifyEventChanged(actorDB, logger, webhook.ActionUpdated, inc.ID)
+		c.JSON(http.StatusOK, toAPIEvent(inc))
+	}
+}
+
 type Component struct {
 	ComponentID
 	Attributes []ComponentAttribute `json:"attributes"`
 	Name       string               `json:"name"`
 }
 
-type ComponentAvailability struct {
-	ComponentID
-	Name         string                `json:"name"`
-	Availability []MonthlyAvailability `json:"availability"`
-	Region       string                `json:"region"`
-}
-
 type ComponentID struct {
 	ID int `json:"id" uri:"id" binding:"required,gte=0"`
 }
@@ -1220,17 +1887,22 @@ var availableAttrs = map[string]struct{}{ //nolint:gochecknoglobals
 	"category": {},
 }
 
-type MonthlyAvailability struct {
-	Year       int     `json:"year"`
-	Month      int     `json:"month"`      // Number of the month (1 - 12)
-	Percentage float64 `json:"percentage"` // Percent (0 - 100 / example: 95.23478)
+// ComponentsQuery is used to show the deleted (retired) components, they are hidden by default.
+type ComponentsQuery struct {
+	IncludeDeleted bool `form:"include_deleted"`
 }
 
 func GetComponentsHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger.Debug("retrieve components")
 
-		r, err := dbInst.GetComponentsWithValues()
+		var query ComponentsQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		r, err := dbInst.GetComponentsWithValues(&db.ComponentsParams{IncludeDeleted: query.IncludeDeleted})
 		if err != nil {
 			apiErrors.RaiseInternalErr(c, err)
 			return
@@ -1280,8 +1952,13 @@ func PostComponentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		if err := checkComponentAttrs(component.Attributes); err != nil {
-			apiErrors.RaiseBadRequestErr(c, err)
+		if err := checkComponentAttrs(dbInst, component.Attributes); err != nil {
+			if errors.Is(err, apiErrors.ErrComponentAttrInvalidFormat) ||
+				errors.Is(err, apiErrors.ErrComponentRegionAttrMissing) {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
+			apiErrors.RaiseInternalErr(c, err)
 			return
 		}
 
@@ -1298,6 +1975,10 @@ func PostComponentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 			Attrs: attrs,
 		}
 
+		if !checkTokenAccess(c, auth.ScopeComponentsManage, []db.Component{*compDB}) {
+			return
+		}
+
 		componentID, err := dbInst.SaveComponent(compDB)
 		if err != nil {
 			if errors.Is(err, db.ErrDBComponentExists) {
@@ -1315,226 +1996,197 @@ func PostComponentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 	}
 }
 
-func checkComponentAttrs(attrs []ComponentAttribute) error {
-	//nolint:nolintlint,mnd
-	// Check total number of attributes
-	// this magic number will be changed in the next iteration
-	if len(attrs) != 3 {
-		return apiErrors.ErrComponentAttrInvalidFormat
-	}
-
-	// Track seen attribute names to detect duplicates
-	seen := make(map[string]bool)
+type PatchComponentData struct {
+	Name       *string              `json:"name,omitempty"`
+	Attributes []ComponentAttribute `json:"attrs,omitempty"`
+}
 
-	// Verify all required attributes exist exactly once
-	for _, attr := range attrs {
-		if _, exists := availableAttrs[attr.Name]; !exists {
-			return apiErrors.ErrComponentAttrInvalidFormat
+// PatchComponentHandler renames the component or changes its attributes, the deleted components can't be changed.
+func PatchComponentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var compID ComponentID
+		if err := c.ShouldBindUri(&compID); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrComponentInvalidFormat)
+			return
 		}
 
-		// Check for duplicate attributes
-		if seen[attr.Name] {
-			return apiErrors.ErrComponentAttrInvalidFormat
+		var compData PatchComponentData
+		if err := c.ShouldBindBodyWithJSON(&compData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
 		}
-		seen[attr.Name] = true
-	}
 
-	// Verify all required attributes were found
-	for requiredAttr := range availableAttrs {
-		if !seen[requiredAttr] {
-			return apiErrors.ErrComponentAttrInvalidFormat
+		if compData.Name != nil && *compData.Name == "" {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrComponentInvalidFormat)
+			return
 		}
-	}
 
-	return nil
-}
-
-func GetComponentsAvailabilityHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		logger.Debug("retrieve availability of components")
-
-		components, err := dbInst.GetComponentsWithIncidents()
-		if err != nil {
+		if err := checkPatchComponentAttrs(dbInst, compData.Attributes); err != nil {
+			if errors.Is(err, apiErrors.ErrComponentAttrInvalidFormat) ||
+				errors.Is(err, apiErrors.ErrComponentRegionAttrMissing) {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
 			apiErrors.RaiseInternalErr(c, err)
 			return
 		}
 
-		availability := make([]*ComponentAvailability, len(components))
-		for index, comp := range components {
-			attrs := make([]ComponentAttribute, len(comp.Attrs))
-			for i, attr := range comp.Attrs {
-				attrs[i] = ComponentAttribute{
-					Name:  attr.Name,
-					Value: attr.Value,
-				}
-			}
-			regionValue := ""
-			for _, attr := range attrs {
-				if attr.Name == "region" {
-					regionValue = attr.Value
-					break
-				}
+		stored, err := dbInst.GetComponent(compID.ID)
+		if err != nil {
+			if errors.Is(err, db.ErrDBComponentDSNotExist) {
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrComponentDSNotExist)
+				return
 			}
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
 
-			incidents := make([]*Incident, len(comp.Incidents))
-			for i, inc := range comp.Incidents {
-				newInc := &Incident{
-					IncidentID: IncidentID{int(inc.ID)},
-					IncidentData: IncidentData{
-						Title:     *inc.Text,
-						Impact:    inc.Impact,
-						StartDate: *inc.StartDate,
-						EndDate:   inc.EndDate,
-						Updates:   nil,
-						Type:      inc.Type,
-					},
-				}
-				incidents[i] = newInc
-			}
+		if stored.DeletedAt != nil {
+			apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrComponentDSNotExist)
+			return
+		}
 
-			compAvailability, calcErr := calculateAvailability(&comp)
-			if calcErr != nil {
-				apiErrors.RaiseInternalErr(c, calcErr)
-				return
-			}
+		comp := &db.Component{ID: stored.ID, Name: stored.Name}
+		if compData.Name != nil {
+			comp.Name = *compData.Name
+		}
+		for _, attr := range compData.Attributes {
+			comp.Attrs = append(comp.Attrs, db.ComponentAttr{Name: attr.Name, Value: attr.Value})
+		}
 
-			sortComponentAvailability(compAvailability)
+		// the restricted API token can't move the component out of its regions
+		changed := *stored
+		changed.Attrs = append(slices.Clone(stored.Attrs), comp.Attrs...)
+		if !checkTokenAccess(c, auth.ScopeComponentsManage, []db.Component{*stored, changed}) {
+			return
+		}
 
-			availability[index] = &ComponentAvailability{
-				ComponentID:  ComponentID{int(comp.ID)},
-				Region:       regionValue,
-				Name:         comp.Name,
-				Availability: compAvailability,
+		if err = dbInst.ModifyComponent(comp); err != nil {
+			switch {
+			case errors.Is(err, db.ErrDBComponentDSNotExist):
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrComponentDSNotExist)
+			case errors.Is(err, db.ErrDBComponentExists):
+				apiErrors.RaiseBadRequestErr(c, apiErrors.ErrComponentExist)
+			default:
+				apiErrors.RaiseInternalErr(c, err)
 			}
+			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": availability})
+		updated, err := dbInst.GetComponent(compID.ID)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the component is updated", zap.Uint("componentID", updated.ID), zap.String("name", updated.Name))
+		c.JSON(http.StatusOK, updated)
 	}
 }
 
-func sortComponentAvailability(availabilities []MonthlyAvailability) {
-	sort.Slice(availabilities, func(i, j int) bool {
-		if availabilities[i].Year == availabilities[j].Year {
-			return availabilities[i].Month > availabilities[j].Month
+// DeleteComponentHandler retires the component, it's hidden from the components list and from the availability,
+// but it's kept in the events history. The component affected by active events can't be deleted.
+func DeleteComponentHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var compID ComponentID
+		if err := c.ShouldBindUri(&compID); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrComponentInvalidFormat)
+			return
 		}
-		return availabilities[i].Year > availabilities[j].Year
-	})
-}
 
-// TODO: add filters for GET request
-func calculateAvailability(component *db.Component) ([]MonthlyAvailability, error) {
-	const (
-		monthsInYear       = 12
-		precisionFactor    = 100000
-		fullPercentage     = 100
-		availabilityMonths = 11
-		roundFactor        = 0.5
-	)
+		if !checkTokenAccessByIDs(c, dbInst, auth.ScopeComponentsManage, []int{compID.ID}) {
+			return
+		}
 
-	if component == nil {
-		return nil, fmt.Errorf("component is nil")
-	}
+		if err := dbInst.DeleteComponent(uint(compID.ID)); err != nil {
+			switch {
+			case errors.Is(err, db.ErrDBComponentDSNotExist):
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrComponentDSNotExist)
+			case errors.Is(err, db.ErrDBComponentInUse):
+				apiErrors.RaiseConflictErr(c, apiErrors.ErrComponentInUse)
+			default:
+				apiErrors.RaiseInternalErr(c, err)
+			}
+			return
+		}
 
-	if len(component.Incidents) == 0 {
-		return nil, nil
+		logger.Info("the component is deleted", zap.Int("componentID", compID.ID))
+		c.Status(http.StatusNoContent)
 	}
+}
 
-	periodEndDate := time.Now().UTC()
-	// Get the current date and starting point (12 months ago)
-	// a year ago, including current the month
-	periodStartDate := time.Date(periodEndDate.Year(), periodEndDate.Month(),
-		1, 0, 0, 0, 0, time.UTC).AddDate(0, -availabilityMonths, 0)
-	monthlyDowntime := make([]float64, monthsInYear) // 12 months
-
-	for _, inc := range component.Incidents {
-		if inc.EndDate == nil || *inc.Impact != 3 {
-			continue
+// checkPatchComponentAttrs validates the changed attributes, the missing attributes are added, but can't be removed.
+func checkPatchComponentAttrs(dbInst *db.DB, attrs []ComponentAttribute) error {
+	seen := make(map[string]bool)
+	for _, attr := range attrs {
+		if _, exists := availableAttrs[attr.Name]; !exists || attr.Value == "" || seen[attr.Name] {
+			return apiErrors.ErrComponentAttrInvalidFormat
 		}
+		seen[attr.Name] = true
 
-		// here we skip all incidents that are not correspond to our period
-		// if the incident started before availability period
-		// (as example the incident was started at 01:00 31/12 and finished at 02:00 01/01),
-		// we cut the beginning to the period start date, and do the same for the period ending
-
-		incidentStart, incidentEnd, valid := adjustIncidentPeriod(
-			*inc.StartDate,
-			*inc.EndDate,
-			periodStartDate,
-			periodEndDate,
-		)
-		if !valid {
+		if attr.Name != "region" {
 			continue
 		}
 
-		current := incidentStart
-		for current.Before(incidentEnd) {
-			monthStart := time.Date(current.Year(), current.Month(), 1, 0, 0, 0, 0, time.UTC)
-			monthEnd := monthStart.AddDate(0, 1, 0)
-
-			downtimeStart := maxTime(incidentStart, monthStart)
-			downtimeEnd := minTime(incidentEnd, monthEnd)
-			downtime := downtimeEnd.Sub(downtimeStart).Hours()
-
-			monthIndex := (downtimeStart.Year()-periodStartDate.Year())*monthsInYear +
-				int(downtimeStart.Month()-periodStartDate.Month())
-			if monthIndex >= 0 && monthIndex < len(monthlyDowntime) {
-				monthlyDowntime[monthIndex] += downtime
-			}
-
-			current = monthEnd
+		exists, err := dbInst.RegionExists(attr.Value)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return apiErrors.ErrComponentRegionAttrMissing
 		}
 	}
 
-	monthlyAvailability := make([]MonthlyAvailability, 0, monthsInYear)
-	for i := range [monthsInYear]int{} {
-		monthDate := periodStartDate.AddDate(0, i, 0)
-		totalHours := hoursInMonth(monthDate.Year(), int(monthDate.Month()))
-		availability := fullPercentage - (monthlyDowntime[i] / totalHours * fullPercentage)
-		availability = float64(int(availability*precisionFactor+roundFactor)) / precisionFactor
+	return nil
+}
 
-		monthlyAvailability = append(monthlyAvailability, MonthlyAvailability{
-			Year:       monthDate.Year(),
-			Month:      int(monthDate.Month()),
-			Percentage: availability,
-		})
+// checkComponentAttrs validates the attributes of a new component, the region attribute must be a registered region.
+func checkComponentAttrs(dbInst *db.DB, attrs []ComponentAttribute) error {
+	//nolint:nolintlint,mnd
+	// Check total number of attributes
+	// this magic number will be changed in the next iteration
+	if len(attrs) != 3 {
+		return apiErrors.ErrComponentAttrInvalidFormat
 	}
 
-	return monthlyAvailability, nil
-}
+	// Track seen attribute names to detect duplicates
+	seen := make(map[string]bool)
 
-// Helper functions for calculateAvailability.
-func adjustIncidentPeriod(incidentStart, incidentEnd, periodStart, periodEnd time.Time) (time.Time, time.Time, bool) {
-	if incidentEnd.Before(periodStart) || incidentStart.After(periodEnd) {
-		return time.Time{}, time.Time{}, false
-	}
-	if incidentStart.Before(periodStart) {
-		incidentStart = periodStart
-	}
-	if incidentEnd.After(periodEnd) {
-		incidentEnd = periodEnd
-	}
-	return incidentStart, incidentEnd, true
-}
+	// Verify all required attributes exist exactly once
+	for _, attr := range attrs {
+		if _, exists := availableAttrs[attr.Name]; !exists {
+			return apiErrors.ErrComponentAttrInvalidFormat
+		}
 
-func minTime(start, end time.Time) time.Time {
-	if start.Before(end) {
-		return start
+		// Check for duplicate attributes
+		if seen[attr.Name] {
+			return apiErrors.ErrComponentAttrInvalidFormat
+		}
+		seen[attr.Name] = true
 	}
-	return end
-}
 
-func maxTime(start, end time.Time) time.Time {
-	if start.After(end) {
-		return start
+	// Verify all required attributes were found
+	for requiredAttr := range availableAttrs {
+		if !seen[requiredAttr] {
+			return apiErrors.ErrComponentAttrInvalidFormat
+		}
 	}
-	return end
-}
 
-func hoursInMonth(year int, month int) float64 {
-	firstDay := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	nextMonth := firstDay.AddDate(0, 1, 0)
+	for _, attr := range attrs {
+		if attr.Name != "region" {
+			continue
+		}
+
+		exists, err := dbInst.RegionExists(attr.Value)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return apiErrors.ErrComponentRegionAttrMissing
+		}
+	}
 
-	return float64(nextMonth.Sub(firstDay).Hours())
+	return nil
 }
 
 type EventUpdateData struct {
@@ -1542,6 +2194,8 @@ type EventUpdateData struct {
 	Status    event.Status `json:"status"`
 	Text      string       `json:"text"`
 	Timestamp time.Time    `json:"timestamp"`
+	// Author is a read only field, it's the user, who added the update.
+	Author string `json:"author,omitempty"`
 }
 
 func bindAndValidatePatchEventUpdate(c *gin.Context) (int, int, string, error) {
@@ -1581,6 +2235,13 @@ func PatchEventUpdateTextHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerF
 			return
 		}
 
+		if auth.TokenFromContext(c) != nil {
+			storedInc := getEventFromContext(c, logger)
+			if storedInc == nil || !checkTokenAccess(c, auth.UpdateScope(storedInc.Type), storedInc.Components) {
+				return
+			}
+		}
+
 		// Update existence check.
 		updates, err := dbInst.GetEventUpdates(uint(incID))
 		if err != nil {
@@ -1596,8 +2257,12 @@ func PatchEventUpdateTextHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerF
 		targetUPD := updates[updID]
 		targetUPD.Text = text
 
-		updated, err := dbInst.ModifyEventUpdate(targetUPD)
-
+		actorDB := dbInst.WithActor(c.Request.Context(), auth.UserFromContext(c))
+		var updated db.IncidentStatus
+		err = audit.TrackEvent(actorDB, string(webhook.ActionUpdated), uint(incID), func(tx *db.DB) error {
+			updated, err = tx.ModifyEventUpdate(targetUPD)
+			return err
+		})
 		if err != nil {
 			apiErrors.RaiseInternalErr(c, err)
 			return
@@ -1621,11 +2286,97 @@ func mapEventUpdates(statuses []db.IncidentStatus) []EventUpdateData {
 			Text:      s.Text,
 			Timestamp: s.Timestamp,
 		}
+		if s.Author != nil {
+			updates[i].Author = *s.Author
+		}
 	}
 
 	return updates
 }
 
+// NotifyEventChanged enqueues webhook deliveries, email messages and stream messages for the changed events,
+// the changes are recorded to the audit log with the write by audit.Track.
+// The event is already stored, so the error is only logged and doesn't affect the response.
+// It's also used by the v1 handlers, so the events of the legacy API get the same notifications.
+func NotifyEventChanged(dbInst *db.DB, log *zap.Logger, action webhook.Action, eventIDs ...uint) {
+	for _, id := range eventIDs {
+		if err := webhook.Enqueue(dbInst, action, id); err != nil {
+			log.Error(
+				"failed to enqueue webhook deliveries",
+				zap.Error(err), zap.Uint("eventID", id), zap.String("action", string(action)),
+			)
+		}
+
+		if err := email.Enqueue(dbInst, id); err != nil {
+			log.Error(
+				"failed to enqueue email messages",
+				zap.Error(err), zap.Uint("eventID", id), zap.String("action", string(action)),
+			)
+		}
+
+		if err := stream.Publish(dbInst, action, id); err != nil {
+			log.Error(
+				"failed to publish the stream message",
+				zap.Error(err), zap.Uint("eventID", id), zap.String("action", string(action)),
+			)
+		}
+	}
+}
+
+// moveComponent moves the component to another incident and records the changes of both incidents
+// to the audit log in one transaction.
+func moveComponent(dbInst *db.DB, comp *db.Component, from, to *db.Incident, closeOld bool) (*db.Incident, error) {
+	var inc *db.Incident
+	err := audit.Track(dbInst, func(t *audit.Tracker) error {
+		if err := t.Capture(from.ID, to.ID); err != nil {
+			return err
+		}
+
+		var err error
+		if inc, err = t.DB().MoveComponentFromOldToAnotherIncident(comp, from, to, closeOld); err != nil {
+			return err
+		}
+
+		return t.Record(string(webhook.ActionUpdated), from.ID, to.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inc, nil
+}
+
+// checkTokenAccess checks the scope and the component restrictions of the API token, the error is raised if it fails.
+func checkTokenAccess(c *gin.Context, scope auth.Scope, components []db.Component) bool {
+	if err := auth.CheckTokenAccess(c, scope, components); err != nil {
+		apiErrors.RaiseForbiddenErr(c, err)
+		return false
+	}
+
+	return true
+}
+
+// checkTokenAccessByIDs is checkTokenAccess for the components, which are not loaded yet.
+// The components are loaded only if the API token is restricted.
+func checkTokenAccessByIDs(c *gin.Context, dbInst *db.DB, scope auth.Scope, componentIDs []int) bool {
+	token := auth.TokenFromContext(c)
+	if token == nil || !token.Restricted() {
+		return checkTokenAccess(c, scope, nil)
+	}
+
+	components, err := fetchComponents(dbInst, componentIDs)
+	if err != nil {
+		if errors.Is(err, db.ErrDBComponentDSNotExist) {
+			apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrComponentDSNotExist)
+			return false
+		}
+		apiErrors.RaiseInternalErr(c, err)
+		return false
+	}
+
+	return checkTokenAccess(c, scope, components)
+}
+
 func getEventFromContext(c *gin.Context, logger *zap.Logger) *db.Incident {
 	val, exists := c.Get("event")
 	if !exists {
@@ -3,6 +3,7 @@ package v2
 import (
 	"database/sql/driver"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
 	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
 )
@@ -25,6 +27,11 @@ func initTests(t *testing.T) (*gin.Engine, sqlmock.Sqlmock, *db.DB) {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
 	r.NoRoute(apiErrors.Return404)
+	// the routes are used without the authentication middleware, the identity is set as for the disabled authentication
+	r.Use(func(c *gin.Context) {
+		auth.SetIdentity(c, auth.RoleAdmin, "")
+		c.Next()
+	})
 
 	log, _ := zap.NewDevelopment()
 	initRoutes(t, r, d, log)
@@ -75,11 +82,13 @@ func prepareIncident(t *testing.T, mock sqlmock.Sqlmock, testTime time.Time) {
 	rowsInc := sqlmock.NewRows([]string{"id", "text", "description", "start_date", "end_date", "impact", "system", "type"}).
 		AddRow(1, "Incident title A", "Description A", testTime, testTime.Add(time.Hour*72), 0, false, "maintenance").
 		AddRow(2, "Incident title B", "Description B", testTime, testTime.Add(time.Hour*72), 3, false, "incident")
-	mock.ExpectQuery("^SELECT (.+) FROM \"incident\" ORDER BY incident.start_date DESC$").WillReturnRows(rowsInc)
+	mock.ExpectQuery("^SELECT (.+) FROM \"incident\" ORDER BY incident.start_date DESC,incident.id DESC$").
+		WillReturnRows(rowsInc)
 
 	rowsIncComp := sqlmock.NewRows([]string{"incident_id", "component_id"}).
 		AddRow(1, 150).
 		AddRow(2, 151)
+	expectComponentImpacts(mock)
 	mock.ExpectQuery("^SELECT (.+) FROM \"incident_component_relation\"(.+)").WillReturnRows(rowsIncComp)
 
 	rowsComp := sqlmock.NewRows([]string{"id", "name"}).
@@ -166,6 +175,7 @@ func prepareMockForIncidents(t *testing.T, mock sqlmock.Sqlmock, result []*db.In
 
 	rowsIncComp, rowsComp, rowsCompAttr, rowsStatus := prepareRelatedRows(result)
 
+	expectComponentImpacts(mock, incidentIDs...)
 	mock.ExpectQuery(`^SELECT (.+) FROM "incident_component_relation"`).WithArgs(incidentIDs...).WillReturnRows(rowsIncComp)
 	mock.ExpectQuery(`^SELECT (.+) FROM "component"`).WithArgs(componentIDs...).WillReturnRows(rowsComp)
 	mock.ExpectQuery("^SELECT (.+) FROM \"component_attribute\"").WillReturnRows(rowsCompAttr)
@@ -190,6 +200,7 @@ func prepareMockForEvents(t *testing.T, mock sqlmock.Sqlmock, result []*db.Incid
 
 	rowsIncComp, rowsComp, rowsCompAttr, rowsStatus := prepareRelatedRows(result)
 
+	expectComponentImpacts(mock, incidentIDs...)
 	mock.ExpectQuery(`^SELECT (.+) FROM "incident_component_relation"`).
 		WithArgs(incidentIDs...).
 		WillReturnRows(rowsIncComp)
@@ -206,12 +217,20 @@ func prepareMockForEvents(t *testing.T, mock sqlmock.Sqlmock, result []*db.Incid
 	mock.ExpectQuery(`^SELECT (.+) FROM "incident_status"`).WithArgs(incidentIDs...).WillReturnRows(rowsStatus)
 }
 
+// expectComponentImpacts mocks the preload of the impacts on the components,
+// the relations without the impact have the impact of the incident.
+func expectComponentImpacts(mock sqlmock.Sqlmock, incidentIDs ...driver.Value) {
+	mock.ExpectQuery(`^SELECT (.+) FROM "incident_component_relation"`).
+		WithArgs(incidentIDs...).
+		WillReturnRows(sqlmock.NewRows([]string{"incident_id", "component_id", "impact"}))
+}
+
 func prepareAvailability(t *testing.T, mock sqlmock.Sqlmock, testTime time.Time) {
 	t.Helper()
 
 	rowsComp := sqlmock.NewRows([]string{"id", "name"}).
 		AddRow(151, "Component B")
-	mock.ExpectQuery("^SELECT (.+) FROM \"component\"$").WillReturnRows(rowsComp)
+	mock.ExpectQuery("^SELECT (.+) FROM \"component\" WHERE component.deleted_at IS NULL$").WillReturnRows(rowsComp)
 
 	rowsCompAttr := sqlmock.NewRows([]string{"id", "component_id", "name", "value"}).
 		AddRows([][]driver.Value{
@@ -231,6 +250,7 @@ func prepareAvailability(t *testing.T, mock sqlmock.Sqlmock, testTime time.Time)
 	rowsInc := sqlmock.NewRows([]string{"id", "text", "description", "start_date", "end_date", "impact", "system", "type"}).
 		AddRow(2, "Incident title B", "Description B for Availability", startOfMonth, startOfNextMonth, 3, false, "incident")
 	mock.ExpectQuery("^SELECT (.+) FROM \"incident\" WHERE \"incident\".\"id\" = \\$1$").WillReturnRows(rowsInc)
+	expectComponentImpacts(mock)
 
 	rowsStatus := sqlmock.NewRows([]string{"id", "incident_id", "timestamp", "text", "status"}).
 		AddRow(2, 2, testTime.Add(time.Hour*72), "Issue solved.", "resolved")
@@ -252,22 +272,7 @@ func prepareMockForPatchEventUpdate(t *testing.T, mock sqlmock.Sqlmock, incident
 	t.Helper()
 
 	// First mock for GetIncident in EventExistenceCheck middleware
-	rowsInc, incidentIDs, componentIDs := prepareIncidentRows([]*db.Incident{incident})
-	mock.ExpectQuery(`^SELECT (.+) FROM "incident"`).WillReturnRows(rowsInc)
-
-	rowsIncComp, rowsComp, rowsCompAttr, rowsStatus := prepareRelatedRows([]*db.Incident{incident})
-
-	mock.ExpectQuery(`^SELECT (.+) FROM "incident_component_relation"`).
-		WithArgs(incidentIDs...).
-		WillReturnRows(rowsIncComp)
-	mock.ExpectQuery(`^SELECT (.+) FROM "component"`).
-		WithArgs(componentIDs...).
-		WillReturnRows(rowsComp)
-	mock.ExpectQuery("^SELECT (.+) FROM \"component_attribute\"").
-		WillReturnRows(rowsCompAttr)
-	mock.ExpectQuery(`^SELECT (.+) FROM "incident_status"`).
-		WithArgs(incidentIDs...).
-		WillReturnRows(rowsStatus)
+	expectGetIncident(mock, incident)
 
 	// Second mock for GetEventUpdates in handler - get all updates
 	statusRows := sqlmock.NewRows([]string{"id", "incident_id", "status", "text", "timestamp"})
@@ -285,11 +290,16 @@ func prepareMockForPatchEventUpdate(t *testing.T, mock sqlmock.Sqlmock, incident
 	returningRows := sqlmock.NewRows([]string{"id", "incident_id", "status", "text", "timestamp"})
 	returningRows.AddRow(targetStatus.ID, targetStatus.IncidentID, targetStatus.Status, updatedText, targetStatus.Timestamp)
 
+	// The update is changed in the transaction with the audit record, the event is locked and captured before it
 	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT "id" FROM "incident" WHERE id IN \(\$1\) ORDER BY id FOR UPDATE`).
+		WithArgs(incident.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(incident.ID))
+	expectGetIncident(mock, incident)
+
 	mock.ExpectQuery(`^UPDATE "incident_status" SET "modified_at"=\$1,"text"=\$2 WHERE id = \$3 AND incident_id = \$4 RETURNING .*`).
 		WithArgs(sqlmock.AnyArg(), updatedText, targetStatus.ID, incident.ID).
 		WillReturnRows(returningRows)
-	mock.ExpectCommit()
 
 	// Mock for Scan(&updated)
 	returningRows = sqlmock.NewRows([]string{"id", "incident_id", "status", "text", "timestamp"})
@@ -297,6 +307,47 @@ func prepareMockForPatchEventUpdate(t *testing.T, mock sqlmock.Sqlmock, incident
 	mock.ExpectQuery(`^SELECT \* FROM "incident_status" WHERE id = \$1 AND incident_id = \$2`).
 		WithArgs(updateID, incident.ID).
 		WillReturnRows(returningRows)
+	mock.ExpectExec(`^UPDATE "incident" SET "modified_at"=\$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), incident.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	updatedInc := *incident
+	updatedInc.Statuses = slices.Clone(incident.Statuses)
+	updatedInc.Statuses[updateIndex].Text = updatedText
+	expectGetIncident(mock, &updatedInc)
+	mock.ExpectQuery(`^INSERT INTO "audit_log"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+}
+
+// expectGetIncident mocks the queries of GetIncident for the event.
+func expectGetIncident(mock sqlmock.Sqlmock, incident *db.Incident) {
+	rowsInc, incidentIDs, componentIDs := prepareIncidentRows([]*db.Incident{incident})
+	mock.ExpectQuery(`^SELECT (.+) FROM "incident"`).WillReturnRows(rowsInc)
+
+	rowsIncComp, rowsComp, rowsCompAttr, rowsStatus := prepareRelatedRows([]*db.Incident{incident})
+
+	expectComponentImpacts(mock, incidentIDs...)
+	mock.ExpectQuery(`^SELECT (.+) FROM "incident_component_relation"`).
+		WithArgs(incidentIDs...).
+		WillReturnRows(rowsIncComp)
+	mock.ExpectQuery(`^SELECT (.+) FROM "component"`).
+		WithArgs(componentIDs...).
+		WillReturnRows(rowsComp)
+	mock.ExpectQuery("^SELECT (.+) FROM \"component_attribute\"").
+		WillReturnRows(rowsCompAttr)
+	mock.ExpectQuery(`^SELECT (.+) FROM "incident_status"`).
+		WithArgs(incidentIDs...).
+		WillReturnRows(rowsStatus)
+}
+
+// prepareMockForEventModifiedAt mocks the modified_at of the event, which is changed with its updates.
+func prepareMockForEventModifiedAt(mock sqlmock.Sqlmock, incidentID uint) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "incident" SET "modified_at"=\$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), incidentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 }
 
 func prepareMockForModifyEventUpdate(
@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,9 +11,11 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
 	"github.com/stackmon/otc-status-dashboard/internal/api/errors"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
@@ -280,6 +283,13 @@ func TestGetIncidentsHandlerFilters(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   fmt.Sprintf(`{"errMsg":"%s"}`, errors.ErrIncidentFQueryInvalidFormat),
 		},
+		{
+			name:           "Invalid filter: too long search",
+			url:            "/v2/incidents?search=" + strings.Repeat("a", 201),
+			mockSetup:      func(_ sqlmock.Sqlmock, _ *db.IncidentsParams) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   fmt.Sprintf(`{"errMsg":"%s"}`, errors.ErrIncidentFQueryInvalidFormat),
+		},
 		{
 			name:           "Invalid filter: start_date after end_date",
 			url:            fmt.Sprintf("/v2/incidents?start_date=%s&end_date=%s", endDate, startDate), // Swapped start and end dates
@@ -522,6 +532,53 @@ func TestGetEventsHandler(t *testing.T) { //nolint:gocognit
 	}
 }
 
+func TestGetEventsHandlerInvalidSortAndCursor(t *testing.T) {
+	r, m, _ := initTests(t)
+
+	startDate := time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC)
+	cursor := db.NewEventsCursor(&db.Incident{ID: 10, StartDate: &startDate}, db.DefaultEventsSort()).Encode()
+
+	urls := []string{
+		"/v2/events?sort=title",
+		"/v2/events?order=random",
+		"/v2/events?cursor=invalid",
+		"/v2/events?cursor=" + base64.RawURLEncoding.EncodeToString([]byte(`{"s":{"f":"impact"},"id":10}`)),
+		"/v2/events?page=2&cursor=" + cursor,
+		"/v2/events?sort=impact&cursor=" + cursor,
+		"/v2/events?order=asc&cursor=" + cursor,
+		"/v2/events?modified_since=yesterday",
+	}
+
+	for _, url := range urls {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		assert.JSONEq(t, fmt.Sprintf(`{"errMsg":"%s"}`, errors.ErrIncidentFQueryInvalidFormat), w.Body.String(), url)
+	}
+
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestEventsCursor(t *testing.T) {
+	startDate := time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC)
+	impact := 2
+	inc := &db.Incident{ID: 10, StartDate: &startDate, Impact: &impact}
+
+	for _, sort := range []db.EventsSort{
+		{Field: db.SortStartDate, Desc: true},
+		{Field: db.SortEndDate},
+		{Field: db.SortModifiedAt},
+		{Field: db.SortImpact, Desc: true},
+	} {
+		cursor := db.NewEventsCursor(inc, sort)
+		decoded, err := db.DecodeEventsCursor(cursor.Encode())
+		require.NoError(t, err, sort.Field)
+		assert.Equal(t, cursor, decoded, sort.Field)
+	}
+}
+
 func TestGetComponentsAvailabilityHandler(t *testing.T) {
 	r, m, _ := initTests(t)
 	// Mocking data for testing
@@ -541,7 +598,11 @@ func TestGetComponentsAvailabilityHandler(t *testing.T) {
 		if i == 1 {
 			percentage = 0
 		}
-		expectedAvailability += fmt.Sprintf(`{"year":%d,"month":%d,"percentage":%d},`, availYear, availMonth, percentage)
+		start := time.Date(availYear, time.Month(availMonth), 1, 0, 0, 0, 0, time.UTC)
+		expectedAvailability += fmt.Sprintf(
+			`{"year":%d,"month":%d,"percentage":%d,"start":"%s","end":"%s"},`,
+			availYear, availMonth, percentage, start.Format(time.RFC3339), start.AddDate(0, 1, 0).Format(time.RFC3339),
+		)
 	}
 
 	// Remove trailing comma
@@ -564,6 +625,7 @@ func TestCalculateAvailability(t *testing.T) {
 	}
 
 	impact := 3
+	now := time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)
 
 	comp := db.Component{
 		ID:        150,
@@ -589,7 +651,7 @@ func TestCalculateAvailability(t *testing.T) {
 				results := make([]*MonthlyAvailability, 12)
 
 				for i := range [12]int{} {
-					year, month := getYearAndMonth(time.Now().Year(), int(time.Now().Month()), 12-i-1)
+					year, month := getYearAndMonth(now.Year(), int(now.Month()), 12-i-1)
 					results[i] = &MonthlyAvailability{
 						Year:       year,
 						Month:      month,
@@ -614,7 +676,7 @@ func TestCalculateAvailability(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		result, err := calculateAvailability(tc.Component)
+		result, err := calculateAvailability(tc.Component, defaultAvailabilityParams(now))
 		require.NoError(t, err)
 
 		t.Logf("Test '%s': Calculated availability: %+v", tc.testDescription, result)
@@ -626,6 +688,130 @@ func TestCalculateAvailability(t *testing.T) {
 	}
 }
 
+func TestCalculateAvailabilityWithParams(t *testing.T) {
+	minor, outage, maintImpact := 1, 3, 0
+
+	// 12 hours of the minor incident and 6 hours of the outage on the 2nd of March 2025,
+	// the outage is covered by the maintenance window for 3 hours
+	incStart := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	incEnd := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	outStart := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	outEnd := time.Date(2025, 3, 2, 18, 0, 0, 0, time.UTC)
+	maintStart := time.Date(2025, 3, 2, 15, 0, 0, 0, time.UTC)
+	maintEnd := time.Date(2025, 3, 2, 18, 0, 0, 0, time.UTC)
+
+	comp := &db.Component{
+		ID:   150,
+		Name: "DataArts",
+		Incidents: []*db.Incident{
+			{ID: 1, StartDate: &incStart, EndDate: &incEnd, Impact: &minor, Type: event.TypeIncident},
+			{ID: 2, StartDate: &outStart, EndDate: &outEnd, Impact: &outage, Type: event.TypeIncident},
+			{
+				ID: 3, StartDate: &maintStart, EndDate: &maintEnd, Impact: &maintImpact,
+				Type: event.TypeMaintenance, Status: event.MaintenanceCompleted,
+			},
+		},
+	}
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	halfWeight := 0.5
+
+	testCases := []struct {
+		name     string
+		query    *AvailabilityQuery
+		expected []float64
+	}{
+		{
+			name:     "daily, only outages",
+			query:    &AvailabilityQuery{From: &from, To: &to, Granularity: GranularityDay},
+			expected: []float64{100, 75, 100},
+		},
+		{
+			name:     "daily, minor incidents with the half weight",
+			query:    &AvailabilityQuery{From: &from, To: &to, Granularity: GranularityDay, MinorWeight: &halfWeight},
+			expected: []float64{100, 50, 100},
+		},
+		{
+			name: "daily, maintenances are excluded",
+			query: &AvailabilityQuery{
+				From: &from, To: &to, Granularity: GranularityDay, ExcludeMaintenance: true,
+			},
+			expected: []float64{100, 85.71429, 100},
+		},
+		{
+			name:     "weekly, the periods are cut by the report range",
+			query:    &AvailabilityQuery{From: &from, To: &to, Granularity: GranularityWeek},
+			expected: []float64{87.5, 100},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := parseAvailabilityQuery(tc.query, to)
+			require.NoError(t, err)
+
+			result, err := calculateAvailability(comp, params)
+			require.NoError(t, err)
+			require.Len(t, result, len(tc.expected))
+			for i, r := range result {
+				assert.InDelta(t, tc.expected[i], r.Percentage, 0.0001)
+			}
+		})
+	}
+}
+
+func TestCalculateAvailabilityWithComponentImpact(t *testing.T) {
+	outage, minor := 3, 1
+
+	// the outage of the incident affects the component 151 only, the component 150 has the minor impact
+	incStart := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	incEnd := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	inc := &db.Incident{
+		ID: 1, StartDate: &incStart, EndDate: &incEnd, Impact: &outage, Type: event.TypeIncident,
+		ComponentImpacts: []db.IncidentComponent{
+			{IncidentID: 1, ComponentID: 150, Impact: &minor},
+			{IncidentID: 1, ComponentID: 151},
+		},
+	}
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	params, err := parseAvailabilityQuery(&AvailabilityQuery{From: &from, To: &to, Granularity: GranularityDay}, to)
+	require.NoError(t, err)
+
+	for compID, expected := range map[uint][]float64{150: {100, 100, 100}, 151: {100, 50, 100}} {
+		result, err := calculateAvailability(&db.Component{ID: compID, Incidents: []*db.Incident{inc}}, params)
+		require.NoError(t, err)
+		require.Len(t, result, len(expected))
+		for i, r := range result {
+			assert.InDelta(t, expected[i], r.Percentage, 0.0001, "component %d", compID)
+		}
+	}
+}
+
+func TestParseAvailabilityQuery(t *testing.T) {
+	now := time.Date(2025, 5, 14, 10, 0, 0, 0, time.UTC)
+
+	params, err := parseAvailabilityQuery(&AvailabilityQuery{Granularity: GranularityQuarter}, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), params.from)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), params.to)
+
+	params, err = parseAvailabilityQuery(&AvailabilityQuery{Granularity: GranularityWeek}, now)
+	require.NoError(t, err)
+	// Monday of the current week is the start of the last period
+	assert.Equal(t, time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC), params.to)
+	assert.Equal(t, time.Monday, params.from.Weekday())
+
+	_, err = parseAvailabilityQuery(&AvailabilityQuery{From: &now, To: &now}, now)
+	require.ErrorIs(t, err, errors.ErrAvailabilityQueryInvalidPeriod)
+
+	from := now.AddDate(-5, 0, 0)
+	_, err = parseAvailabilityQuery(&AvailabilityQuery{From: &from, Granularity: GranularityDay}, now)
+	require.ErrorIs(t, err, errors.ErrAvailabilityQueryTooManyPeriods)
+}
+
 func TestValidateStatusesPatches(t *testing.T) {
 	// Create test incidents for different types
 	infoEvent := &db.Incident{
@@ -1045,6 +1231,7 @@ func TestModifyEventUpdate(t *testing.T) {
 	m.ExpectQuery(`^SELECT \* FROM "incident_status" WHERE id = \$1 AND incident_id = \$2`).
 		WithArgs(status.ID, status.IncidentID).
 		WillReturnRows(returningRows)
+	prepareMockForEventModifiedAt(m, status.IncidentID)
 
 	status.Text = updatedText
 
@@ -1058,3 +1245,238 @@ func TestModifyEventUpdate(t *testing.T) {
 	require.Equal(t, modifiedAt.Truncate(time.Microsecond), updated.ModifiedAt.Truncate(time.Microsecond))
 	require.True(t, updated.ModifiedAt.After(*updated.CreatedAt) || updated.ModifiedAt.Equal(*updated.CreatedAt))
 }
+
+func TestValidateMaintenanceSubmission(t *testing.T) {
+	startDate := time.Now().UTC().Add(time.Hour * 24)
+	endDate := startDate.Add(time.Hour * 2)
+	pastDate := time.Now().UTC().Add(-time.Hour)
+
+	validMaintenance := func() IncidentData {
+		return IncidentData{
+			Title:        "maintenance",
+			Description:  "description",
+			Type:         event.TypeMaintenance,
+			StartDate:    startDate,
+			EndDate:      &endDate,
+			ContactEmail: "owner@example.com",
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		modify      func(data *IncidentData)
+		role        auth.Role
+		expectedErr error
+	}{
+		{
+			name:   "Valid maintenance from sd_creators",
+			modify: func(_ *IncidentData) {},
+			role:   auth.RoleCreator,
+		},
+		{
+			name:        "Missing description",
+			modify:      func(data *IncidentData) { data.Description = "" },
+			role:        auth.RoleCreator,
+			expectedErr: errors.ErrMaintenanceDescriptionEmpty,
+		},
+		{
+			name:        "Missing contact email",
+			modify:      func(data *IncidentData) { data.ContactEmail = "" },
+			role:        auth.RoleCreator,
+			expectedErr: errors.ErrMaintenanceContactEmailEmpty,
+		},
+		{
+			name:        "Invalid contact email",
+			modify:      func(data *IncidentData) { data.ContactEmail = "not-an-email" },
+			role:        auth.RoleOperator,
+			expectedErr: errors.ErrMaintenanceContactEmailInvalid,
+		},
+		{
+			name:        "Start date in the past",
+			modify:      func(data *IncidentData) { data.StartDate = pastDate },
+			role:        auth.RoleCreator,
+			expectedErr: errors.ErrMaintenanceStartDateInPast,
+		},
+		{
+			name:        "End date before start date",
+			modify:      func(data *IncidentData) { data.EndDate = &pastDate },
+			role:        auth.RoleCreator,
+			expectedErr: errors.ErrMaintenanceEndDateBeforeStart,
+		},
+		{
+			name: "Operators are not restricted",
+			modify: func(data *IncidentData) {
+				data.Description = ""
+				data.ContactEmail = ""
+				data.StartDate = pastDate
+			},
+			role: auth.RoleOperator,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := validMaintenance()
+			tc.modify(&data)
+			err := validateMaintenanceSubmission(data, tc.role)
+			if tc.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestCheckPatchPermissions(t *testing.T) {
+	creator := "creator-id"
+	pendingMaintenance := &db.Incident{
+		Type:    event.TypeMaintenance,
+		Status:  event.MaintenancePendingReview,
+		Creator: &creator,
+	}
+	reviewedMaintenance := &db.Incident{
+		Type:    event.TypeMaintenance,
+		Status:  event.MaintenanceReviewed,
+		Creator: &creator,
+	}
+	plannedMaintenance := &db.Incident{
+		Type:   event.TypeMaintenance,
+		Status: event.MaintenancePlanned,
+	}
+
+	testCases := []struct {
+		name        string
+		role        auth.Role
+		user        string
+		incoming    *PatchIncidentData
+		stored      *db.Incident
+		expectedErr error
+	}{
+		{
+			name:     "Creator modifies own pending maintenance",
+			role:     auth.RoleCreator,
+			user:     creator,
+			incoming: &PatchIncidentData{Status: event.MaintenancePendingReview},
+			stored:   pendingMaintenance,
+		},
+		{
+			name:     "Creator cancels own pending maintenance",
+			role:     auth.RoleCreator,
+			user:     creator,
+			incoming: &PatchIncidentData{Status: event.MaintenanceCancelled},
+			stored:   pendingMaintenance,
+		},
+		{
+			name:        "Creator modifies another user's maintenance",
+			role:        auth.RoleCreator,
+			user:        "another-user",
+			incoming:    &PatchIncidentData{Status: event.MaintenancePendingReview},
+			stored:      pendingMaintenance,
+			expectedErr: errors.ErrMaintenancePatchForbidden,
+		},
+		{
+			name:        "Creator cancels reviewed maintenance",
+			role:        auth.RoleCreator,
+			user:        creator,
+			incoming:    &PatchIncidentData{Status: event.MaintenanceCancelled},
+			stored:      reviewedMaintenance,
+			expectedErr: errors.ErrMaintenancePatchForbidden,
+		},
+		{
+			name:        "Operator sets reviewed status manually",
+			role:        auth.RoleOperator,
+			incoming:    &PatchIncidentData{Status: event.MaintenanceReviewed},
+			stored:      pendingMaintenance,
+			expectedErr: errors.ErrMaintenanceReviewStatusForbidden,
+		},
+		{
+			name:        "Operator sets pending review status for planned maintenance",
+			role:        auth.RoleOperator,
+			incoming:    &PatchIncidentData{Status: event.MaintenancePendingReview},
+			stored:      plannedMaintenance,
+			expectedErr: errors.ErrMaintenanceReviewStatusForbidden,
+		},
+		{
+			name:     "Operator cancels pending maintenance",
+			role:     auth.RoleOperator,
+			incoming: &PatchIncidentData{Status: event.MaintenanceCancelled},
+			stored:   pendingMaintenance,
+		},
+		{
+			name:     "Admin sets reviewed status",
+			role:     auth.RoleAdmin,
+			incoming: &PatchIncidentData{Status: event.MaintenanceReviewed},
+			stored:   pendingMaintenance,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			auth.SetIdentity(c, tc.role, tc.user)
+			err := checkPatchPermissions(c, tc.incoming, tc.stored)
+			if tc.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestCheckComponentAttrs(t *testing.T) {
+	_, m, d := initTests(t)
+
+	attrs := []ComponentAttribute{
+		{Name: "type", Value: "ecs"},
+		{Name: "region", Value: "EU-DE"},
+		{Name: "category", Value: "Compute"},
+	}
+
+	m.ExpectQuery(`SELECT count\(\*\) FROM "region" WHERE name = \$1`).
+		WithArgs("EU-DE").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	require.NoError(t, checkComponentAttrs(d, attrs))
+
+	attrs[1].Value = "EU-UNKNOWN"
+	m.ExpectQuery(`SELECT count\(\*\) FROM "region" WHERE name = \$1`).
+		WithArgs("EU-UNKNOWN").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	require.ErrorIs(t, checkComponentAttrs(d, attrs), errors.ErrComponentRegionAttrMissing)
+
+	// the attributes format is checked before the region
+	require.ErrorIs(t, checkComponentAttrs(d, attrs[:2]), errors.ErrComponentAttrInvalidFormat)
+
+	require.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestCheckPatchComponentAttrs(t *testing.T) {
+	_, m, d := initTests(t)
+
+	require.NoError(t, checkPatchComponentAttrs(d, nil))
+	require.NoError(t, checkPatchComponentAttrs(d, []ComponentAttribute{{Name: "type", Value: "ecs"}}))
+
+	require.ErrorIs(t,
+		checkPatchComponentAttrs(d, []ComponentAttribute{{Name: "unknown", Value: "value"}}),
+		errors.ErrComponentAttrInvalidFormat,
+	)
+	require.ErrorIs(t,
+		checkPatchComponentAttrs(d, []ComponentAttribute{{Name: "category", Value: ""}}),
+		errors.ErrComponentAttrInvalidFormat,
+	)
+	require.ErrorIs(t,
+		checkPatchComponentAttrs(d, []ComponentAttribute{{Name: "type", Value: "a"}, {Name: "type", Value: "b"}}),
+		errors.ErrComponentAttrInvalidFormat,
+	)
+
+	m.ExpectQuery(`SELECT count\(\*\) FROM "region" WHERE name = \$1`).
+		WithArgs("EU-UNKNOWN").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	require.ErrorIs(t,
+		checkPatchComponentAttrs(d, []ComponentAttribute{{Name: "region", Value: "EU-UNKNOWN"}}),
+		errors.ErrComponentRegionAttrMissing,
+	)
+
+	require.NoError(t, m.ExpectationsWereMet())
+}
@@ -3,13 +3,19 @@ package v2
 import (
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
 )
 
-const maxComponentID = 2048
+const (
+	maxComponentID = 2048
+	// maxSearchLength limits the search query, the long queries are expensive for the full-text search.
+	maxSearchLength = 200
+	sortOrderDesc   = "desc"
+)
 
 // IsValidIncidentFilterStatus checks if the status is valid for maintenance or incidents.
 func IsValidIncidentFilterStatus(status event.Status) bool {
@@ -97,3 +103,66 @@ func parseAndSetTypes(queryTypes *string, params *db.IncidentsParams) error {
 
 	return nil
 }
+
+// validateAndSetSearch trims the search query and sets it on db.IncidentsParams, the empty query is ignored.
+func validateAndSetSearch(querySearch *string, params *db.IncidentsParams) error {
+	if querySearch == nil {
+		return nil
+	}
+
+	search := strings.TrimSpace(*querySearch)
+	if utf8.RuneCountInString(search) > maxSearchLength {
+		return apiErrors.ErrIncidentFQueryInvalidFormat
+	}
+
+	params.Search = search
+
+	return nil
+}
+
+// validateAndSetSort validates the sort field and the order and sets them on db.IncidentsParams.
+// The order without the sort field changes the order of the default sort.
+func validateAndSetSort(querySort, queryOrder *string, params *db.IncidentsParams) error {
+	if querySort == nil && queryOrder == nil {
+		return nil
+	}
+
+	sort := db.DefaultEventsSort()
+	if querySort != nil {
+		if !db.IsValidSortField(*querySort) {
+			return apiErrors.ErrIncidentFQueryInvalidFormat
+		}
+		sort.Field = *querySort
+	}
+
+	sort.Desc = queryOrder == nil || *queryOrder == sortOrderDesc
+	params.Sort = &sort
+
+	return nil
+}
+
+// validateAndSetCursor decodes the cursor and sets it on db.IncidentsParams instead of the page.
+// The cursor can't be used with the page and with another sort.
+func validateAndSetCursor(query *APIGetIncidentsQuery, params *db.IncidentsParams) error {
+	if query.Cursor == nil {
+		return nil
+	}
+
+	if query.Page != nil {
+		return apiErrors.ErrIncidentFQueryInvalidFormat
+	}
+
+	cursor, err := db.DecodeEventsCursor(*query.Cursor)
+	if err != nil {
+		return apiErrors.ErrIncidentFQueryInvalidFormat
+	}
+
+	if params.Sort != nil && *params.Sort != cursor.Sort {
+		return apiErrors.ErrIncidentFQueryInvalidFormat
+	}
+
+	params.After = cursor
+	params.Page = nil
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package v2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const (
+	webhookSecretLength         = 32
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 500
+)
+
+type WebhookID struct {
+	ID uint `uri:"id" binding:"required,gte=1"`
+}
+
+type WebhookData struct {
+	URL string `json:"url" binding:"required"`
+	// Secret is used to sign the payload, it's generated if it's empty.
+	Secret string `json:"secret,omitempty"`
+	// EventTypes is a list of event types, the empty list means all types.
+	EventTypes []string `json:"event_types,omitempty"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+type PatchWebhookData struct {
+	URL        *string   `json:"url,omitempty"`
+	Secret     *string   `json:"secret,omitempty"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+	Active     *bool     `json:"active,omitempty"`
+}
+
+type Webhook struct {
+	ID         uint       `json:"id"`
+	URL        string     `json:"url"`
+	EventTypes []string   `json:"event_types"`
+	Active     bool       `json:"active"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	ModifiedAt *time.Time `json:"modified_at,omitempty"`
+	// Secret is returned only once, after the webhook creation.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDeliveriesQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending delivered failed"`
+	Limit  int    `form:"limit" binding:"omitempty,gte=1"`
+}
+
+func toAPIWebhook(wh *db.Webhook) *Webhook {
+	types := wh.Types()
+	if types == nil {
+		types = []string{}
+	}
+
+	return &Webhook{
+		ID:         wh.ID,
+		URL:        wh.URL,
+		EventTypes: types,
+		Active:     wh.Active,
+		CreatedAt:  wh.CreatedAt,
+		ModifiedAt: wh.ModifiedAt,
+	}
+}
+
+func GetWebhooksHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve webhooks")
+
+		webhooks, err := dbInst.GetWebhooks()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		result := make([]*Webhook, len(webhooks))
+		for i, wh := range webhooks {
+			result[i] = toAPIWebhook(wh)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": result})
+	}
+}
+
+func GetWebhookHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Debug("retrieve webhook")
+
+		wh := getWebhook(c, dbInst)
+		if wh == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, toAPIWebhook(wh))
+	}
+}
+
+func PostWebhookHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var whData WebhookData
+		if err := c.ShouldBindBodyWithJSON(&whData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if err := validateWebhookURL(whData.URL); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if err := validateWebhookEventTypes(whData.EventTypes); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if whData.Secret == "" {
+			secret, err := generateWebhookSecret()
+			if err != nil {
+				apiErrors.RaiseInternalErr(c, err)
+				return
+			}
+			whData.Secret = secret
+		}
+
+		active := true
+		if whData.Active != nil {
+			active = *whData.Active
+		}
+
+		wh := &db.Webhook{
+			URL:        whData.URL,
+			Secret:     whData.Secret,
+			EventTypes: strings.Join(whData.EventTypes, ","),
+			Active:     active,
+		}
+
+		if _, err := dbInst.SaveWebhook(wh); err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the webhook is created", zap.Uint("webhookID", wh.ID), zap.String("url", wh.URL))
+
+		resp := toAPIWebhook(wh)
+		resp.Secret = wh.Secret
+		c.JSON(http.StatusCreated, resp)
+	}
+}
+
+func PatchWebhookHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wh := getWebhook(c, dbInst)
+		if wh == nil {
+			return
+		}
+
+		var whData PatchWebhookData
+		if err := c.ShouldBindBodyWithJSON(&whData); err != nil {
+			apiErrors.RaiseBadRequestErr(c, err)
+			return
+		}
+
+		if whData.URL != nil {
+			if err := validateWebhookURL(*whData.URL); err != nil {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
+			wh.URL = *whData.URL
+		}
+
+		if whData.EventTypes != nil {
+			if err := validateWebhookEventTypes(*whData.EventTypes); err != nil {
+				apiErrors.RaiseBadRequestErr(c, err)
+				return
+			}
+			wh.EventTypes = strings.Join(*whData.EventTypes, ",")
+		}
+
+		if whData.Secret != nil && *whData.Secret != "" {
+			wh.Secret = *whData.Secret
+		}
+
+		if whData.Active != nil {
+			wh.Active = *whData.Active
+		}
+
+		if err := dbInst.ModifyWebhook(wh); err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the webhook is updated", zap.Uint("webhookID", wh.ID))
+		c.JSON(http.StatusOK, toAPIWebhook(wh))
+	}
+}
+
+func DeleteWebhookHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var whID WebhookID
+		if err := c.ShouldBindUri(&whID); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrWebhookInvalidID)
+			return
+		}
+
+		if err := dbInst.DeleteWebhook(whID.ID); err != nil {
+			if errors.Is(err, db.ErrDBWebhookDSNotExist) {
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrWebhookDSNotExist)
+				return
+			}
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		logger.Info("the webhook is deleted", zap.Uint("webhookID", whID.ID))
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetWebhookDeliveriesHandler returns the delivery log for the webhook.
+func GetWebhookDeliveriesHandler(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wh := getWebhook(c, dbInst)
+		if wh == nil {
+			return
+		}
+
+		var query WebhookDeliveriesQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrWebhookDeliveryInvalidStatus)
+			return
+		}
+
+		limit := defaultWebhookDeliveryLimit
+		if query.Limit > 0 {
+			limit = min(query.Limit, maxWebhookDeliveryLimit)
+		}
+
+		logger.Debug("retrieve webhook deliveries", zap.Uint("webhookID", wh.ID), zap.Any("query", query))
+		deliveries, err := dbInst.GetWebhookDeliveries(wh.ID, &db.WebhookDeliveriesParams{
+			Status: query.Status,
+			Limit:  limit,
+		})
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": deliveries})
+	}
+}
+
+func getWebhook(c *gin.Context, dbInst *db.DB) *db.Webhook {
+	var whID WebhookID
+	if err := c.ShouldBindUri(&whID); err != nil {
+		apiErrors.RaiseBadRequestErr(c, apiErrors.ErrWebhookInvalidID)
+		return nil
+	}
+
+	wh, err := dbInst.GetWebhook(whID.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrDBWebhookDSNotExist) {
+			apiErrors.RaiseStatusNotFoundErr(c, apiErrors.ErrWebhookDSNotExist)
+			return nil
+		}
+		apiErrors.RaiseInternalErr(c, err)
+		return nil
+	}
+
+	return wh
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apiErrors.ErrWebhookInvalidURL
+	}
+
+	return nil
+}
+
+func validateWebhookEventTypes(types []string) error {
+	for _, t := range types {
+		if t != event.TypeIncident && t != event.TypeMaintenance && t != event.TypeInformation {
+			return apiErrors.ErrWebhookInvalidEventType
+		}
+	}
+
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
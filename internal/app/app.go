@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/stackmon/otc-status-dashboard/internal/api"
 	"github.com/stackmon/otc-status-dashboard/internal/conf"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
 )
 
 const (
@@ -28,6 +30,8 @@ type App struct {
 	DB *db.DB
 	// http server
 	srv *http.Server
+	// http server of the metrics, it listens on the separate port
+	metricsSrv *http.Server
 }
 
 func New(c *conf.Config, log *zap.Logger) (*App, error) {
@@ -35,6 +39,9 @@ func New(c *conf.Config, log *zap.Logger) (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = metrics.InstrumentDB(dbNew); err != nil {
+		return nil, err
+	}
 
 	apiNew, err := api.New(c, log, dbNew)
 	if err != nil {
@@ -47,7 +54,13 @@ func New(c *conf.Config, log *zap.Logger) (*App, error) {
 		ReadHeaderTimeout: readHeaderTimeout,
 	}
 
-	a := &App{api: apiNew, Log: log, conf: c, DB: dbNew, srv: s}
+	ms := &http.Server{
+		Addr:              fmt.Sprintf(":%s", c.MetricsPort),
+		Handler:           apiNew.MetricsHandler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	a := &App{api: apiNew, Log: log, conf: c, DB: dbNew, srv: s, metricsSrv: ms}
 
 	return a, nil
 }
@@ -56,7 +69,14 @@ func (a *App) Run() error {
 	return a.srv.ListenAndServe()
 }
 
+// RunMetrics serves the metrics, the port isn't exposed to the public as the API port.
+func (a *App) RunMetrics() error {
+	return a.metricsSrv.ListenAndServe()
+}
+
 func (a *App) Shutdown(ctx context.Context) error {
 	// TODO: add a proper shutdown for a database
-	return a.srv.Shutdown(ctx)
+	// the broker closes the streams, otherwise the server waits for them
+	a.api.Shutdown()
+	return errors.Join(a.srv.Shutdown(ctx), a.metricsSrv.Shutdown(ctx))
 }
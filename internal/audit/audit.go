@@ -0,0 +1,203 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+// State is the event fields, which are tracked by the audit log.
+// The status updates are stored by the keys "updates[i]", so only the changed and added updates are in the diff.
+type State map[string]any
+
+// Change is the value of the field before and after the change, the missing value is null.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type update struct {
+	Status    string    `json:"status"`
+	Text      string    `json:"text"`
+	Author    string    `json:"author,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Snapshot returns the tracked fields of the event.
+// The values are converted through JSON, so the snapshot is equal to the stored one.
+func Snapshot(inc *db.Incident) (State, error) {
+	components := make([]uint, len(inc.Components))
+	for i, comp := range inc.Components {
+		components[i] = comp.ID
+	}
+	slices.Sort(components)
+
+	fields := map[string]any{
+		"title":         inc.Text,
+		"description":   inc.Description,
+		"type":          inc.Type,
+		"impact":        inc.Impact,
+		"status":        inc.Status,
+		"system":        inc.System,
+		"start_date":    inc.StartDate,
+		"end_date":      inc.EndDate,
+		"components":    components,
+		"contact_email": inc.ContactEmail,
+	}
+
+	for i, st := range inc.Statuses {
+		upd := update{Status: string(st.Status), Text: st.Text, Timestamp: st.Timestamp}
+		if st.Author != nil {
+			upd.Author = *st.Author
+		}
+		fields[fmt.Sprintf("updates[%d]", i)] = upd
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseState(string(data))
+}
+
+// Diff returns the changed fields, all fields of the state are changed if the previous state is nil.
+func Diff(before, after State) map[string]Change {
+	changes := make(map[string]Change)
+	for key, value := range after {
+		if prev, ok := before[key]; !ok || !reflect.DeepEqual(prev, value) {
+			changes[key] = Change{Before: before[key], After: value}
+		}
+	}
+
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			changes[key] = Change{Before: value}
+		}
+	}
+
+	return changes
+}
+
+// Tracker records the changes of the events to the audit log in the transaction of the write.
+type Tracker struct {
+	tx     *db.DB
+	before map[uint]State
+}
+
+// Track runs the write and the audit records in one transaction. The write captures the states of the events
+// by Capture before it changes them and records the changes by Record after it, so the records have only
+// the changes made by the write.
+func Track(dbInst *db.DB, write func(t *Tracker) error) error {
+	return dbInst.Transaction(func(tx *db.DB) error {
+		return write(&Tracker{tx: tx, before: make(map[uint]State)})
+	})
+}
+
+// TrackEvent runs the write of the event and records its change in one transaction.
+func TrackEvent(dbInst *db.DB, action string, eventID uint, write func(tx *db.DB) error) error {
+	return Track(dbInst, func(t *Tracker) error {
+		if err := t.Capture(eventID); err != nil {
+			return err
+		}
+		if err := write(t.DB()); err != nil {
+			return err
+		}
+
+		return t.Record(action, eventID)
+	})
+}
+
+// DB returns the database instance of the transaction, the writes should use it.
+func (t *Tracker) DB() *db.DB {
+	return t.tx
+}
+
+// Capture locks the events till the end of the transaction and keeps their states before the write,
+// so the concurrent writes of the events wait for the records of this write.
+func (t *Tracker) Capture(eventIDs ...uint) error {
+	if err := t.tx.LockIncidents(eventIDs...); err != nil {
+		return err
+	}
+
+	for _, id := range eventIDs {
+		inc, err := t.tx.GetIncident(int(id))
+		if err != nil {
+			return err
+		}
+		if t.before[id], err = Snapshot(inc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Record stores the changes of the events by the actor of the database instance.
+// The event without the captured state is recorded with all fields, as example, the created event.
+// The record of the captured event is skipped if nothing is changed by the write.
+func (t *Tracker) Record(action string, eventIDs ...uint) error {
+	for _, id := range eventIDs {
+		if err := t.record(action, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Tracker) record(action string, eventID uint) error {
+	inc, err := t.tx.GetIncident(int(eventID))
+	if err != nil {
+		return err
+	}
+
+	after, err := Snapshot(inc)
+	if err != nil {
+		return err
+	}
+
+	before, captured := t.before[eventID]
+	changes := Diff(before, after)
+	if captured && len(changes) == 0 {
+		return nil
+	}
+
+	changesData, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	stateData, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.tx.SaveAuditRecord(&db.AuditRecord{
+		IncidentID: eventID,
+		Action:     action,
+		Actor:      t.tx.Actor(),
+		Changes:    string(changesData),
+		State:      string(stateData),
+	})
+	if err != nil {
+		return err
+	}
+
+	// the next record of the event in the transaction has only its own changes
+	t.before[eventID] = after
+
+	return nil
+}
+
+func parseState(data string) (State, error) {
+	var state State
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestSnapshot(t *testing.T) {
+	title := "incident"
+	impact := 2
+	author := "user"
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	inc := &db.Incident{
+		Text:       &title,
+		Impact:     &impact,
+		StartDate:  &start,
+		Type:       event.TypeIncident,
+		Status:     event.IncidentDetected,
+		Components: []db.Component{{ID: 3}, {ID: 1}},
+		Statuses: []db.IncidentStatus{
+			{Status: event.IncidentDetected, Text: "detected", Timestamp: start, Author: &author},
+		},
+	}
+
+	state, err := Snapshot(inc)
+	require.NoError(t, err)
+	assert.Equal(t, "incident", state["title"])
+	assert.InDelta(t, 2, state["impact"], 0)
+	assert.Equal(t, []any{float64(1), float64(3)}, state["components"])
+	assert.Nil(t, state["end_date"])
+	assert.Equal(t, map[string]any{
+		"status": "detected", "text": "detected", "author": "user", "timestamp": "2025-01-01T10:00:00Z",
+	}, state["updates[0]"])
+}
+
+func TestDiff(t *testing.T) {
+	before := State{"title": "old", "impact": float64(1), "updates[0]": "a"}
+	after := State{"title": "new", "impact": float64(1), "updates[0]": "a", "updates[1]": "b"}
+
+	changes := Diff(before, after)
+	assert.Equal(t, map[string]Change{
+		"title":      {Before: "old", After: "new"},
+		"updates[1]": {After: "b"},
+	}, changes)
+
+	assert.Equal(t, map[string]Change{"title": {Before: "new"}}, Diff(State{"title": "new"}, State{}))
+	assert.Empty(t, Diff(after, after))
+	assert.Len(t, Diff(nil, after), len(after))
+}
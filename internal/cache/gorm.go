@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	gormPluginName  = "cache"
+	callbackAfter   = "cache:invalidate_"
+	callbackPattern = "*"
+)
+
+// GormPlugin invalidates the cache after the writes to the tables with the public data.
+// The writes in the transaction with the context by DeferInvalidation invalidate the cache after the commit.
+type GormPlugin struct {
+	Cache Cache
+}
+
+//nolint:gochecknoglobals
+var invalidatingTables = map[string]struct{}{
+	"component":                             {},
+	"component_attribute":                   {},
+	"incident":                              {},
+	"incident_status":                       {},
+	"incident_component_relation":           {},
+	"maintenance_series":                    {},
+	"maintenance_series_component_relation": {},
+	"region":                                {},
+}
+
+func (p *GormPlugin) Name() string {
+	return gormPluginName
+}
+
+func (p *GormPlugin) Initialize(g *gorm.DB) error {
+	cb := g.Callback()
+
+	return errors.Join(
+		cb.Create().After(callbackPattern).Register(callbackAfter+"create", p.invalidate),
+		cb.Update().After(callbackPattern).Register(callbackAfter+"update", p.invalidate),
+		cb.Delete().After(callbackPattern).Register(callbackAfter+"delete", p.invalidate),
+	)
+}
+
+func (p *GormPlugin) invalidate(g *gorm.DB) {
+	if g.Error != nil || g.Statement == nil {
+		return
+	}
+
+	if _, ok := invalidatingTables[g.Statement.Table]; !ok {
+		return
+	}
+
+	if d, ok := g.Statement.Context.Value(deferredKey{}).(*Deferred); ok {
+		d.pending = true
+		return
+	}
+
+	if err := p.Cache.Invalidate(g.Statement.Context); err != nil {
+		zap.L().Error("failed to invalidate the cache", zap.String("table", g.Statement.Table), zap.Error(err))
+	}
+}
+
+type deferredKey struct{}
+
+// Deferred is the invalidation by the writes in the transaction, it's applied after the commit,
+// otherwise the concurrent reads can cache the data, which is replaced by the transaction.
+type Deferred struct {
+	pending bool
+}
+
+// DeferInvalidation returns the context of the transaction, the writes with the context don't invalidate the cache
+// until Flush is called after the commit. The nested transaction gets the nil Deferred, it's flushed by the outer one.
+func DeferInvalidation(ctx context.Context) (context.Context, *Deferred) {
+	if _, ok := ctx.Value(deferredKey{}).(*Deferred); ok {
+		return ctx, nil
+	}
+
+	d := &Deferred{}
+	return context.WithValue(ctx, deferredKey{}, d), d
+}
+
+// Flush invalidates the cache if the public data is changed in the transaction.
+func (d *Deferred) Flush(ctx context.Context, c Cache) {
+	if d == nil || !d.pending {
+		return
+	}
+	d.pending = false
+
+	if err := c.Invalidate(ctx); err != nil {
+		zap.L().Error("failed to invalidate the cache after the commit", zap.Error(err))
+	}
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is the in-memory LRU cache, the least recently used entry is removed when the cache is full.
+type Memory struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List
+	now   func() time.Time
+	// generation is increased on the invalidation.
+	generation int64
+}
+
+type memoryItem struct {
+	key     string
+	entry   *Entry
+	expires time.Time
+}
+
+func NewMemory(size int, ttl time.Duration) *Memory {
+	return &Memory{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) (*Entry, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, m.generation, ErrNotFound
+	}
+
+	item := el.Value.(*memoryItem) //nolint:errcheck
+	if !m.now().Before(item.expires) {
+		m.remove(el)
+		return nil, m.generation, ErrNotFound
+	}
+
+	m.order.MoveToFront(el)
+
+	return item.entry, m.generation, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, generation int64, entry *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if generation != m.generation {
+		return nil
+	}
+
+	expires := m.now().Add(m.ttl)
+	if el, ok := m.items[key]; ok {
+		item := el.Value.(*memoryItem) //nolint:errcheck
+		item.entry = entry
+		item.expires = expires
+		m.order.MoveToFront(el)
+		return nil
+	}
+
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, entry: entry, expires: expires})
+	for m.order.Len() > m.size {
+		m.remove(m.order.Back())
+	}
+
+	return nil
+}
+
+func (m *Memory) Invalidate(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items = make(map[string]*list.Element, m.size)
+	m.order.Init()
+	m.generation++
+
+	return nil
+}
+
+// Len returns the number of stored entries, including the expired ones.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+func (m *Memory) remove(el *list.Element) {
+	item := m.order.Remove(el).(*memoryItem) //nolint:errcheck
+	delete(m.items, item.key)
+}
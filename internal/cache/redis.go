@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisPrefix is the prefix of the keys of the cached entries, the key includes the generation.
+	redisPrefix = "sd:cache:"
+	// redisGenerationKey is the generation of the cache, it's increased on the invalidation.
+	// The instances of the app share the generation, so a write on one instance invalidates the cache of all of them,
+	// the entries of the previous generations are removed by Redis when their TTL is expired.
+	redisGenerationKey = "sd:cache-generation"
+)
+
+// redisSetScript stores the entry only if the generation isn't increased since it's read by Get.
+// KEYS are the generation key and the entry key, ARGV are the generation, the entry and the TTL in milliseconds.
+const redisSetScript = `
+if tonumber(redis.call("GET", KEYS[1]) or "0") ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+return 1
+`
+
+// Redis stores every entry in its own key with the TTL.
+type Redis struct {
+	client *redis.Client
+	ttl    time.Duration
+	set    *redis.Script
+}
+
+func NewRedis(uri string, ttl time.Duration) (*Redis, error) {
+	opts, err := redis.ParseURL(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis uri: %w", err)
+	}
+
+	return &Redis{client: redis.NewClient(opts), ttl: ttl, set: redis.NewScript(redisSetScript)}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) (*Entry, int64, error) {
+	generation, err := r.client.Get(ctx, redisGenerationKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, err
+	}
+
+	data, err := r.client.Get(ctx, entryKey(generation, key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, generation, ErrNotFound
+	}
+	if err != nil {
+		return nil, generation, err
+	}
+
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil, generation, err
+	}
+
+	return &entry, generation, nil
+}
+
+// Set stores the entry by the script, so the generation is checked and the entry is stored atomically.
+func (r *Redis) Set(ctx context.Context, key string, generation int64, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{redisGenerationKey, entryKey(generation, key)}
+	return r.set.Run(ctx, r.client, keys, generation, data, r.ttl.Milliseconds()).Err()
+}
+
+func (r *Redis) Invalidate(ctx context.Context) error {
+	return r.client.Incr(ctx, redisGenerationKey).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// entryKey returns the key of the entry in the generation.
+func entryKey(generation int64, key string) string {
+	return fmt.Sprintf("%s%d:%s", redisPrefix, generation, key)
+}
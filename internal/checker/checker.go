@@ -1,20 +1,34 @@
 package checker
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/stackmon/otc-status-dashboard/internal/audit"
 	"github.com/stackmon/otc-status-dashboard/internal/conf"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/email"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
+	"github.com/stackmon/otc-status-dashboard/internal/stream"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
 )
 
-const defaultPeriod = time.Minute * 2
+const (
+	defaultPeriod = time.Minute * 2
+	// defaultSeriesHorizon is the period ahead, for which the occurrences of the recurring maintenances are created.
+	defaultSeriesHorizon = time.Hour * 24 * 30
+	// actor is the name of the checker in the audit log.
+	actor = "system:checker"
+)
 
 type Checker struct {
-	db  *db.DB
-	log *zap.Logger
+	db            *db.DB
+	log           *zap.Logger
+	seriesHorizon time.Duration
 	// lastIDs are the earliest planned or in progress maintenance/info events ID.
 	lastMntID  uint
 	lastInfoID uint
@@ -25,7 +39,18 @@ func New(c *conf.Config, log *zap.Logger) (*Checker, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Checker{db: dbNew, log: log}, nil
+	if err = metrics.InstrumentDB(dbNew); err != nil {
+		return nil, err
+	}
+
+	horizon := defaultSeriesHorizon
+	if c.MaintenanceHorizon != "" {
+		if horizon, err = time.ParseDuration(c.MaintenanceHorizon); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Checker{db: dbNew.WithActor(context.Background(), actor), log: log, seriesHorizon: horizon}, nil
 }
 
 func (ch *Checker) Check() {
@@ -33,6 +58,10 @@ func (ch *Checker) Check() {
 
 	wg.Add(1)
 	go func() {
+		// the new occurrences of the recurring maintenances are checked in the same run
+		if err := ch.CheckMaintenanceSeries(); err != nil {
+			ch.log.Error("error to check maintenance series", zap.Error(err))
+		}
 		err := ch.CheckMaintenance()
 		if err != nil {
 			ch.log.Error("error to check maintenances", zap.Error(err))
@@ -52,6 +81,44 @@ func (ch *Checker) Check() {
 	wg.Wait()
 }
 
+// CheckOnce runs a single pass of the checks one by one, it's used by the admin CLI.
+func (ch *Checker) CheckOnce() error {
+	return errors.Join(ch.CheckMaintenanceSeries(), ch.CheckMaintenance(), ch.CheckInfoEvents())
+}
+
+// Close closes the database connection of the checker, which is not started by Run.
+func (ch *Checker) Close() error {
+	return ch.db.Close()
+}
+
+// modifyEvent saves the event checked by the checker, the change is recorded to the audit log
+// in the same transaction, if the event is changed.
+func (ch *Checker) modifyEvent(inc *db.Incident, changed bool) error {
+	if !changed {
+		return ch.db.ModifyIncident(inc)
+	}
+
+	return audit.TrackEvent(ch.db, string(webhook.ActionUpdated), inc.ID, func(tx *db.DB) error {
+		return tx.ModifyIncident(inc)
+	})
+}
+
+// notifyEventChanged enqueues webhook deliveries, email and stream messages for the event,
+// which was created or changed by the checker.
+func (ch *Checker) notifyEventChanged(action webhook.Action, eventID uint) {
+	if err := webhook.Enqueue(ch.db, action, eventID); err != nil {
+		ch.log.Error("failed to enqueue webhook deliveries", zap.Error(err), zap.Uint("eventID", eventID))
+	}
+
+	if err := email.Enqueue(ch.db, eventID); err != nil {
+		ch.log.Error("failed to enqueue email messages", zap.Error(err), zap.Uint("eventID", eventID))
+	}
+
+	if err := stream.Publish(ch.db, action, eventID); err != nil {
+		ch.log.Error("failed to publish the stream message", zap.Error(err), zap.Uint("eventID", eventID))
+	}
+}
+
 func (ch *Checker) Run(done chan struct{}) {
 	ch.log.Info("checker is started")
 	ticker := time.NewTicker(defaultPeriod)
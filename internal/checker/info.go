@@ -9,6 +9,8 @@ import (
 
 	"github.com/stackmon/otc-status-dashboard/internal/db"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
 )
 
 type InfoStatusHistory struct {
@@ -46,6 +48,14 @@ func (st *InfoStatusHistory) setStatus(status event.Status) {
 }
 
 func (ch *Checker) CheckInfoEvents() error {
+	start := time.Now()
+	transitions, err := ch.checkInfoEvents()
+	metrics.ObserveCheckerRun(metrics.CheckerInfo, start, transitions, err)
+	return err
+}
+
+// checkInfoEvents updates the info events statuses and returns the number of the status transitions.
+func (ch *Checker) checkInfoEvents() (int, error) {
 	ch.log.Info("check info event statuses")
 	if ch.lastInfoID == 0 {
 		ch.log.Info("no last completed info event, starting from the beginning")
@@ -53,13 +63,15 @@ func (ch *Checker) CheckInfoEvents() error {
 
 	infos, err := ch.db.GetInfoEvents(ch.lastInfoID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	var transitions int
 	var activeInfoEvents []uint
 	for _, info := range infos {
 		sHistory := calculateInfoStatusHistory(info)
 		actualStatus := calculateCurrentInfoStatus(sHistory, info)
+		statusesCount := len(info.Statuses)
 
 		switch actualStatus { //nolint:exhaustive
 		case event.InfoPlanned:
@@ -74,9 +86,14 @@ func (ch *Checker) CheckInfoEvents() error {
 			ch.fixInfoMissedStatuses(event.InfoCancelled, sHistory, info)
 		}
 
-		err = ch.db.ModifyIncident(info)
+		err = ch.modifyEvent(info, len(info.Statuses) != statusesCount)
 		if err != nil {
-			return err
+			return transitions, err
+		}
+
+		if len(info.Statuses) != statusesCount {
+			ch.notifyEventChanged(webhook.ActionUpdated, info.ID)
+			transitions++
 		}
 	}
 
@@ -100,7 +117,7 @@ func (ch *Checker) CheckInfoEvents() error {
 
 	ch.log.Info("finished checking info events")
 
-	return nil
+	return transitions, nil
 }
 
 func calculateInfoStatusHistory(mn *db.Incident) *InfoStatusHistory {
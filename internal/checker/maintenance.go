@@ -9,13 +9,16 @@ import (
 
 	"github.com/stackmon/otc-status-dashboard/internal/db"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
 )
 
 type MntStatusHistory struct {
-	hasPlanned    bool
-	hasInProgress bool
-	hasCompleted  bool
-	hasCancelled  bool
+	hasPendingReview bool
+	hasPlanned       bool
+	hasInProgress    bool
+	hasCompleted     bool
+	hasCancelled     bool
 }
 
 func (st *MntStatusHistory) hasStatus(status event.Status) bool {
@@ -46,6 +49,14 @@ func (st *MntStatusHistory) setStatus(status event.Status) {
 }
 
 func (ch *Checker) CheckMaintenance() error {
+	start := time.Now()
+	transitions, err := ch.checkMaintenance()
+	metrics.ObserveCheckerRun(metrics.CheckerMaintenance, start, transitions, err)
+	return err
+}
+
+// checkMaintenance updates the maintenances statuses and returns the number of the status transitions.
+func (ch *Checker) checkMaintenance() (int, error) {
 	ch.log.Info("check maintenances statuses")
 	if ch.lastMntID == 0 {
 		ch.log.Info("no last completed maintenance, starting from the beginning")
@@ -53,12 +64,25 @@ func (ch *Checker) CheckMaintenance() error {
 
 	maintenances, err := ch.db.GetMaintenances(ch.lastMntID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	var activeMaintenances []uint
+	var transitions int
+	// the maintenances waiting for the approval are not active, but they are checked again after the approval
+	var activeMaintenances, pendingMaintenances []uint
 	for _, mn := range maintenances {
 		sHistory := calculateMntStatusHistory(mn)
+		statusesCount := len(mn.Statuses)
+
+		if mn.Status == event.MaintenancePendingReview {
+			pendingMaintenances = append(pendingMaintenances, mn.ID)
+			continue
+		}
+
+		if mn.Status == event.MaintenanceReviewed {
+			ch.planReviewedMnt(sHistory, mn)
+		}
+
 		actualStatus := calculateCurrentMntStatus(sHistory, mn)
 
 		switch actualStatus { //nolint:exhaustive
@@ -74,14 +98,20 @@ func (ch *Checker) CheckMaintenance() error {
 			ch.fixMntMissedStatuses(event.MaintenanceCancelled, sHistory, mn)
 		}
 
+		previousStatus := mn.Status
 		mn.Status = actualStatus
-		err = ch.db.ModifyIncident(mn)
+		err = ch.modifyEvent(mn, previousStatus != actualStatus || len(mn.Statuses) != statusesCount)
 		if err != nil {
-			return err
+			return transitions, err
+		}
+
+		if previousStatus != actualStatus {
+			ch.notifyEventChanged(webhook.ActionUpdated, mn.ID)
+			transitions++
 		}
 	}
 
-	if len(activeMaintenances) == 0 {
+	if nextMaintenances := slices.Concat(activeMaintenances, pendingMaintenances); len(nextMaintenances) == 0 {
 		for _, mn := range maintenances {
 			if mn.ID > ch.lastMntID {
 				ch.lastMntID = mn.ID
@@ -92,21 +122,24 @@ func (ch *Checker) CheckMaintenance() error {
 			zap.Uint("lastMntID", ch.lastMntID),
 		)
 	} else {
-		ch.lastMntID = slices.Min(activeMaintenances)
+		ch.lastMntID = slices.Min(nextMaintenances)
 		ch.log.Debug(
-			"set the last ID to the earliest planned or in progress maintenance",
+			"set the last ID to the earliest planned, in progress or pending review maintenance",
 			zap.Uint("lastMntID", ch.lastMntID),
 		)
 	}
 
 	ch.log.Info("finished checking maintenances")
 
-	return nil
+	return transitions, nil
 }
 
 func calculateMntStatusHistory(mn *db.Incident) *MntStatusHistory {
 	sHistory := &MntStatusHistory{}
 	for _, st := range mn.Statuses {
+		if st.Status == event.MaintenancePendingReview {
+			sHistory.hasPendingReview = true
+		}
 		if st.Status == event.MaintenancePlanned {
 			sHistory.hasPlanned = true
 		}
@@ -183,7 +216,10 @@ func (ch *Checker) fixMntMissedStatuses(status event.Status, sHistory *MntStatus
 		statusTimestamp = *mnt.EndDate
 	case event.MaintenanceCancelled:
 		ch.log.Info("fixing the cancelled status for the maintenance", zap.Uint("mntID", mnt.ID))
-		ch.fixMntMissedStatuses(event.MaintenancePlanned, sHistory, mnt)
+		// the maintenance from the review workflow could be cancelled before it was planned
+		if !sHistory.hasPendingReview {
+			ch.fixMntMissedStatuses(event.MaintenancePlanned, sHistory, mnt)
+		}
 		ch.log.Info("the maintenance is already has cancelled status", zap.Uint("mntID", mnt.ID))
 		return
 	}
@@ -197,3 +233,20 @@ func (ch *Checker) fixMntMissedStatuses(status event.Status, sHistory *MntStatus
 	sHistory.setStatus(status)
 	ch.log.Info("the status was added", zap.String("status", string(status)), zap.Uint("mntID", mnt.ID))
 }
+
+// planReviewedMnt moves the approved maintenance to the planned status.
+// There is no validation here, the maintenance was validated before the review.
+func (ch *Checker) planReviewedMnt(sHistory *MntStatusHistory, mnt *db.Incident) {
+	if sHistory.hasPlanned {
+		return
+	}
+
+	ch.log.Info("the maintenance is reviewed, set the planned status", zap.Uint("mntID", mnt.ID))
+	mnt.Statuses = append(mnt.Statuses, db.IncidentStatus{
+		IncidentID: mnt.ID,
+		Status:     event.MaintenancePlanned,
+		Text:       event.MaintenancePlannedStatusText(),
+		Timestamp:  time.Now().UTC(),
+	})
+	sHistory.setStatus(event.MaintenancePlanned)
+}
@@ -0,0 +1,273 @@
+package checker
+
+import (
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/audit"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
+	"github.com/stackmon/otc-status-dashboard/internal/recurrence"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
+)
+
+const (
+	seriesModifiedText    = "The maintenance is changed with the recurring maintenance series."
+	seriesRescheduledText = "The maintenance is cancelled, the schedule of the recurring maintenance series is changed."
+	seriesCancelledText   = "The maintenance is cancelled with the recurring maintenance series."
+)
+
+func (ch *Checker) CheckMaintenanceSeries() error {
+	start := time.Now()
+	transitions, err := ch.checkMaintenanceSeries(start.UTC())
+	metrics.ObserveCheckerRun(metrics.CheckerMaintenanceSeries, start, transitions, err)
+	return err
+}
+
+// checkMaintenanceSeries creates, updates and cancels the upcoming occurrences of the recurring maintenances.
+// It returns the number of the changed occurrences.
+func (ch *Checker) checkMaintenanceSeries(now time.Time) (int, error) {
+	ch.log.Info("check maintenance series")
+
+	seriesList, err := ch.db.GetMaintenanceSeriesToCheck(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var transitions int
+	for _, series := range seriesList {
+		changed, errSeries := ch.checkSeries(series, now)
+		transitions += changed
+		if errSeries != nil {
+			return transitions, errSeries
+		}
+	}
+
+	ch.log.Info("finished checking maintenance series")
+
+	return transitions, nil
+}
+
+// checkSeries synchronises the upcoming occurrences with the series.
+// The occurrences, which are started, changed separately or cancelled, are not touched,
+// except the cancellation of the whole series, which cancels all planned occurrences.
+func (ch *Checker) checkSeries(series *db.MaintenanceSeries, now time.Time) (int, error) {
+	log := ch.log.With(zap.Uint("seriesID", series.ID))
+
+	occurrences, err := ch.db.GetSeriesOccurrences(series.ID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var slots []time.Time
+	if series.CancelledAt == nil {
+		rule, errRule := recurrence.Parse(series.RRule)
+		if errRule != nil {
+			// the rule is validated by the API, so it's not a reason to stop the other checks
+			log.Error("the recurrence rule of the series is invalid", zap.Error(errRule))
+			return 0, nil
+		}
+
+		// the occurrences created with the longer horizon are checked too
+		to := now.Add(ch.seriesHorizon)
+		if len(occurrences) > 0 {
+			to = laterOf(to, occurrences[len(occurrences)-1].SeriesOccurrence.Add(time.Second))
+		}
+		slots = rule.Between(series.StartDate, now.Add(time.Nanosecond), to)
+	}
+
+	var transitions int
+	existing := make(map[int64]bool, len(occurrences))
+	for _, occ := range occurrences {
+		existing[occ.SeriesOccurrence.Unix()] = true
+
+		// the modified occurrence is planned until the maintenance check corrects its status
+		planned := occ.Status == event.MaintenancePlanned || occ.Status == event.MaintenanceModified
+		if !planned || !occ.StartDate.After(now) {
+			continue
+		}
+		if occ.SeriesDetached && series.CancelledAt == nil {
+			continue
+		}
+
+		inSchedule := slices.ContainsFunc(slots, func(t time.Time) bool { return t.Equal(*occ.SeriesOccurrence) })
+		var write func(tx *db.DB) error
+		switch {
+		case series.CancelledAt != nil:
+			write = func(tx *db.DB) error { return ch.cancelOccurrence(tx, occ, seriesCancelledText, now) }
+		case !inSchedule:
+			write = func(tx *db.DB) error { return ch.cancelOccurrence(tx, occ, seriesRescheduledText, now) }
+		default:
+			fieldsChanged, componentsChanged := occurrenceChanges(series, occ)
+			if !fieldsChanged && !componentsChanged {
+				continue
+			}
+			write = func(tx *db.DB) error { return ch.syncOccurrence(tx, series, occ, componentsChanged, now) }
+		}
+
+		if err = audit.TrackEvent(ch.db, string(webhook.ActionUpdated), occ.ID, write); err != nil {
+			return transitions, err
+		}
+		ch.notifyEventChanged(webhook.ActionUpdated, occ.ID)
+		transitions++
+	}
+
+	horizon := now.Add(ch.seriesHorizon)
+	for _, slot := range slots {
+		if existing[slot.Unix()] || slot.After(horizon) {
+			continue
+		}
+
+		var occ *db.Incident
+		errCreate := audit.Track(ch.db, func(t *audit.Tracker) error {
+			var err error
+			if occ, err = createOccurrence(t.DB(), series, slot, now); err != nil {
+				return err
+			}
+
+			return t.Record(string(webhook.ActionCreated), occ.ID)
+		})
+		if errCreate != nil {
+			return transitions, errCreate
+		}
+		log.Info("the occurrence of the series is created", zap.Uint("mntID", occ.ID), zap.Time("start", slot))
+		ch.notifyEventChanged(webhook.ActionCreated, occ.ID)
+		transitions++
+	}
+
+	return transitions, nil
+}
+
+func createOccurrence(tx *db.DB, series *db.MaintenanceSeries, slot, now time.Time) (*db.Incident, error) {
+	title := series.Title
+	impact := 0
+	end := slot.Add(series.Duration())
+	occurrence := slot
+
+	inc := &db.Incident{
+		Text:             &title,
+		Description:      series.Description,
+		StartDate:        &occurrence,
+		EndDate:          &end,
+		Impact:           &impact,
+		Type:             event.TypeMaintenance,
+		Components:       seriesComponents(series),
+		Status:           event.MaintenancePlanned,
+		Creator:          series.Creator,
+		ContactEmail:     series.ContactEmail,
+		SeriesID:         &series.ID,
+		SeriesOccurrence: &occurrence,
+		Statuses: []db.IncidentStatus{{
+			Status:    event.MaintenancePlanned,
+			Text:      event.MaintenancePlannedStatusText(),
+			Timestamp: now,
+		}},
+	}
+
+	if _, err := tx.SaveIncident(inc); err != nil {
+		return nil, err
+	}
+
+	return inc, nil
+}
+
+// occurrenceChanges checks if the fields and the components of the occurrence differ from the series.
+func occurrenceChanges(series *db.MaintenanceSeries, occ *db.Incident) (bool, bool) {
+	end := occ.SeriesOccurrence.Add(series.Duration())
+
+	fieldsChanged := *occ.Text != series.Title ||
+		stringValue(occ.Description) != stringValue(series.Description) ||
+		stringValue(occ.ContactEmail) != stringValue(series.ContactEmail) ||
+		!occ.StartDate.Equal(*occ.SeriesOccurrence) ||
+		occ.EndDate == nil || !occ.EndDate.Equal(end)
+	componentsChanged := !slices.Equal(componentIDs(occ.Components), componentIDs(seriesComponents(series)))
+
+	return fieldsChanged, componentsChanged
+}
+
+// syncOccurrence applies the changes of the series to the occurrence.
+func (ch *Checker) syncOccurrence(
+	tx *db.DB, series *db.MaintenanceSeries, occ *db.Incident, componentsChanged bool, now time.Time,
+) error {
+	ch.log.Info("update the occurrence with the series", zap.Uint("seriesID", series.ID), zap.Uint("mntID", occ.ID))
+
+	if componentsChanged {
+		if err := tx.ReplaceEventComponents(occ, seriesComponents(series)); err != nil {
+			return err
+		}
+	}
+
+	end := occ.SeriesOccurrence.Add(series.Duration())
+
+	// the empty values are set instead of nil, because the nil fields are not updated
+	title := series.Title
+	description := stringValue(series.Description)
+	contactEmail := stringValue(series.ContactEmail)
+	start := *occ.SeriesOccurrence
+	occ.Text = &title
+	occ.Description = &description
+	occ.ContactEmail = &contactEmail
+	occ.StartDate = &start
+	occ.EndDate = &end
+	occ.Version++
+	occ.Statuses = append(occ.Statuses, db.IncidentStatus{
+		IncidentID: occ.ID,
+		Status:     event.MaintenanceModified,
+		Text:       seriesModifiedText,
+		Timestamp:  now,
+	})
+
+	return tx.ModifyIncident(occ)
+}
+
+func (ch *Checker) cancelOccurrence(tx *db.DB, occ *db.Incident, text string, now time.Time) error {
+	ch.log.Info("cancel the occurrence of the series", zap.Uint("mntID", occ.ID), zap.String("reason", text))
+
+	occ.Status = event.MaintenanceCancelled
+	occ.Version++
+	occ.Statuses = append(occ.Statuses, db.IncidentStatus{
+		IncidentID: occ.ID,
+		Status:     event.MaintenanceCancelled,
+		Text:       text,
+		Timestamp:  now,
+	})
+
+	return tx.ModifyIncident(occ)
+}
+
+func seriesComponents(series *db.MaintenanceSeries) []db.Component {
+	ids := series.ComponentIDs()
+	components := make([]db.Component, len(ids))
+	for i, id := range ids {
+		components[i] = db.Component{ID: id}
+	}
+
+	return components
+}
+
+func componentIDs(components []db.Component) []uint {
+	ids := make([]uint, len(components))
+	for i, comp := range components {
+		ids[i] = comp.ID
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
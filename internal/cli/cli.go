@@ -0,0 +1,193 @@
+// Package cli contains the admin commands of the status dashboard.
+// The commands work with the database directly, so the events can be managed
+// when the identity provider or the frontend is not available.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/checker"
+	"github.com/stackmon/otc-status-dashboard/internal/conf"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+const usage = `Usage: app <command> [subcommand] [flags]
+
+Without a command the server is started.
+
+Commands:
+  events list       list events
+  events show       show the event with the updates
+  events create     create an event
+  events update     add a status update to the event
+  events close      close the event
+  events move       move a component from one incident to another
+  events extract    extract components of the incident to a new incident
+  events merge      merge the incident into another incident
+  checker run       run a single pass of the events checker
+  migrate up        apply the database migrations
+  migrate down      revert the database migrations
+  migrate version   print the current migration version
+
+Run "app <command> <subcommand> -h" to see the flags of the subcommand.
+The configuration is read from the SD_* environment variables and the .env file.
+`
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+// env is the shared state of the commands.
+type env struct {
+	conf *conf.Config
+	log  *zap.Logger
+	out  io.Writer
+	db   *db.DB
+}
+
+type command func(e *env, args []string) error
+
+//nolint:gochecknoglobals
+var commands = map[string]map[string]command{
+	"events": {
+		"list":    eventsList,
+		"show":    eventsShow,
+		"create":  eventsCreate,
+		"update":  eventsUpdate,
+		"close":   eventsClose,
+		"move":    eventsMove,
+		"extract": eventsExtract,
+		"merge":   eventsMerge,
+	},
+	"checker": {
+		"run": checkerRun,
+	},
+	"migrate": {
+		"up":      migrateUp,
+		"down":    migrateDown,
+		"version": migrateVersion,
+	},
+}
+
+// Run executes the command, the result is written to the out.
+func Run(c *conf.Config, log *zap.Logger, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		_, err := fmt.Fprint(out, usage)
+		return err
+	}
+
+	group, ok := commands[args[0]]
+	if !ok || len(args) < 2 { //nolint:mnd
+		return fmt.Errorf("%w: %s, see \"app help\"", ErrUnknownCommand, strings.Join(args, " "))
+	}
+
+	cmd, ok := group[args[1]]
+	if !ok {
+		return fmt.Errorf("%w: %s %s, see \"app help\"", ErrUnknownCommand, args[0], args[1])
+	}
+
+	e := &env{conf: c, log: log, out: out}
+	defer e.close()
+
+	err := cmd(e, args[2:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	return err
+}
+
+// database opens the database connection on the first call.
+func (e *env) database() (*db.DB, error) {
+	if e.db != nil {
+		return e.db, nil
+	}
+
+	dbInst, err := db.New(e.conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	e.db = dbInst.WithActor(context.Background(), cliActor())
+
+	return e.db, nil
+}
+
+// cliActor returns the name of the OS user, who runs the command, for the audit log.
+func cliActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
+
+func (e *env) close() {
+	if e.db == nil {
+		return
+	}
+	if err := e.db.Close(); err != nil {
+		e.log.Error("failed to close the database connection", zap.Error(err))
+	}
+}
+
+func (e *env) printJSON(v any) error {
+	enc := json.NewEncoder(e.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func newFlagSet(name string, out io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(out)
+	return fs
+}
+
+// parseIDs parses the comma separated list of IDs.
+func parseIDs(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	ids := make([]int, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid ID %q", part)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func checkerRun(e *env, args []string) error {
+	fs := newFlagSet("checker run", e.out)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ch, err := checker.New(e.conf, e.log)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := ch.Close(); closeErr != nil {
+			e.log.Error("failed to close the checker", zap.Error(closeErr))
+		}
+	}()
+
+	if err = ch.CheckOnce(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(e.out, "checker pass is finished")
+	return err
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/conf"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Run(&conf.Config{}, zap.NewNop(), []string{"help"}, &out))
+	assert.Contains(t, out.String(), "events create")
+
+	err := Run(&conf.Config{}, zap.NewNop(), []string{"events"}, &out)
+	require.ErrorIs(t, err, ErrUnknownCommand)
+
+	err = Run(&conf.Config{}, zap.NewNop(), []string{"events", "remove"}, &out)
+	require.ErrorIs(t, err, ErrUnknownCommand)
+
+	t.Log("the flags are checked before the database connection")
+	out.Reset()
+	require.NoError(t, Run(&conf.Config{}, zap.NewNop(), []string{"events", "create", "-h"}, &out))
+	assert.Contains(t, out.String(), "-components")
+
+	err = Run(&conf.Config{}, zap.NewNop(), []string{"events", "create", "-title", "test"}, &out)
+	require.ErrorIs(t, err, ErrFlagRequired)
+
+	err = Run(&conf.Config{}, zap.NewNop(), []string{"events", "show"}, &out)
+	require.ErrorIs(t, err, ErrFlagRequired)
+}
+
+func TestParseIDs(t *testing.T) {
+	ids, err := parseIDs("1, 2,30")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 30}, ids)
+
+	ids, err = parseIDs("")
+	require.NoError(t, err)
+	assert.Nil(t, ids)
+
+	_, err = parseIDs("1,a")
+	require.Error(t, err)
+
+	_, err = parseIDs("0")
+	require.Error(t, err)
+}
+
+func TestParseTime(t *testing.T) {
+	def := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	parsed, err := parseTime("", def)
+	require.NoError(t, err)
+	assert.Equal(t, def, parsed)
+
+	parsed, err = parseTime("2025-03-02T15:00:00+02:00", def)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 2, 13, 0, 0, 0, time.UTC), parsed)
+
+	_, err = parseTime("2025-03-02", def)
+	require.Error(t, err)
+}
+
+func TestFormatNullable(t *testing.T) {
+	impact, text := 2, "title"
+
+	assert.Equal(t, "2", formatImpact(&impact))
+	assert.Equal(t, "-", formatImpact(nil))
+	assert.Equal(t, "title", formatText(&text))
+	assert.Equal(t, "-", formatText(nil))
+}
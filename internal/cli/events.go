@@ -0,0 +1,430 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const (
+	defaultListLimit   = 20
+	incidentClosedText = "The incident is resolved."
+)
+
+var ErrFlagRequired = errors.New("flag is required")
+
+func eventsList(e *env, args []string) error {
+	fs := newFlagSet("events list", e.out)
+	types := fs.String("type", "", "comma separated list of event types: incident, maintenance, info")
+	active := fs.Bool("active", false, "show only active events")
+	limit := fs.Int("limit", defaultListLimit, "max number of events, the latest events are shown")
+	asJSON := fs.Bool("json", false, "print events as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params := &db.IncidentsParams{LastCount: *limit}
+	if *types != "" {
+		params.Types = strings.Split(*types, ",")
+	}
+	if *active {
+		params.IsActive = active
+	}
+
+	dbInst, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	events, err := dbInst.GetEvents(params)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return e.printJSON(events)
+	}
+
+	w := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0) //nolint:mnd
+	fmt.Fprintln(w, "ID\tTYPE\tIMPACT\tSTATUS\tSTART\tEND\tCOMPONENTS\tTITLE")
+	for _, inc := range events {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			inc.ID, inc.Type, formatImpact(inc.Impact), inc.Status, formatTime(inc.StartDate), formatTime(inc.EndDate),
+			componentIDs(inc.Components), formatText(inc.Text),
+		)
+	}
+
+	return w.Flush()
+}
+
+func eventsShow(e *env, args []string) error {
+	fs := newFlagSet("events show", e.out)
+	id := fs.Int("id", 0, "event ID (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	inc, err := e.getEvent(*id)
+	if err != nil {
+		return err
+	}
+
+	return e.printJSON(inc)
+}
+
+func eventsCreate(e *env, args []string) error {
+	fs := newFlagSet("events create", e.out)
+	title := fs.String("title", "", "event title (required)")
+	description := fs.String("description", "", "event description")
+	eventType := fs.String("type", event.TypeIncident, "event type: incident, maintenance or info")
+	impact := fs.Int("impact", 0, "impact: 0 for maintenance and info, 1 - minor, 2 - major, 3 - outage")
+	components := fs.String("components", "", "comma separated list of component IDs (required)")
+	start := fs.String("start", "", "start date in RFC3339 format, the current time by default")
+	end := fs.String("end", "", "end date in RFC3339 format, it's required for maintenances")
+	system := fs.Bool("system", false, "create a system incident")
+	contactEmail := fs.String("contact-email", "", "contact email of the maintenance")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *title == "" {
+		return fmt.Errorf("%w: -title", ErrFlagRequired)
+	}
+
+	compIDs, err := parseIDs(*components)
+	if err != nil {
+		return err
+	}
+	if len(compIDs) == 0 {
+		return fmt.Errorf("%w: -components", ErrFlagRequired)
+	}
+
+	startDate, err := parseTime(*start, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	incData := v2.IncidentData{
+		Title:        *title,
+		Description:  *description,
+		Impact:       impact,
+		Components:   compIDs,
+		StartDate:    startDate,
+		System:       system,
+		Type:         *eventType,
+		ContactEmail: *contactEmail,
+	}
+
+	if *end != "" {
+		endDate, parseErr := parseTime(*end, time.Time{})
+		if parseErr != nil {
+			return parseErr
+		}
+		incData.EndDate = &endDate
+	}
+
+	dbInst, err := e.database()
+	if err != nil {
+		return err
+	}
+
+	result, err := v2.CreateEvent(dbInst, e.log, incData, auth.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range result {
+		if _, err = fmt.Fprintf(e.out, "component %d: event %d\n", r.ComponentID, r.IncidentID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func eventsUpdate(e *env, args []string) error {
+	fs := newFlagSet("events update", e.out)
+	id := fs.Int("id", 0, "event ID (required)")
+	status := fs.String("status", "", "new status of the event (required)")
+	message := fs.String("message", "", "text of the update (required)")
+	date := fs.String("date", "", "update date in RFC3339 format, the current time by default")
+	title := fs.String("title", "", "new title")
+	description := fs.String("description", "", "new description")
+	impact := fs.Int("impact", -1, "new impact, the status should be \"impact changed\" for incidents")
+	start := fs.String("start", "", "new start date in RFC3339 format")
+	end := fs.String("end", "", "new end date in RFC3339 format")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *status == "" || *message == "" {
+		return fmt.Errorf("%w: -status and -message", ErrFlagRequired)
+	}
+
+	updateDate, err := parseTime(*date, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	patch := v2.PatchIncidentData{
+		Message:    *message,
+		Status:     event.Status(*status),
+		UpdateDate: updateDate,
+	}
+	if *title != "" {
+		patch.Title = title
+	}
+	if *description != "" {
+		patch.Description = description
+	}
+	if *impact >= 0 {
+		patch.Impact = impact
+	}
+	if patch.StartDate, err = parseOptionalTime(*start); err != nil {
+		return err
+	}
+	if patch.EndDate, err = parseOptionalTime(*end); err != nil {
+		return err
+	}
+
+	stored, err := e.getEvent(*id)
+	if err != nil {
+		return err
+	}
+
+	return e.updateEvent(stored, patch)
+}
+
+// eventsClose resolves the incident or completes the maintenance or info event.
+// The end date of the maintenance or info event is moved to the close date.
+func eventsClose(e *env, args []string) error {
+	fs := newFlagSet("events close", e.out)
+	id := fs.Int("id", 0, "event ID (required)")
+	message := fs.String("message", "", "text of the update, the default text depends on the event type")
+	date := fs.String("date", "", "close date in RFC3339 format, the current time by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	closeDate, err := parseTime(*date, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	inc, err := e.getEvent(*id)
+	if err != nil {
+		return err
+	}
+
+	patch := v2.PatchIncidentData{UpdateDate: closeDate}
+	switch inc.Type {
+	case event.TypeIncident:
+		patch.Status = event.IncidentResolved
+		patch.Message = incidentClosedText
+	case event.TypeMaintenance:
+		patch.Status = event.MaintenanceCompleted
+		patch.Message = event.MaintenanceCompletedStatusText()
+	case event.TypeInformation:
+		patch.Status = event.InfoCompleted
+		patch.Message = event.InfoCompletedStatusText()
+	}
+
+	if inc.Type != event.TypeIncident {
+		if inc.StartDate.After(closeDate) {
+			return fmt.Errorf("the event %d is not started yet, cancel it with \"events update\"", inc.ID)
+		}
+		if inc.EndDate == nil || inc.EndDate.After(closeDate) {
+			patch.EndDate = &closeDate
+		}
+	}
+
+	if *message != "" {
+		patch.Message = *message
+	}
+
+	return e.updateEvent(inc, patch)
+}
+
+func eventsMove(e *env, args []string) error {
+	fs := newFlagSet("events move", e.out)
+	componentID := fs.Int("component", 0, "component ID (required)")
+	fromID := fs.Int("from", 0, "ID of the incident with the component (required)")
+	toID := fs.Int("to", 0, "ID of the target incident (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *componentID <= 0 {
+		return fmt.Errorf("%w: -component", ErrFlagRequired)
+	}
+
+	from, err := e.getEvent(*fromID)
+	if err != nil {
+		return err
+	}
+
+	to, err := e.getEvent(*toID)
+	if err != nil {
+		return err
+	}
+
+	inc, err := v2.MoveComponent(e.db, e.log, uint(*componentID), from, to)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(e.out, "component %d is moved to the event %d\n", *componentID, inc.ID)
+	return err
+}
+
+func eventsExtract(e *env, args []string) error {
+	fs := newFlagSet("events extract", e.out)
+	id := fs.Int("id", 0, "incident ID (required)")
+	components := fs.String("components", "", "comma separated list of component IDs (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	compIDs, err := parseIDs(*components)
+	if err != nil {
+		return err
+	}
+	if len(compIDs) == 0 {
+		return fmt.Errorf("%w: -components", ErrFlagRequired)
+	}
+
+	stored, err := e.getEvent(*id)
+	if err != nil {
+		return err
+	}
+
+	if stored.Type != event.TypeIncident || stored.EndDate != nil {
+		return fmt.Errorf("the event %d is not an opened incident", stored.ID)
+	}
+
+	inc, err := v2.ExtractComponents(e.db, e.log, stored, compIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(e.out, "components are extracted to the event %d\n", inc.ID)
+	return err
+}
+
+func eventsMerge(e *env, args []string) error {
+	fs := newFlagSet("events merge", e.out)
+	id := fs.Int("id", 0, "ID of the merged incident (required)")
+	intoID := fs.Int("into", 0, "ID of the target incident (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	source, err := e.getEvent(*id)
+	if err != nil {
+		return err
+	}
+
+	target, err := e.getEvent(*intoID)
+	if err != nil {
+		return err
+	}
+
+	inc, err := v2.MergeEvents(e.db, e.log, source, target)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(e.out, "event %d is merged into the event %d\n", source.ID, inc.ID)
+	return err
+}
+
+func (e *env) getEvent(id int) (*db.Incident, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: event ID", ErrFlagRequired)
+	}
+
+	dbInst, err := e.database()
+	if err != nil {
+		return nil, err
+	}
+
+	inc, err := dbInst.GetIncident(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the event %d: %w", id, err)
+	}
+
+	return inc, nil
+}
+
+func (e *env) updateEvent(stored *db.Incident, patch v2.PatchIncidentData) error {
+	inc, err := v2.UpdateEvent(e.db, e.log, stored, patch)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(e.out, "event %d is updated, status: %s\n", inc.ID, inc.Status)
+	return err
+}
+
+func parseTime(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use RFC3339 format: %w", value, err)
+	}
+
+	return t.UTC(), nil
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	t, err := parseTime(value, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatImpact(impact *int) string {
+	if impact == nil {
+		return "-"
+	}
+	return strconv.Itoa(*impact)
+}
+
+func formatText(text *string) string {
+	if text == nil {
+		return "-"
+	}
+	return *text
+}
+
+func componentIDs(components []db.Component) string {
+	ids := make([]string, 0, len(components))
+	for _, c := range components {
+		ids = append(ids, fmt.Sprintf("%d", c.ID))
+	}
+	return strings.Join(ids, ",")
+}
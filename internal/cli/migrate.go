@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+// migration is the migrate instance with the database, which holds the migration lock.
+type migration struct {
+	m     *migrate.Migrate
+	db    *sql.DB
+	steps int
+	run   func(fn func() error) error
+}
+
+func newMigrate(e *env, args []string, name string) (*migration, error) {
+	fs := newFlagSet(name, e.out)
+	path := fs.String("path", "", "directory with the migrations, the embedded migrations are used by default")
+	steps := fs.Int("steps", 0, "number of migrations to apply or revert, all migrations by default")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.OpenMigrationsDB(e.conf.DB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	m, err := db.NewMigrate(sqlDB, *path)
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to init migrations: %w", err)
+	}
+
+	run := func(fn func() error) error { return db.WithMigrationLock(context.Background(), sqlDB, fn) }
+	return &migration{m: m, db: sqlDB, steps: *steps, run: run}, nil
+}
+
+func closeMigrate(e *env, mg *migration) {
+	if err := db.CloseMigrate(mg.m); err != nil {
+		e.log.Error("failed to close migrations", zap.Error(err))
+	}
+	if err := mg.db.Close(); err != nil {
+		e.log.Error("failed to close the migrations database", zap.Error(err))
+	}
+}
+
+func migrateUp(e *env, args []string) error {
+	mg, err := newMigrate(e, args, "migrate up")
+	if err != nil {
+		return err
+	}
+	defer closeMigrate(e, mg)
+
+	err = mg.run(func() error {
+		if mg.steps > 0 {
+			return mg.m.Steps(mg.steps)
+		}
+		return mg.m.Up()
+	})
+
+	return e.migrationResult(mg.m, err)
+}
+
+func migrateDown(e *env, args []string) error {
+	mg, err := newMigrate(e, args, "migrate down")
+	if err != nil {
+		return err
+	}
+	defer closeMigrate(e, mg)
+
+	err = mg.run(func() error {
+		if mg.steps > 0 {
+			return mg.m.Steps(-mg.steps)
+		}
+		return mg.m.Down()
+	})
+
+	return e.migrationResult(mg.m, err)
+}
+
+func migrateVersion(e *env, args []string) error {
+	mg, err := newMigrate(e, args, "migrate version")
+	if err != nil {
+		return err
+	}
+	defer closeMigrate(e, mg)
+
+	return e.migrationResult(mg.m, nil)
+}
+
+// migrationResult prints the current version of the database schema after the migration
+// and the version of the last embedded migration.
+func (e *env) migrationResult(m *migrate.Migrate, err error) error {
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	version, err := db.GetSchemaVersion(m)
+	if err != nil {
+		return err
+	}
+
+	if version.Current == 0 && !version.Dirty {
+		_, err = fmt.Fprintf(e.out, "no migrations are applied, latest: %d\n", version.Latest)
+		return err
+	}
+
+	_, err = fmt.Fprintln(e.out, version)
+	return err
+}
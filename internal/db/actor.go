@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type actorContextKey struct{}
+
+// WithActor returns the database instance bound to the context, which records the actor as the author
+// of the events changes. The actor is the user ID, the API token name or the name of the internal process,
+// it's empty if the authentication is disabled.
+// The values of the context are kept, but the cancellation isn't, so the stored change is always followed
+// by its notifications, even if the request is cancelled.
+func (db *DB) WithActor(ctx context.Context, actor string) *DB {
+	ctx = context.WithoutCancel(ctx)
+	if actor != "" {
+		ctx = context.WithValue(ctx, actorContextKey{}, actor)
+	}
+
+	return &DB{g: db.g.WithContext(ctx), cache: db.cache, actor: actor}
+}
+
+// Actor returns the actor of the database instance, it's empty if the actor is not set.
+func (db *DB) Actor() string {
+	return db.actor
+}
+
+func actorFromContext(tx *gorm.DB) string {
+	if tx.Statement.Context == nil {
+		return ""
+	}
+
+	actor, _ := tx.Statement.Context.Value(actorContextKey{}).(string)
+	return actor
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// CreateAlert stores the firing alert, it returns false if the alert is already stored.
+// The unique index deduplicates the notifications of the Alertmanager replicas, which are sent concurrently.
+func (db *DB) CreateAlert(alert *Alert) (bool, error) {
+	alert.StartsAt = alertTime(alert.StartsAt)
+	r := db.g.Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	if r.Error != nil {
+		return false, r.Error
+	}
+
+	return r.RowsAffected > 0, nil
+}
+
+// DeleteAlert removes the alert, it's used if the incident isn't created, so the next notification is processed.
+func (db *DB) DeleteAlert(id uint) error {
+	return db.g.Delete(&Alert{}, id).Error
+}
+
+// ResolveAlert marks the firing alert as resolved, it returns ErrDBAlertDSNotExist
+// if the alert is unknown or already resolved.
+func (db *DB) ResolveAlert(fingerprint string, startsAt, endsAt time.Time) (*Alert, error) {
+	startsAt = alertTime(startsAt)
+	r := db.g.Model(&Alert{}).
+		Where("fingerprint = ? AND starts_at = ? AND status = ?", fingerprint, startsAt, AlertFiring).
+		Updates(map[string]any{"status": AlertResolved, "ends_at": endsAt.UTC(), "modified_at": time.Now().UTC()})
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	if r.RowsAffected == 0 {
+		return nil, ErrDBAlertDSNotExist
+	}
+
+	alert := &Alert{}
+	if err := db.g.Where("fingerprint = ? AND starts_at = ?", fingerprint, startsAt).First(alert).Error; err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// GetAlertsSince returns the firing alerts and the alerts resolved at or after the date.
+func (db *DB) GetAlertsSince(since time.Time) ([]*Alert, error) {
+	var alerts []*Alert
+	r := db.g.Model(&Alert{}).
+		Where("status = ? OR ends_at >= ?", AlertFiring, since.UTC()).
+		Order("id").
+		Find(&alerts)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+
+	return alerts, nil
+}
+
+// alertTime converts the time to the precision of the timestamp column.
+func alertTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (db *DB) GetAPITokens() ([]*APIToken, error) {
+	var tokens []*APIToken
+	if err := db.g.Model(&APIToken{}).Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (db *DB) GetAPIToken(id uint) (*APIToken, error) {
+	return db.getAPIToken("id = ?", id)
+}
+
+func (db *DB) GetAPITokenByHash(hash string) (*APIToken, error) {
+	return db.getAPIToken("token_hash = ?", hash)
+}
+
+func (db *DB) getAPIToken(query string, arg any) (*APIToken, error) {
+	token := &APIToken{}
+	r := db.g.Model(&APIToken{}).Where(query, arg).First(token)
+	if r.Error != nil {
+		if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrDBAPITokenDSNotExist
+		}
+		return nil, r.Error
+	}
+
+	return token, nil
+}
+
+func (db *DB) SaveAPIToken(token *APIToken) (uint, error) {
+	if err := db.g.Create(token).Error; err != nil {
+		return 0, err
+	}
+
+	return token.ID, nil
+}
+
+// RevokeAPIToken sets the revocation date, the revoked token is kept for the audit.
+func (db *DB) RevokeAPIToken(id uint) (*APIToken, error) {
+	token, err := db.GetAPIToken(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RevokedAt != nil {
+		return token, nil
+	}
+
+	now := time.Now().UTC()
+	if err = db.g.Model(token).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	token.RevokedAt = &now
+
+	return token, nil
+}
+
+// TouchAPIToken sets the last usage date of the token.
+func (db *DB) TouchAPIToken(id uint) error {
+	return db.g.Model(&APIToken{}).Where("id = ?", id).Update("last_used_at", time.Now().UTC()).Error
+}
@@ -0,0 +1,53 @@
+package db
+
+import "time"
+
+type AuditParams struct {
+	EventID uint
+	Actor   string
+	Action  string
+	Since   *time.Time
+	Until   *time.Time
+	Limit   int
+}
+
+func (db *DB) SaveAuditRecord(record *AuditRecord) (uint, error) {
+	if err := db.g.Create(record).Error; err != nil {
+		return 0, err
+	}
+
+	return record.ID, nil
+}
+
+// GetAuditRecords returns the records by the filters, the latest records go first.
+func (db *DB) GetAuditRecords(params *AuditParams) ([]*AuditRecord, error) {
+	var records []*AuditRecord
+	r := db.g.Model(&AuditRecord{})
+
+	if params != nil {
+		if params.EventID != 0 {
+			r = r.Where("incident_id = ?", params.EventID)
+		}
+		if params.Actor != "" {
+			r = r.Where("actor = ?", params.Actor)
+		}
+		if params.Action != "" {
+			r = r.Where("action = ?", params.Action)
+		}
+		if params.Since != nil {
+			r = r.Where("created_at >= ?", *params.Since)
+		}
+		if params.Until != nil {
+			r = r.Where("created_at <= ?", *params.Until)
+		}
+		if params.Limit > 0 {
+			r = r.Limit(params.Limit)
+		}
+	}
+
+	if err := r.Order("id DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// SaveAuthToken stores the token, the token with the same key is replaced.
+// The expired tokens are removed on the save.
+func (db *DB) SaveAuthToken(token *AuthToken) error {
+	r := db.g.Clauses(clause.OnConflict{UpdateAll: true}).Create(token)
+	if r.Error != nil {
+		return r.Error
+	}
+
+	_, err := db.DeleteAuthTokensBefore(time.Now().UTC())
+	return err
+}
+
+// PopAuthToken returns and removes the token, so the token can be read only once.
+func (db *DB) PopAuthToken(key string) (*AuthToken, error) {
+	var tokens []*AuthToken
+	r := db.g.Clauses(clause.Returning{}).Where("key = ?", key).Delete(&tokens)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+
+	if len(tokens) == 0 || !tokens[0].ExpiresAt.After(time.Now().UTC()) {
+		return nil, ErrDBAuthTokenDSNotExist
+	}
+
+	return tokens[0], nil
+}
+
+func (db *DB) DeleteAuthTokensBefore(before time.Time) (int64, error) {
+	r := db.g.Where("expires_at < ?", before).Delete(&AuthToken{})
+	return r.RowsAffected, r.Error
+}
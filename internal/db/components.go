@@ -0,0 +1,111 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+// ComponentsParams are the filters for the components list.
+// IncludeDeleted adds the deleted (retired) components to the result.
+type ComponentsParams struct {
+	IncludeDeleted bool
+}
+
+func applyComponentsFilters(base *gorm.DB, params ...*ComponentsParams) *gorm.DB {
+	if len(params) > 0 && params[0] != nil && params[0].IncludeDeleted {
+		return base
+	}
+
+	return base.Where("component.deleted_at IS NULL")
+}
+
+// ModifyComponent updates the name and the attributes of the not deleted component.
+// Only the given attributes are changed or added, the other attributes stay as is.
+func (db *DB) ModifyComponent(comp *Component) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		stored := &Component{}
+		r := tx.Model(&Component{}).Preload("Attrs").
+			Where("id = ? AND deleted_at IS NULL", comp.ID).
+			First(stored)
+		if r.Error != nil {
+			if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+				return ErrDBComponentDSNotExist
+			}
+			return r.Error
+		}
+
+		region := stored.Region()
+		for _, attr := range comp.Attrs {
+			if attr.Name == regionAttrName {
+				region = attr.Value
+			}
+		}
+
+		var count int64
+		err := tx.Model(&Component{}).
+			Joins("JOIN component_attribute ca ON ca.component_id = component.id").
+			Where("component.name = ? AND ca.name = ? AND ca.value = ?", comp.Name, regionAttrName, region).
+			Where("component.id <> ? AND component.deleted_at IS NULL", comp.ID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDBComponentExists
+		}
+
+		for _, attr := range comp.Attrs {
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "component_id"}, {Name: "name"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value"}),
+			}).Create(&ComponentAttr{ComponentID: comp.ID, Name: attr.Name, Value: attr.Value}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(comp).Select("name", "modified_at").Updates(comp).Error
+	})
+}
+
+// DeleteComponent marks the component as deleted, the component stays in the history of the events.
+// The component can't be deleted while it's affected by an active event, the event should be finished
+// or the component should be extracted from the event first.
+func (db *DB) DeleteComponent(id uint) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		comp := &Component{}
+		r := tx.Model(&Component{}).Where("id = ? AND deleted_at IS NULL", id).First(comp)
+		if r.Error != nil {
+			if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+				return ErrDBComponentDSNotExist
+			}
+			return r.Error
+		}
+
+		var count int64
+		err := tx.Model(&Incident{}).
+			Joins("JOIN incident_component_relation icr ON icr.incident_id = incident.id").
+			Where("icr.component_id = ?", id).
+			Where("(incident.end_date IS NULL OR (incident.end_date > ? AND "+
+				"(incident.status IS NULL OR incident.status NOT IN (?))))",
+				time.Now().UTC(),
+				// the statuses are the same for maintenances and info events
+				[]event.Status{event.MaintenanceCompleted, event.MaintenanceCancelled},
+			).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDBComponentInUse
+		}
+
+		now := time.Now().UTC()
+		return tx.Model(comp).Updates(map[string]any{"deleted_at": now, "modified_at": now}).Error
+	})
+}
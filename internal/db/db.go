@@ -3,6 +3,7 @@ package db
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"go.uber.org/zap"
@@ -11,12 +12,15 @@ import (
 	"gorm.io/gorm/clause"
 	"moul.io/zapgorm2"
 
+	"github.com/stackmon/otc-status-dashboard/internal/cache"
 	"github.com/stackmon/otc-status-dashboard/internal/conf"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
 )
 
 type DB struct {
-	g *gorm.DB
+	g     *gorm.DB
+	cache cache.Cache
+	actor string
 }
 
 func New(c *conf.Config) (*DB, error) {
@@ -40,7 +44,50 @@ func New(c *conf.Config) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{g: g}, nil
+	readCache, err := cache.Shared(c.Cache)
+	if err != nil {
+		return nil, err
+	}
+	if readCache != nil {
+		if err = g.Use(&cache.GormPlugin{Cache: readCache}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &DB{g: g, cache: readCache}, nil
+}
+
+// Use registers the gorm plugin, as example, the plugin of the database metrics.
+func (db *DB) Use(plugin gorm.Plugin) error {
+	return db.g.Use(plugin)
+}
+
+// Cache returns the read cache of the public API, it's nil if the cache is disabled.
+func (db *DB) Cache() cache.Cache {
+	return db.cache
+}
+
+// Transaction runs the function with the database instance bound to one transaction,
+// the transaction is rolled back if the function returns the error.
+func (db *DB) Transaction(fn func(tx *DB) error) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		return fn(&DB{g: tx, cache: db.cache, actor: db.actor})
+	})
+}
+
+// transaction runs the function in the transaction, the read cache is invalidated after the commit.
+func (db *DB) transaction(fn func(tx *gorm.DB) error) error {
+	if db.cache == nil {
+		return db.g.Transaction(fn)
+	}
+
+	ctx, deferred := cache.DeferInvalidation(db.g.Statement.Context)
+	if err := db.g.WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+	deferred.Flush(ctx, db.cache)
+
+	return nil
 }
 
 func (db *DB) Close() error {
@@ -52,17 +99,36 @@ func (db *DB) Close() error {
 }
 
 type IncidentsParams struct {
-	Types        []string
-	Status       *event.Status
-	StartDate    *time.Time
-	EndDate      *time.Time
+	Types  []string
+	Status *event.Status
+	// ExcludeStatuses hides events with the given statuses, it's used to hide not public events.
+	ExcludeStatuses []event.Status
+	// StartDate and EndDate select the events inside the window: the start after the StartDate and
+	// the end before the EndDate. With the Overlap the events, which were active at any time of the window,
+	// are selected, including the events started before the window and the open events.
+	StartDate *time.Time
+	EndDate   *time.Time
+	Overlap   bool
+	// At selects the events, which were active at the time, it's used instead of the StartDate and the EndDate.
+	At           *time.Time
 	Impact       *int
 	IsSystem     *bool
 	ComponentIDs []int
+	SeriesID     *uint
 	LastCount    int
 	IsActive     *bool
 	Limit        *int
 	Page         *int
+	// Search is the full-text search of the title, the description and the updates,
+	// the events are sorted by the search rank, if the Sort and the After aren't set.
+	Search string
+	// ModifiedSince returns the events, which are created or changed since the time.
+	ModifiedSince *time.Time
+	// Sort is the order of the events, the DefaultEventsSort is used if it's not set.
+	Sort *EventsSort
+	// After is the cursor of the keyset pagination, the events are sorted by the sort of the cursor.
+	// It's used instead of the Page.
+	After *EventsCursor
 }
 
 func applyEventsFilters(base *gorm.DB, params *IncidentsParams) (*gorm.DB, error) {
@@ -106,6 +172,42 @@ func applyEventsFilters(base *gorm.DB, params *IncidentsParams) (*gorm.DB, error
 		base = base.Where("incident.status = ?", params.Status)
 	}
 
+	if params.SeriesID != nil {
+		base = base.Where("incident.series_id = ?", *params.SeriesID)
+	}
+
+	if params.Search != "" {
+		base = applySearchFilter(base, params.Search)
+	}
+
+	if params.ModifiedSince != nil {
+		base = base.Where("incident.modified_at >= ?", *params.ModifiedSince)
+	}
+
+	if len(params.ExcludeStatuses) > 0 {
+		base = base.Where("(incident.status IS NULL OR incident.status NOT IN (?))", params.ExcludeStatuses)
+	}
+
+	return applyEventsTimeWindow(base, params), nil
+}
+
+// applyEventsTimeWindow filters the events by the StartDate, the EndDate and the At of the params.
+func applyEventsTimeWindow(base *gorm.DB, params *IncidentsParams) *gorm.DB {
+	if params.At != nil {
+		return base.Where("incident.start_date <= ? AND (incident.end_date IS NULL OR incident.end_date >= ?)",
+			*params.At, *params.At)
+	}
+
+	if params.Overlap {
+		if params.StartDate != nil {
+			base = base.Where("(incident.end_date IS NULL OR incident.end_date >= ?)", *params.StartDate)
+		}
+		if params.EndDate != nil {
+			base = base.Where("incident.start_date <= ?", *params.EndDate)
+		}
+		return base
+	}
+
 	switch {
 	case params.StartDate != nil && params.EndDate != nil:
 		base = base.Where("incident.start_date >= ? AND incident.end_date <= ?", *params.StartDate, *params.EndDate)
@@ -114,7 +216,7 @@ func applyEventsFilters(base *gorm.DB, params *IncidentsParams) (*gorm.DB, error
 	case params.EndDate != nil && params.StartDate == nil:
 		base = base.Where("incident.end_date <= ?", *params.EndDate)
 	}
-	return base, nil
+	return base
 }
 
 func (db *DB) fetchPaginatedEvents(filteredBase *gorm.DB, param *IncidentsParams) ([]*Incident, error) {
@@ -122,19 +224,24 @@ func (db *DB) fetchPaginatedEvents(filteredBase *gorm.DB, param *IncidentsParams
 
 	subQuery := filteredBase.
 		Select("incident.id").
-		Order("incident.start_date DESC").
+		Order(eventsOrder(param)).
 		Limit(*param.Limit)
 
-	if param.Page != nil && *param.Page > 1 {
+	switch {
+	case param.After != nil:
+		subQuery = applyEventsCursor(subQuery, param.After)
+	case param.Page != nil && *param.Page > 1:
 		subQuery = subQuery.Offset((*param.Page - 1) * *param.Limit)
 	}
 
 	r := db.g.Model(&Incident{}).
 		Joins("JOIN (?) AS filtered_ids ON filtered_ids.id = incident.id", subQuery).
-		Preload("Statuses").
+		Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB { return db.Select("ID, Name") }).
 		Preload("Components.Attrs").
-		Order("incident.start_date DESC")
+		Preload("Series").
+		Preload("ComponentImpacts").
+		Order(eventsOrder(param))
 
 	if err := r.Find(&events).Error; err != nil {
 		return nil, err
@@ -145,13 +252,15 @@ func (db *DB) fetchPaginatedEvents(filteredBase *gorm.DB, param *IncidentsParams
 func (db *DB) fetchUnpaginatedEvents(filteredBase *gorm.DB, param *IncidentsParams) ([]*Incident, error) {
 	var events []*Incident
 
-	r := filteredBase.Order("incident.start_date DESC")
+	r := filteredBase.Order(eventsOrder(param))
 	if param.LastCount > 0 {
 		r = r.Limit(param.LastCount)
 	}
-	if err := r.Preload("Statuses").
+	if err := r.Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB { return db.Select("ID, Name") }).
 		Preload("Components.Attrs").
+		Preload("Series").
+		Preload("ComponentImpacts").
 		Find(&events).Error; err != nil {
 		return nil, err
 	}
@@ -199,18 +308,25 @@ func (db *DB) GetEvents(params ...*IncidentsParams) ([]*Incident, error) {
 	return events, err
 }
 
+// orderStatuses orders the updates of the event chronologically, the updates with the same timestamp
+// are in the order of creation. The copies of the updates of the merged incident are created after
+// the updates of the target, the order places them by their timestamps.
+func orderStatuses(tx *gorm.DB) *gorm.DB {
+	return tx.Order("incident_status.timestamp, incident_status.id")
+}
+
 func (db *DB) GetIncident(id int) (*Incident, error) {
 	inc := Incident{ID: uint(id)}
 
 	r := db.g.Model(&Incident{}).
 		Where(inc).
-		Preload("Statuses", func(db *gorm.DB) *gorm.DB {
-			return db.Order("id ASC")
-		}).
+		Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB {
 			return db.Select("ID, Name")
 		}).
 		Preload("Components.Attrs").
+		Preload("Series").
+		Preload("ComponentImpacts").
 		First(&inc)
 
 	if r.Error != nil {
@@ -223,6 +339,17 @@ func (db *DB) GetIncident(id int) (*Incident, error) {
 	return &inc, nil
 }
 
+// LockIncidents locks the rows of the incidents till the end of the transaction.
+// The rows are locked in the order of the IDs, so the concurrent transactions with the same incidents don't deadlock.
+func (db *DB) LockIncidents(ids ...uint) error {
+	var locked []Incident
+	return db.g.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&locked).Error
+}
+
 func (db *DB) SaveIncident(inc *Incident) (uint, error) {
 	r := db.g.Create(inc)
 
@@ -234,7 +361,8 @@ func (db *DB) SaveIncident(inc *Incident) (uint, error) {
 }
 
 func (db *DB) ModifyIncident(inc *Incident) error {
-	r := db.g.Updates(inc)
+	// the series is changed only by its own methods
+	r := db.g.Omit("Series").Updates(inc)
 
 	if r.Error != nil {
 		return r.Error
@@ -246,7 +374,8 @@ func (db *DB) ModifyIncident(inc *Incident) error {
 // ReOpenIncident the special function if you need to NULL your end_date.
 func (db *DB) ReOpenIncident(inc *Incident) error {
 	r := db.g.Model(&Incident{}).Where("id = ?", inc.ID).Updates(map[string]interface{}{
-		"end_date": nil,
+		"end_date":    nil,
+		"modified_at": time.Now().UTC(),
 	})
 	if r.Error != nil {
 		return r.Error
@@ -268,11 +397,13 @@ func (db *DB) GetEventsByComponentID(componentID uint, params ...*IncidentsParam
 	r := db.g.Model(&Incident{}).
 		Joins("JOIN incident_component_relation icr ON icr.incident_id = incident.id").
 		Where("icr.component_id = ?", componentID).
-		Preload("Statuses").
+		Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB {
 			return db.Select("ID, Name")
 		}).
-		Preload("Components.Attrs")
+		Preload("Components.Attrs").
+		Preload("Series").
+		Preload("ComponentImpacts")
 
 	if param.LastCount != 0 {
 		r.Order("incident.id desc").Limit(param.LastCount)
@@ -297,6 +428,12 @@ func (db *DB) GetEventsByComponentID(componentID uint, params ...*IncidentsParam
 		r.Where("incident.type IN (?)", param.Types)
 	}
 
+	if len(param.ExcludeStatuses) > 0 {
+		r.Where("(incident.status IS NULL OR incident.status NOT IN (?))", param.ExcludeStatuses)
+	}
+
+	r = applyEventsTimeWindow(r, &param)
+
 	r.Find(&incidents)
 	if r.Error != nil {
 		return nil, r.Error
@@ -316,16 +453,28 @@ func (db *DB) GetIncidentsByComponentAttr(attr *ComponentAttr, params ...*Incide
 		Joins("JOIN incident_component_relation icr ON icr.incident_id = incident.id").
 		Joins("JOIN component_attribute ca ON ca.component_id = icr.component_id").
 		Where("ca.name = ? AND ca.value = ?", attr.Name, attr.Value).
-		Preload("Statuses").
+		Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB {
 			return db.Select("ID, Name")
 		}).
-		Preload("Components.Attrs")
+		Preload("Components.Attrs").
+		Preload("Series").
+		Preload("ComponentImpacts")
 
 	if param.LastCount != 0 {
 		r.Order("incident.id desc").Limit(param.LastCount)
 	}
 
+	if len(param.Types) > 0 {
+		r.Where("incident.type IN (?)", param.Types)
+	}
+
+	if len(param.ExcludeStatuses) > 0 {
+		r.Where("(incident.status IS NULL OR incident.status NOT IN (?))", param.ExcludeStatuses)
+	}
+
+	r = applyEventsTimeWindow(r, &param)
+
 	r.Find(&incidents)
 	if r.Error != nil {
 		return nil, r.Error
@@ -346,7 +495,7 @@ func (db *DB) GetOpenedIncidentsWithComponent(name string, attrs []ComponentAttr
 
 	var incident Incident
 	r = db.g.Model(&Incident{}).
-		Preload("Statuses").
+		Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB {
 			return db.Select("ID")
 		}).
@@ -374,9 +523,10 @@ func (db *DB) GetComponent(id int) (*Component, error) {
 	return comp, nil
 }
 
+// GetComponentsAsMap returns the not deleted components, it's used to validate the components of new events.
 func (db *DB) GetComponentsAsMap() (map[int]*Component, error) {
 	var components []Component
-	r := db.g.Model(&Component{}).Find(&components)
+	r := db.g.Model(&Component{}).Where("deleted_at IS NULL").Find(&components)
 
 	if r.Error != nil {
 		return nil, r.Error
@@ -390,9 +540,10 @@ func (db *DB) GetComponentsAsMap() (map[int]*Component, error) {
 	return compMap, nil
 }
 
-func (db *DB) GetComponentsWithValues() ([]Component, error) {
+// GetComponentsWithValues returns the components with the attributes, the deleted components are skipped by default.
+func (db *DB) GetComponentsWithValues(params ...*ComponentsParams) ([]Component, error) {
 	var components []Component
-	r := db.g.Model(&Component{}).Preload("Attrs").Find(&components)
+	r := applyComponentsFilters(db.g.Model(&Component{}), params...).Preload("Attrs").Find(&components)
 
 	if r.Error != nil {
 		return nil, r.Error
@@ -401,9 +552,12 @@ func (db *DB) GetComponentsWithValues() ([]Component, error) {
 	return components, nil
 }
 
-func (db *DB) GetComponentsWithIncidents() ([]Component, error) {
+// GetComponentsWithIncidents returns the components with the events, the deleted components are skipped by default.
+func (db *DB) GetComponentsWithIncidents(params ...*ComponentsParams) ([]Component, error) {
 	var components []Component
-	r := db.g.Model(&Component{}).Preload("Attrs").Preload("Incidents").Preload("Incidents.Statuses").Find(&components)
+	r := applyComponentsFilters(db.g.Model(&Component{}), params...).
+		Preload("Attrs").Preload("Incidents").Preload("Incidents.Statuses", orderStatuses).Preload("Incidents.ComponentImpacts").
+		Find(&components)
 
 	if r.Error != nil {
 		return nil, r.Error
@@ -412,7 +566,7 @@ func (db *DB) GetComponentsWithIncidents() ([]Component, error) {
 	return components, nil
 }
 
-// GetComponentFromNameAttrs returns the Component from its name and region attribute.
+// GetComponentFromNameAttrs returns the not deleted Component from its name and region attribute.
 func (db *DB) GetComponentFromNameAttrs(name string, attr *ComponentAttr) (*Component, error) {
 	comp := Component{}
 	//nolint:lll
@@ -424,7 +578,8 @@ func (db *DB) GetComponentFromNameAttrs(name string, attr *ComponentAttr) (*Comp
 		Select("component.id").
 		Joins("JOIN component_attribute ca ON ca.component_id = component.id").
 		Where("ca.value = ?", attr.Value).
-		Where("component.name = ?", name)
+		Where("component.name = ?", name).
+		Where("component.deleted_at IS NULL")
 	r := db.g.Model(&Component{}).Where("name = ?", name).
 		Where("id = (?)", subQuery).
 		Preload("Attrs").
@@ -451,7 +606,8 @@ func (db *DB) SaveComponent(comp *Component) (uint, error) {
 			var exists Component
 			if err := db.g.Joins("JOIN component_attribute ca ON ca.component_id = component.id").
 				Where("component.name = ? AND ca.name = 'region' AND ca.value = ?",
-					comp.Name, attr.Value).First(&exists).Error; err == nil {
+					comp.Name, attr.Value).
+				Where("component.deleted_at IS NULL").First(&exists).Error; err == nil {
 				return 0, ErrDBComponentExists
 			}
 			break
@@ -511,7 +667,7 @@ func (db *DB) MoveComponentFromOldToAnotherIncident(
 		incOld.EndDate = &timeNow
 	}
 
-	err := db.g.Transaction(func(tx *gorm.DB) error {
+	err := db.transaction(func(tx *gorm.DB) error {
 		if !closeOld {
 			if err := tx.Model(incOld).Association("Components").Delete(comp); err != nil {
 				return err
@@ -525,6 +681,16 @@ func (db *DB) MoveComponentFromOldToAnotherIncident(
 			return r.Error
 		}
 
+		// the moved component gets the impact of the new incident
+		if incNew.HasComponentImpacts() {
+			if err := setComponentImpacts(tx, incNew, map[uint]int{comp.ID: *incNew.Impact}); err != nil {
+				return err
+			}
+		}
+		if !closeOld && incOld.HasComponentImpacts() {
+			return setComponentImpacts(tx, incOld, nil)
+		}
+
 		return nil
 	})
 
@@ -582,10 +748,10 @@ func (db *DB) ExtractComponentsToNewIncident(
 	}
 
 	// Use a transaction to save both incidents with their statuses and update associations
-	err = db.g.Transaction(func(tx *gorm.DB) error {
+	err = db.transaction(func(tx *gorm.DB) error {
 		// Remove component from old incident
 		for _, c := range comp {
-			if errDel := tx.Model(incOld).Association("Components").Delete(c); err != nil {
+			if errDel := tx.Model(incOld).Association("Components").Delete(c); errDel != nil {
 				return errDel
 			}
 		}
@@ -598,6 +764,11 @@ func (db *DB) ExtractComponentsToNewIncident(
 			return r.Error
 		}
 
+		// the impact of the old incident is the max impact of the remaining components
+		if incOld.HasComponentImpacts() {
+			return setComponentImpacts(tx, incOld, nil)
+		}
+
 		return nil
 	})
 
@@ -608,6 +779,131 @@ func (db *DB) ExtractComponentsToNewIncident(
 	return inc, nil
 }
 
+// MergeIncidents merges the source incident into the target incident in one transaction.
+// Both incidents are read again with the row locks, they must be opened.
+// The target gets the components and the copies of the updates of the source, the max impact
+// and the earliest start date, the stored updates of the target aren't changed.
+// The source is closed by system with the pointer to the target.
+func (db *DB) MergeIncidents(sourceID, targetID uint) (*Incident, error) {
+	err := db.transaction(func(tx *gorm.DB) error {
+		txDB := &DB{g: tx, cache: db.cache, actor: db.actor}
+		if err := txDB.LockIncidents(sourceID, targetID); err != nil {
+			return err
+		}
+
+		source, err := txDB.GetIncident(int(sourceID))
+		if err != nil {
+			return err
+		}
+		target, err := txDB.GetIncident(int(targetID))
+		if err != nil {
+			return err
+		}
+
+		return mergeIncidents(tx, source, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return db.GetIncident(int(targetID))
+}
+
+func mergeIncidents(tx *gorm.DB, source, target *Incident) error {
+	for _, inc := range []*Incident{source, target} {
+		if inc.EndDate != nil {
+			return fmt.Errorf("%w: %d", ErrDBIncidentNotOpened, inc.ID)
+		}
+	}
+
+	timeNow := time.Now().UTC()
+
+	// the components keep their impacts, if the impacts are set separately, so the impacts of the target
+	// are taken before its impact is raised, otherwise its components get the raised impact
+	impacts := make(map[uint]int, len(target.Components)+len(source.Components))
+	for _, c := range target.Components {
+		impacts[c.ID] = target.ComponentImpact(c.ID)
+	}
+	added := make([]Component, 0, len(source.Components))
+	for _, c := range source.Components {
+		if !slices.ContainsFunc(target.Components, func(tc Component) bool { return tc.ID == c.ID }) {
+			added = append(added, Component{ID: c.ID})
+			impacts[c.ID] = source.ComponentImpact(c.ID)
+		}
+	}
+	if len(added) != 0 {
+		if err := tx.Model(target).Association("Components").Append(added); err != nil {
+			return err
+		}
+	}
+
+	impact, startDate := max(*target.Impact, *source.Impact), *target.StartDate
+	if source.StartDate.Before(startDate) {
+		startDate = *source.StartDate
+	}
+	r := tx.Model(&Incident{}).Where("id = ?", target.ID).
+		Updates(map[string]any{"impact": impact, "start_date": startDate, "modified_at": timeNow})
+	if r.Error != nil {
+		return r.Error
+	}
+	target.Impact = &impact
+
+	if target.HasComponentImpacts() || source.HasComponentImpacts() {
+		if err := setComponentImpacts(tx, target, impacts); err != nil {
+			return err
+		}
+	}
+
+	// the copies keep the authors of the source updates, so the hooks aren't called for them
+	copies := make([]IncidentStatus, len(source.Statuses))
+	for i, s := range source.Statuses {
+		if s.CreatedAt == nil {
+			s.CreatedAt = &timeNow
+		}
+		copies[i] = IncidentStatus{
+			IncidentID: target.ID,
+			Status:     s.Status,
+			Text:       s.Text,
+			Timestamp:  s.Timestamp,
+			Author:     s.Author,
+			CreatedAt:  s.CreatedAt,
+			ModifiedAt: &timeNow,
+		}
+	}
+	if len(copies) != 0 {
+		if r = tx.Session(&gorm.Session{SkipHooks: true}).Create(&copies); r.Error != nil {
+			return r.Error
+		}
+	}
+
+	notes := []IncidentStatus{
+		{
+			IncidentID: target.ID,
+			Status:     event.OutDatedSystem,
+			Text:       fmt.Sprintf("%s merged into the incident", source.Link()),
+			Timestamp:  timeNow,
+		},
+		{
+			IncidentID: source.ID,
+			Status:     event.IncidentResolved,
+			Text:       fmt.Sprintf("Incident merged into %s, Incident closed by system", target.Link()),
+			Timestamp:  timeNow,
+		},
+	}
+	if r = tx.Create(&notes); r.Error != nil {
+		return r.Error
+	}
+
+	return tx.Model(&Incident{}).Where("id = ?", source.ID).Updates(map[string]any{
+		"status":         event.IncidentResolved,
+		"end_date":       timeNow,
+		"merged_into_id": target.ID,
+		"modified_at":    timeNow,
+	}).Error
+}
+
+// IncreaseIncidentImpact changes the impact of the incident, the impacts on the components are reset
+// and all components get the new impact of the incident.
 func (db *DB) IncreaseIncidentImpact(inc *Incident, impact int) (*Incident, error) {
 	timeNow := time.Now().UTC()
 	text := fmt.Sprintf("impact changed from %d to %d", *inc.Impact, impact)
@@ -619,13 +915,85 @@ func (db *DB) IncreaseIncidentImpact(inc *Incident, impact int) (*Incident, erro
 	})
 	inc.Impact = &impact
 
-	if r := db.g.Updates(inc); r.Error != nil {
-		return nil, r.Error
+	err := db.transaction(func(tx *gorm.DB) error {
+		if r := tx.Updates(inc); r.Error != nil {
+			return r.Error
+		}
+		if inc.HasComponentImpacts() {
+			return resetComponentImpacts(tx, inc)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return inc, nil
 }
 
+// SetComponentImpacts sets the impacts on the components of the incident, the components without the impact
+// in the map keep the current impact. The incident impact is changed to the max impact of the components.
+func (db *DB) SetComponentImpacts(inc *Incident, impacts map[uint]int) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		return setComponentImpacts(tx, inc, impacts)
+	})
+}
+
+func setComponentImpacts(tx *gorm.DB, inc *Incident, impacts map[uint]int) error {
+	timeNow := time.Now().UTC()
+
+	componentImpacts := make([]IncidentComponent, 0, len(inc.Components))
+	maxImpact := 0
+	for _, c := range inc.Components {
+		impact, ok := impacts[c.ID]
+		if !ok {
+			impact = inc.ComponentImpact(c.ID)
+		}
+		componentImpacts = append(componentImpacts, IncidentComponent{
+			IncidentID: inc.ID, ComponentID: c.ID, Impact: &impact,
+		})
+		maxImpact = max(maxImpact, impact)
+	}
+
+	for _, ic := range componentImpacts {
+		r := tx.Model(&IncidentComponent{}).
+			Where("incident_id = ? AND component_id = ?", ic.IncidentID, ic.ComponentID).
+			Update("impact", *ic.Impact)
+		if r.Error != nil {
+			return r.Error
+		}
+	}
+
+	r := tx.Model(&Incident{}).Where("id = ?", inc.ID).
+		Updates(map[string]any{"impact": maxImpact, "modified_at": timeNow})
+	if r.Error != nil {
+		return r.Error
+	}
+
+	inc.Impact = &maxImpact
+	inc.ComponentImpacts = componentImpacts
+	inc.ModifiedAt = &timeNow
+
+	return nil
+}
+
+// ResetComponentImpacts removes the impacts on the components, the components get the impact of the incident.
+func (db *DB) ResetComponentImpacts(inc *Incident) error {
+	return resetComponentImpacts(db.g, inc)
+}
+
+func resetComponentImpacts(tx *gorm.DB, inc *Incident) error {
+	r := tx.Model(&IncidentComponent{}).Where("incident_id = ?", inc.ID).Update("impact", nil)
+	if r.Error != nil {
+		return r.Error
+	}
+
+	inc.ComponentImpacts = nil
+
+	return nil
+}
+
 func (db *DB) GetUniqueAttributeValues(attrName string) ([]string, error) {
 	var values []string
 	r := db.g.Model(&ComponentAttr{}).
@@ -643,9 +1011,8 @@ func (db *DB) GetUniqueAttributeValues(attrName string) ([]string, error) {
 
 func (db *DB) GetEventUpdates(incidentID uint) ([]IncidentStatus, error) {
 	var updates []IncidentStatus
-	r := db.g.Model(&IncidentStatus{}).
+	r := orderStatuses(db.g.Model(&IncidentStatus{})).
 		Where("incident_id = ?", incidentID).
-		Order("id ASC").
 		Find(&updates)
 
 	if r.Error != nil {
@@ -674,5 +1041,11 @@ func (db *DB) ModifyEventUpdate(update IncidentStatus) (IncidentStatus, error) {
 		return IncidentStatus{}, ErrDBEventUpdateDSNotExist
 	}
 
+	// the changed update changes the event for the sync by the modified_at
+	r = db.g.Model(&Incident{}).Where("id = ?", update.IncidentID).Update("modified_at", now)
+	if r.Error != nil {
+		return IncidentStatus{}, r.Error
+	}
+
 	return updated, nil
 }
@@ -5,5 +5,21 @@ import "errors"
 var ErrDBComponentDSNotExist = errors.New("component does not exist")
 var ErrDBComponentExists = errors.New("component exists")
 var ErrDBIncidentDSNotExist = errors.New("incident does not exist")
+var ErrDBIncidentNotOpened = errors.New("incident is not opened")
 var ErrDBEventUpdateDSNotExist = errors.New("update does not exist")
 var ErrDBIncidentFilterActiveFalse = errors.New("filter for inactive incidents is restricted")
+var ErrDBMaintenanceNotPendingReview = errors.New("maintenance is not in pending review status")
+var ErrDBMaintenanceVersionConflict = errors.New("maintenance version conflict")
+var ErrDBWebhookDSNotExist = errors.New("webhook does not exist")
+var ErrDBRegionDSNotExist = errors.New("region does not exist")
+var ErrDBRegionExists = errors.New("region exists")
+var ErrDBRegionInUse = errors.New("region is used by components")
+var ErrDBComponentInUse = errors.New("component is affected by active events")
+var ErrDBAuthTokenDSNotExist = errors.New("auth token does not exist or expired")
+var ErrDBAPITokenDSNotExist = errors.New("api token does not exist")
+var ErrDBMaintenanceSeriesDSNotExist = errors.New("maintenance series does not exist")
+var ErrDBAlertDSNotExist = errors.New("firing alert does not exist")
+var ErrDBSubscriberDSNotExist = errors.New("subscriber does not exist")
+var ErrDBEmailNotificationDSNotExist = errors.New("email notification does not exist")
+var ErrDBSchemaVersionMismatch = errors.New("database schema version mismatch")
+var ErrDBEventsCursorInvalid = errors.New("events cursor is invalid")
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The fields to sort the events, the events with the same value are sorted by the ID in the same direction.
+const (
+	SortStartDate  = "start_date"
+	SortEndDate    = "end_date"
+	SortModifiedAt = "modified_at"
+	SortImpact     = "impact"
+)
+
+// The sort expressions don't have NULL values, so the cursor can be compared with them.
+// The events without the end date are open, they are sorted as the events with the latest end date.
+//
+//nolint:gochecknoglobals
+var sortExpressions = map[string]string{
+	SortStartDate:  "incident.start_date",
+	SortEndDate:    "COALESCE(incident.end_date, '9999-12-31'::timestamp)",
+	SortModifiedAt: "COALESCE(incident.modified_at, '0001-01-01'::timestamp)",
+	SortImpact:     "incident.impact",
+}
+
+// openEndDate is the end date of the open events in the sort expression.
+//
+//nolint:gochecknoglobals
+var openEndDate = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC) //nolint:mnd
+
+// EventsSort is the order of the events.
+type EventsSort struct {
+	Field string `json:"f"`
+	Desc  bool   `json:"d,omitempty"`
+}
+
+// DefaultEventsSort is the order of the events without the sort and the search parameters.
+func DefaultEventsSort() EventsSort {
+	return EventsSort{Field: SortStartDate, Desc: true}
+}
+
+func IsValidSortField(field string) bool {
+	_, ok := sortExpressions[field]
+	return ok
+}
+
+// EventsCursor is the position of the last returned event for the keyset pagination.
+// The next page starts after the event with the sort value and the ID of the cursor.
+type EventsCursor struct {
+	Sort EventsSort `json:"s"`
+	// Time is the value of the date fields, Impact is the value of the impact field.
+	Time   *time.Time `json:"t,omitempty"`
+	Impact *int       `json:"i,omitempty"`
+	ID     uint       `json:"id"`
+}
+
+// NewEventsCursor returns the cursor after the event in the sort order.
+func NewEventsCursor(inc *Incident, sort EventsSort) *EventsCursor {
+	c := &EventsCursor{Sort: sort, ID: inc.ID}
+
+	var value time.Time
+	switch sort.Field {
+	case SortImpact:
+		c.Impact = inc.Impact
+		return c
+	case SortStartDate:
+		value = *inc.StartDate
+	case SortEndDate:
+		value = openEndDate
+		if inc.EndDate != nil {
+			value = *inc.EndDate
+		}
+	case SortModifiedAt:
+		if inc.ModifiedAt != nil {
+			value = *inc.ModifiedAt
+		}
+	}
+	value = value.UTC()
+	c.Time = &value
+
+	return c
+}
+
+// Encode returns the opaque string of the cursor for the API.
+func (c *EventsCursor) Encode() string {
+	// the cursor has only the plain fields, so the marshal doesn't fail
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeEventsCursor parses the cursor of Encode, it returns ErrDBEventsCursorInvalid for the malformed cursor.
+func DecodeEventsCursor(s string) (*EventsCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrDBEventsCursorInvalid
+	}
+
+	var c EventsCursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, ErrDBEventsCursorInvalid
+	}
+
+	if !IsValidSortField(c.Sort.Field) || c.ID == 0 {
+		return nil, ErrDBEventsCursorInvalid
+	}
+
+	// the cursor has only the value of the sort field
+	if c.Sort.Field == SortImpact && (c.Impact == nil || c.Time != nil) ||
+		c.Sort.Field != SortImpact && (c.Time == nil || c.Impact != nil) {
+		return nil, ErrDBEventsCursorInvalid
+	}
+
+	return &c, nil
+}
+
+func (c *EventsCursor) value() any {
+	if c.Impact != nil {
+		return *c.Impact
+	}
+	return *c.Time
+}
+
+// EventsSort returns the order of the events, it's false if the events are sorted by the search rank.
+// The explicit sort and the cursor take precedence over the search rank.
+func (p *IncidentsParams) EventsSort() (EventsSort, bool) {
+	switch {
+	case p.After != nil:
+		return p.After.Sort, true
+	case p.Sort != nil:
+		return *p.Sort, true
+	case p.Search != "":
+		return EventsSort{}, false
+	default:
+		return DefaultEventsSort(), true
+	}
+}
+
+// applyEventsCursor returns the events after the cursor in the order of the cursor.
+func applyEventsCursor(base *gorm.DB, c *EventsCursor) *gorm.DB {
+	op := ">"
+	if c.Sort.Desc {
+		op = "<"
+	}
+
+	return base.Where("("+sortExpressions[c.Sort.Field]+", incident.id) "+op+" (?, ?)", c.value(), c.ID)
+}
+
+// eventsOrder sorts the events by the EventsSort of the params.
+func eventsOrder(params *IncidentsParams) clause.OrderBy {
+	sort, ok := params.EventsSort()
+	if !ok {
+		return searchOrder(params.Search)
+	}
+
+	return clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: sortExpressions[sort.Field], Raw: true}, Desc: sort.Desc},
+		{Column: clause.Column{Name: "incident.id", Raw: true}, Desc: sort.Desc},
+	}}
+}
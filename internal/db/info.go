@@ -10,7 +10,7 @@ func (db *DB) GetInfoEvents(after uint) ([]*Incident, error) {
 	var incidents []*Incident
 
 	r := db.g.Model(&Incident{}).
-		Preload("Statuses").
+		Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB { return db.Select("ID") })
 
 	r.Where("incident.type = ?", event.TypeInformation)
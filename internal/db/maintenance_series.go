@@ -0,0 +1,123 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func (db *DB) GetMaintenanceSeriesList() ([]*MaintenanceSeries, error) {
+	var series []*MaintenanceSeries
+	r := db.g.Model(&MaintenanceSeries{}).Preload("Components", selectComponentIDs).Order("id").Find(&series)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+
+	return series, nil
+}
+
+func (db *DB) GetMaintenanceSeries(id uint) (*MaintenanceSeries, error) {
+	series := &MaintenanceSeries{}
+	r := db.g.Model(&MaintenanceSeries{}).Preload("Components", selectComponentIDs).Where("id = ?", id).First(series)
+	if r.Error != nil {
+		if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrDBMaintenanceSeriesDSNotExist
+		}
+		return nil, r.Error
+	}
+
+	return series, nil
+}
+
+func (db *DB) SaveMaintenanceSeries(series *MaintenanceSeries) (uint, error) {
+	if err := db.g.Create(series).Error; err != nil {
+		return 0, err
+	}
+
+	return series.ID, nil
+}
+
+// ModifyMaintenanceSeries saves all fields and the components of the series,
+// the occurrences are updated by the checker.
+func (db *DB) ModifyMaintenanceSeries(series *MaintenanceSeries) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Components").Save(series).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(series).Association("Components").Replace(series.Components)
+	})
+}
+
+// CancelMaintenanceSeries sets the cancellation date, the upcoming occurrences are cancelled by the checker.
+func (db *DB) CancelMaintenanceSeries(id uint) (*MaintenanceSeries, error) {
+	series, err := db.GetMaintenanceSeries(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if series.CancelledAt != nil {
+		return series, nil
+	}
+
+	now := time.Now().UTC()
+	if err = db.g.Model(series).Updates(map[string]any{"cancelled_at": now, "modified_at": now}).Error; err != nil {
+		return nil, err
+	}
+	series.CancelledAt = &now
+
+	return series, nil
+}
+
+// GetMaintenanceSeriesToCheck returns the active series and the cancelled series with the planned occurrences.
+func (db *DB) GetMaintenanceSeriesToCheck(now time.Time) ([]*MaintenanceSeries, error) {
+	var series []*MaintenanceSeries
+	r := db.g.Model(&MaintenanceSeries{}).
+		Where("cancelled_at IS NULL OR EXISTS (?)",
+			db.g.Model(&Incident{}).Select("1").
+				Where("incident.series_id = maintenance_series.id AND incident.status = ? AND incident.start_date > ?",
+					event.MaintenancePlanned, now),
+		).
+		Preload("Components", selectComponentIDs).
+		Order("id").
+		Find(&series)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+
+	return series, nil
+}
+
+// GetSeriesOccurrences returns the occurrences of the series, which are scheduled by the rule after the date.
+func (db *DB) GetSeriesOccurrences(seriesID uint, after time.Time) ([]*Incident, error) {
+	var incidents []*Incident
+	r := db.g.Model(&Incident{}).
+		Where("series_id = ? AND series_occurrence > ?", seriesID, after).
+		Preload("Statuses", orderStatuses).
+		Preload("Components", selectComponentIDs).
+		Order("series_occurrence").
+		Find(&incidents)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+
+	return incidents, nil
+}
+
+// ReplaceEventComponents sets the components of the event, the other components are removed from it.
+func (db *DB) ReplaceEventComponents(inc *Incident, components []Component) error {
+	if err := db.g.Model(inc).Association("Components").Replace(components); err != nil {
+		return err
+	}
+	inc.Components = components
+
+	return nil
+}
+
+// selectComponentIDs preloads only the IDs of the components.
+func selectComponentIDs(tx *gorm.DB) *gorm.DB {
+	return tx.Select("ID").Order("id")
+}
@@ -1,6 +1,9 @@
 package db
 
 import (
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/stackmon/otc-status-dashboard/internal/event"
@@ -10,7 +13,7 @@ func (db *DB) GetMaintenances(after uint) ([]*Incident, error) {
 	var incidents []*Incident
 
 	r := db.g.Model(&Incident{}).
-		Preload("Statuses").
+		Preload("Statuses", orderStatuses).
 		Preload("Components", func(db *gorm.DB) *gorm.DB { return db.Select("ID") })
 
 	r.Where("incident.type = ?", event.TypeMaintenance)
@@ -26,3 +29,52 @@ func (db *DB) GetMaintenances(after uint) ([]*Incident, error) {
 	}
 	return incidents, nil
 }
+
+// ApproveMaintenance moves the maintenance from "pending review" to "reviewed" status.
+// The update is conditional, so only the first approval for the given version succeeds.
+func (db *DB) ApproveMaintenance(id uint, version int, text string) (*Incident, error) {
+	now := time.Now().UTC()
+
+	err := db.transaction(func(tx *gorm.DB) error {
+		var stored Incident
+		if r := tx.Model(&Incident{}).Where("id = ?", id).First(&stored); r.Error != nil {
+			if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+				return ErrDBIncidentDSNotExist
+			}
+			return r.Error
+		}
+
+		if stored.Status != event.MaintenancePendingReview {
+			return ErrDBMaintenanceNotPendingReview
+		}
+		if stored.Version != version {
+			return ErrDBMaintenanceVersionConflict
+		}
+
+		r := tx.Model(&Incident{}).
+			Where("id = ? AND status = ? AND version = ?", id, event.MaintenancePendingReview, version).
+			Updates(map[string]interface{}{
+				"status":      event.MaintenanceReviewed,
+				"version":     gorm.Expr("version + 1"),
+				"modified_at": now,
+			})
+		if r.Error != nil {
+			return r.Error
+		}
+		if r.RowsAffected == 0 {
+			return ErrDBMaintenanceVersionConflict
+		}
+
+		return tx.Create(&IncidentStatus{
+			IncidentID: id,
+			Status:     event.MaintenanceReviewed,
+			Text:       text,
+			Timestamp:  now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return db.GetIncident(int(id))
+}
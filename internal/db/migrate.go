@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // file source for migrations
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/stackmon/otc-status-dashboard/db/migrations"
+)
+
+// migrationLockKey is the key of the postgres advisory lock, which serialises the migrations and
+// the schema checks of the replicas. It differs from the lock of the migrate driver,
+// which is held only while the migrations are applied.
+const migrationLockKey int64 = 7_301_152_025
+
+// SchemaVersion is the state of the database schema.
+type SchemaVersion struct {
+	// Current is the applied version, it's 0 if no migrations are applied.
+	Current uint
+	// Latest is the version of the last migration of the binary.
+	Latest uint
+	Dirty  bool
+}
+
+func (v *SchemaVersion) Valid() bool {
+	return v.Current == v.Latest && !v.Dirty
+}
+
+func (v *SchemaVersion) String() string {
+	return fmt.Sprintf("version: %d, latest: %d, dirty: %t", v.Current, v.Latest, v.Dirty)
+}
+
+// NewMigrate returns the migrations from the directory, the embedded migrations are used if the path is empty.
+// The migrations use the pgx driver of the app, so the connection uri has the same meaning for both.
+func NewMigrate(sqlDB *sql.DB, path string) (*migrate.Migrate, error) {
+	var src source.Driver
+	var err error
+	if path != "" {
+		src, err = source.Open("file://" + path)
+	} else {
+		src, err = iofs.New(migrations.FS, ".")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithInstance(sqlDB, &postgres.Config{})
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("migrations", src, "postgres", driver)
+}
+
+// OpenMigrationsDB opens the database for NewMigrate, the caller closes it after CloseMigrate.
+func OpenMigrationsDB(dsn string) (*sql.DB, error) {
+	return sql.Open("pgx", dsn)
+}
+
+// CloseMigrate closes the source and the database of the migrations.
+func CloseMigrate(m *migrate.Migrate) error {
+	srcErr, dbErr := m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// LatestSchemaVersion returns the version of the last embedded migration.
+func LatestSchemaVersion() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, errNext := src.Next(version)
+		if errors.Is(errNext, fs.ErrNotExist) {
+			return version, nil
+		}
+		if errNext != nil {
+			return 0, errNext
+		}
+		version = next
+	}
+}
+
+// GetSchemaVersion returns the applied and the latest version of the schema.
+func GetSchemaVersion(m *migrate.Migrate) (*SchemaVersion, error) {
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &SchemaVersion{Latest: latest}
+	v.Current, v.Dirty, err = m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// WithMigrationLock runs the function under the advisory lock of the migrations,
+// so the replicas, which start at once, don't apply the migrations concurrently and
+// don't check the schema while another replica changes it.
+func WithMigrationLock(ctx context.Context, sqlDB *sql.DB, fn func() error) error {
+	// the advisory lock belongs to the session, so the lock and the unlock use the same connection
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockKey) //nolint:errcheck
+
+	return fn()
+}
+
+// PrepareSchema checks that the database schema has the version of the embedded migrations.
+// If apply is true, the missing migrations are applied first. The schema with another version,
+// or dirty after a failed migration, returns ErrDBSchemaVersionMismatch.
+func PrepareSchema(ctx context.Context, dsn string, apply bool) (*SchemaVersion, error) {
+	sqlDB, err := OpenMigrationsDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDB.Close()
+
+	m, err := NewMigrate(sqlDB, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to init migrations: %w", err)
+	}
+	defer CloseMigrate(m) //nolint:errcheck
+
+	var version *SchemaVersion
+	err = WithMigrationLock(ctx, sqlDB, func() error {
+		if apply {
+			if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+				return fmt.Errorf("failed to run migrations: %w", err)
+			}
+		}
+
+		version, err = GetSchemaVersion(m)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !version.Valid() {
+		return version, fmt.Errorf("%w: %s", ErrDBSchemaVersionMismatch, version)
+	}
+
+	return version, nil
+}
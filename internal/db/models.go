@@ -2,6 +2,9 @@ package db
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -16,7 +19,7 @@ type Component struct {
 	Incidents  []*Incident     `json:"incidents,omitempty" gorm:"many2many:incident_component_relation"`
 	CreatedAt  *time.Time      `json:"-"`
 	ModifiedAt *time.Time      `json:"-"`
-	DeletedAt  *time.Time      `json:"-"`
+	DeletedAt  *time.Time      `json:"deleted_at,omitempty"`
 }
 
 func (c *Component) TableName() string {
@@ -93,21 +96,39 @@ func (c *ComponentAttr) TableName() string {
 }
 
 // Incident is a db table representation.
+// Creator is the user_id from the JWT token, ContactEmail is used for maintenances and only displayed.
+// Creator and ModifiedBy are set from the actor of the database instance, see DB.WithActor.
+// Version is increased on every manual modification, it's used to detect conflicts during the review.
 type Incident struct {
-	ID          uint             `json:"id"`
-	Text        *string          `json:"text" gorm:"not null"`
-	Description *string          `json:"description" gorm:"type:varchar(500)"`
-	StartDate   *time.Time       `json:"start_date" gorm:"not null"`
-	EndDate     *time.Time       `json:"end_date"`
-	Impact      *int             `json:"impact" gorm:"not null"`
-	Statuses    []IncidentStatus `json:"updates" gorm:"foreignKey:IncidentID"`
-	Status      event.Status     `json:"status" gorm:"type:varchar(50)"`
-	System      bool             `json:"system" gorm:"not null"`
-	Type        string           `json:"type" gorm:"not null"`
-	Components  []Component      `json:"components" gorm:"many2many:incident_component_relation"`
-	CreatedAt   *time.Time       `json:"created_at,omitempty"`
-	ModifiedAt  *time.Time       `json:"modified_at,omitempty"`
-	DeletedAt   *time.Time       `json:"deleted_at,omitempty"`
+	ID           uint             `json:"id"`
+	Text         *string          `json:"text" gorm:"not null"`
+	Description  *string          `json:"description" gorm:"type:varchar(500)"`
+	StartDate    *time.Time       `json:"start_date" gorm:"not null"`
+	EndDate      *time.Time       `json:"end_date"`
+	Impact       *int             `json:"impact" gorm:"not null"`
+	Statuses     []IncidentStatus `json:"updates" gorm:"foreignKey:IncidentID"`
+	Status       event.Status     `json:"status" gorm:"type:varchar(50)"`
+	System       bool             `json:"system" gorm:"not null"`
+	Type         string           `json:"type" gorm:"not null"`
+	Components   []Component      `json:"components" gorm:"many2many:incident_component_relation"`
+	Creator      *string          `json:"creator,omitempty"`
+	ModifiedBy   *string          `json:"modified_by,omitempty"`
+	ContactEmail *string          `json:"contact_email,omitempty"`
+	Version      int              `json:"version" gorm:"not null;default:1"`
+	CreatedAt    *time.Time       `json:"created_at,omitempty"`
+	ModifiedAt   *time.Time       `json:"modified_at,omitempty"`
+	DeletedAt    *time.Time       `json:"deleted_at,omitempty"`
+	// SeriesID is set for the occurrences of the recurring maintenance.
+	// SeriesOccurrence is the start of the occurrence by the recurrence rule, it's kept if the occurrence is moved.
+	// SeriesDetached is set if the occurrence is changed separately, such occurrence isn't updated with the series.
+	SeriesID         *uint              `json:"series_id,omitempty"`
+	Series           *MaintenanceSeries `json:"-" gorm:"foreignKey:SeriesID"`
+	SeriesOccurrence *time.Time         `json:"series_occurrence,omitempty"`
+	SeriesDetached   bool               `json:"series_detached" gorm:"not null;default:false"`
+	// MergedIntoID is set for the closed incident, which is merged into another incident.
+	MergedIntoID *uint `json:"merged_into_id,omitempty"`
+	// ComponentImpacts are the impacts on the components, they are read only and changed by DB.SetComponentImpacts.
+	ComponentImpacts []IncidentComponent `json:"-" gorm:"foreignKey:IncidentID;<-:false"`
 }
 
 func (in *Incident) TableName() string {
@@ -118,8 +139,27 @@ func (in *Incident) Link() string {
 	return fmt.Sprintf("<a href='/incidents/%d'>%s</a>", in.ID, *in.Text)
 }
 
-// BeforeSave GORM hook to set created_at and modified_at.
-func (in *Incident) BeforeSave(_ *gorm.DB) error {
+// ComponentImpact returns the impact of the incident on the component.
+func (in *Incident) ComponentImpact(componentID uint) int {
+	for _, ic := range in.ComponentImpacts {
+		if ic.ComponentID == componentID && ic.Impact != nil {
+			return *ic.Impact
+		}
+	}
+
+	if in.Impact == nil {
+		return 0
+	}
+	return *in.Impact
+}
+
+// HasComponentImpacts checks if the impact is set separately for the components of the incident.
+func (in *Incident) HasComponentImpacts() bool {
+	return slices.ContainsFunc(in.ComponentImpacts, func(ic IncidentComponent) bool { return ic.Impact != nil })
+}
+
+// BeforeSave GORM hook to set created_at, modified_at and modified_by.
+func (in *Incident) BeforeSave(tx *gorm.DB) error {
 	now := time.Now().UTC()
 	if in.CreatedAt == nil {
 		in.CreatedAt = &now
@@ -127,6 +167,18 @@ func (in *Incident) BeforeSave(_ *gorm.DB) error {
 	if in.ModifiedAt == nil {
 		in.ModifiedAt = &now
 	}
+	if actor := actorFromContext(tx); actor != "" {
+		// the column is set for the updates by map too
+		tx.Statement.SetColumn("ModifiedBy", &actor)
+	}
+	return nil
+}
+
+// BeforeCreate GORM hook to set the creator.
+func (in *Incident) BeforeCreate(tx *gorm.DB) error {
+	if actor := actorFromContext(tx); actor != "" && in.Creator == nil {
+		in.Creator = &actor
+	}
 	return nil
 }
 
@@ -137,13 +189,27 @@ func (in *Incident) BeforeUpdate(_ *gorm.DB) error {
 	return nil
 }
 
+// IncidentComponent is the relation of the incident and the component.
+// Impact is the impact of the incident on the component, the relation without the impact has the incident impact.
+type IncidentComponent struct {
+	IncidentID  uint `gorm:"primaryKey"`
+	ComponentID uint `gorm:"primaryKey"`
+	Impact      *int
+}
+
+func (ic *IncidentComponent) TableName() string {
+	return "incident_component_relation"
+}
+
 // IncidentStatus is a db table representation.
+// Author is the actor of the database instance, which created the update, see DB.WithActor.
 type IncidentStatus struct {
 	ID         uint         `json:"-" gorm:"primaryKey;autoIncrement:true;"`
 	IncidentID uint         `json:"-"`
 	Status     event.Status `json:"status"`
 	Text       string       `json:"text"`
 	Timestamp  time.Time    `json:"timestamp"`
+	Author     *string      `json:"author,omitempty"`
 	CreatedAt  *time.Time   `json:"created_at,omitempty"`
 	ModifiedAt *time.Time   `json:"modified_at,omitempty"`
 	DeletedAt  *time.Time   `json:"deleted_at,omitempty"`
@@ -165,9 +231,404 @@ func (is *IncidentStatus) BeforeSave(_ *gorm.DB) error {
 	return nil
 }
 
+// BeforeCreate GORM hook to set the author, the existing updates are saved with the event and are skipped.
+func (is *IncidentStatus) BeforeCreate(tx *gorm.DB) error {
+	if actor := actorFromContext(tx); actor != "" && is.ID == 0 && is.Author == nil {
+		is.Author = &actor
+	}
+	return nil
+}
+
 // BeforeUpdate GORM hook to set modified_at.
 func (is *IncidentStatus) BeforeUpdate(_ *gorm.DB) error {
 	now := time.Now().UTC()
 	is.ModifiedAt = &now
 	return nil
 }
+
+// Webhook is a subscription for the events changes.
+// EventTypes is a comma separated list of event types, the empty value means all types.
+type Webhook struct {
+	ID         uint       `json:"id"`
+	URL        string     `json:"url" gorm:"not null"`
+	Secret     string     `json:"-" gorm:"not null"`
+	EventTypes string     `json:"event_types" gorm:"not null;default:''"`
+	Active     bool       `json:"active" gorm:"not null;default:true"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	ModifiedAt *time.Time `json:"modified_at,omitempty"`
+}
+
+func (w *Webhook) TableName() string {
+	return "webhook"
+}
+
+// Types returns the list of subscribed event types.
+func (w *Webhook) Types() []string {
+	if w.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(w.EventTypes, ",")
+}
+
+// Accepts checks if the webhook is subscribed to the event type.
+func (w *Webhook) Accepts(eventType string) bool {
+	types := w.Types()
+	return len(types) == 0 || slices.Contains(types, eventType)
+}
+
+// BeforeSave GORM hook to set created_at and modified_at.
+func (w *Webhook) BeforeSave(_ *gorm.DB) error {
+	now := time.Now().UTC()
+	if w.CreatedAt == nil {
+		w.CreatedAt = &now
+	}
+	if w.ModifiedAt == nil {
+		w.ModifiedAt = &now
+	}
+	return nil
+}
+
+// BeforeUpdate GORM hook to set modified_at.
+func (w *Webhook) BeforeUpdate(_ *gorm.DB) error {
+	now := time.Now().UTC()
+	w.ModifiedAt = &now
+	return nil
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is a single notification for the webhook, it's used as an outbox and as a delivery log.
+type WebhookDelivery struct {
+	ID            uint       `json:"id"`
+	WebhookID     uint       `json:"webhook_id" gorm:"not null"`
+	Webhook       *Webhook   `json:"-"`
+	IncidentID    uint       `json:"event_id" gorm:"not null"`
+	Action        string     `json:"action" gorm:"not null"`
+	Payload       string     `json:"payload" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null"`
+	ResponseCode  *int       `json:"response_code,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+func (wd *WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+// StreamMessage is a change of the event, it's stored to resume the stream with Last-Event-ID.
+type StreamMessage struct {
+	ID         uint       `json:"id"`
+	IncidentID uint       `json:"event_id" gorm:"not null"`
+	Action     string     `json:"action" gorm:"not null"`
+	Payload    string     `json:"payload" gorm:"not null"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+func (sm *StreamMessage) TableName() string {
+	return "event_stream"
+}
+
+// Region is a location of the components, the component attribute "region" refers to the region name.
+type Region struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name" gorm:"not null;unique"`
+	Description string     `json:"description" gorm:"not null;default:''"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ModifiedAt  *time.Time `json:"modified_at,omitempty"`
+}
+
+func (r *Region) TableName() string {
+	return "region"
+}
+
+// BeforeSave GORM hook to set created_at and modified_at.
+func (r *Region) BeforeSave(_ *gorm.DB) error {
+	now := time.Now().UTC()
+	if r.CreatedAt == nil {
+		r.CreatedAt = &now
+	}
+	if r.ModifiedAt == nil {
+		r.ModifiedAt = &now
+	}
+	return nil
+}
+
+// BeforeUpdate GORM hook to set modified_at.
+func (r *Region) BeforeUpdate(_ *gorm.DB) error {
+	now := time.Now().UTC()
+	r.ModifiedAt = &now
+	return nil
+}
+
+// AuthToken keeps the user tokens between the authentication callback and the token request of the client.
+type AuthToken struct {
+	Key       string    `gorm:"primaryKey"`
+	Data      string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt *time.Time
+}
+
+func (at *AuthToken) TableName() string {
+	return "auth_token"
+}
+
+// APIToken is a token of an integration, only the SHA256 hash of the token is stored.
+// Scopes, Components and Regions are comma separated lists, the empty Components and Regions mean no restrictions.
+type APIToken struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name" gorm:"not null"`
+	Owner      string     `json:"owner" gorm:"not null"`
+	TokenHash  string     `json:"-" gorm:"not null;unique"`
+	Scopes     string     `json:"scopes" gorm:"not null"`
+	Components string     `json:"components" gorm:"not null;default:''"`
+	Regions    string     `json:"regions" gorm:"not null;default:''"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+func (at *APIToken) TableName() string {
+	return "api_token"
+}
+
+func (at *APIToken) ScopeList() []string {
+	return splitList(at.Scopes)
+}
+
+func (at *APIToken) RegionList() []string {
+	return splitList(at.Regions)
+}
+
+func (at *APIToken) ComponentIDs() []uint {
+	return parseIDs(at.Components)
+}
+
+// IsActive checks that the token is not revoked or expired.
+func (at *APIToken) IsActive(now time.Time) bool {
+	if at.RevokedAt != nil {
+		return false
+	}
+	return at.ExpiresAt == nil || at.ExpiresAt.After(now)
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// parseIDs parses a comma separated list of IDs, the invalid values are skipped.
+func parseIDs(value string) []uint {
+	values := splitList(value)
+	ids := make([]uint, 0, len(values))
+	for _, v := range values {
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+
+	return ids
+}
+
+// AuditRecord is a change of the event.
+// Changes is a JSON object with the changed fields and their values before and after the change.
+// State is a JSON object with the event fields after the change, the changes of the next record are calculated from it.
+type AuditRecord struct {
+	ID         uint       `json:"id"`
+	IncidentID uint       `json:"event_id" gorm:"not null"`
+	Action     string     `json:"action" gorm:"not null"`
+	Actor      string     `json:"actor" gorm:"not null;default:''"`
+	Changes    string     `json:"changes" gorm:"not null"`
+	State      string     `json:"-" gorm:"not null"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+func (ar *AuditRecord) TableName() string {
+	return "audit_log"
+}
+
+// MaintenanceSeries is the recurring maintenance, the checker creates its occurrences as maintenance events.
+// StartDate and EndDate are the first occurrence, every occurrence has the same duration.
+type MaintenanceSeries struct {
+	ID           uint        `json:"id"`
+	Title        string      `json:"title" gorm:"not null"`
+	Description  *string     `json:"description,omitempty" gorm:"type:varchar(500)"`
+	Components   []Component `json:"components" gorm:"many2many:maintenance_series_component_relation"`
+	RRule        string      `json:"rrule" gorm:"column:rrule;not null"`
+	StartDate    time.Time   `json:"start_date" gorm:"not null"`
+	EndDate      time.Time   `json:"end_date" gorm:"not null"`
+	ContactEmail *string     `json:"contact_email,omitempty"`
+	Creator      *string     `json:"creator,omitempty"`
+	CancelledAt  *time.Time  `json:"cancelled_at,omitempty"`
+	CreatedAt    *time.Time  `json:"created_at,omitempty"`
+	ModifiedAt   *time.Time  `json:"modified_at,omitempty"`
+}
+
+func (ms *MaintenanceSeries) TableName() string {
+	return "maintenance_series"
+}
+
+// BeforeSave GORM hook to set created_at and modified_at.
+func (ms *MaintenanceSeries) BeforeSave(_ *gorm.DB) error {
+	now := time.Now().UTC()
+	if ms.CreatedAt == nil {
+		ms.CreatedAt = &now
+	}
+	ms.ModifiedAt = &now
+	return nil
+}
+
+// BeforeCreate GORM hook to set the creator.
+func (ms *MaintenanceSeries) BeforeCreate(tx *gorm.DB) error {
+	if actor := actorFromContext(tx); actor != "" && ms.Creator == nil {
+		ms.Creator = &actor
+	}
+	return nil
+}
+
+func (ms *MaintenanceSeries) ComponentIDs() []uint {
+	ids := make([]uint, len(ms.Components))
+	for i, comp := range ms.Components {
+		ids[i] = comp.ID
+	}
+
+	return ids
+}
+
+// Duration returns the duration of every occurrence.
+func (ms *MaintenanceSeries) Duration() time.Duration {
+	return ms.EndDate.Sub(ms.StartDate)
+}
+
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Alert is the Alertmanager alert, which opened or escalated the system incidents of the components.
+// The alert is identified by the fingerprint and the start, the start is stored with microsecond precision.
+// Components is a comma separated list of the component IDs.
+type Alert struct {
+	ID          uint
+	Fingerprint string    `gorm:"not null"`
+	StartsAt    time.Time `gorm:"not null"`
+	EndsAt      *time.Time
+	Status      string `gorm:"not null"`
+	Title       string `gorm:"not null"`
+	Impact      int    `gorm:"not null"`
+	Components  string `gorm:"not null"`
+	CreatedAt   *time.Time
+	ModifiedAt  *time.Time
+}
+
+func (a *Alert) TableName() string {
+	return "alert"
+}
+
+// BeforeSave GORM hook to set created_at and modified_at.
+func (a *Alert) BeforeSave(_ *gorm.DB) error {
+	now := time.Now().UTC()
+	if a.CreatedAt == nil {
+		a.CreatedAt = &now
+	}
+	a.ModifiedAt = &now
+	return nil
+}
+
+func (a *Alert) ComponentIDs() []uint {
+	return parseIDs(a.Components)
+}
+
+// Subscriber is the email subscription to the events of the components and the regions.
+// Components and Regions are comma separated lists, the subscription is active after the confirmation.
+// The tokens are stored as is, because they are used in the links of every message.
+type Subscriber struct {
+	ID               uint       `json:"id"`
+	Email            string     `json:"email" gorm:"not null"`
+	Components       string     `json:"components" gorm:"not null;default:''"`
+	Regions          string     `json:"regions" gorm:"not null;default:''"`
+	ConfirmToken     string     `json:"-" gorm:"not null;unique"`
+	UnsubscribeToken string     `json:"-" gorm:"not null;unique"`
+	ConfirmedAt      *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+}
+
+func (s *Subscriber) TableName() string {
+	return "subscriber"
+}
+
+func (s *Subscriber) ComponentIDs() []uint {
+	return parseIDs(s.Components)
+}
+
+func (s *Subscriber) RegionList() []string {
+	return splitList(s.Regions)
+}
+
+// Matches checks if any of the components is subscribed directly or by its region.
+func (s *Subscriber) Matches(components []Component) bool {
+	ids := s.ComponentIDs()
+	regions := s.RegionList()
+	for _, comp := range components {
+		if slices.Contains(ids, comp.ID) || slices.Contains(regions, comp.Region()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EmailNotification is the notified state of the event, the next change of the event is compared with it.
+// StatusID is the ID of the latest update of the event.
+type EmailNotification struct {
+	ID         uint
+	IncidentID uint         `gorm:"not null"`
+	Kind       string       `gorm:"not null"`
+	Impact     int          `gorm:"not null"`
+	Status     event.Status `gorm:"not null"`
+	StatusID   uint         `gorm:"not null;default:0"`
+	CreatedAt  *time.Time
+}
+
+func (en *EmailNotification) TableName() string {
+	return "email_notification"
+}
+
+const (
+	EmailMessagePending = "pending"
+	EmailMessageSent    = "sent"
+	EmailMessageFailed  = "failed"
+)
+
+// EmailMessage is the outbox of the messages, the links of the message are added by the sender.
+// IncidentID is empty for the confirmation messages.
+type EmailMessage struct {
+	ID            uint
+	SubscriberID  uint        `gorm:"not null"`
+	Subscriber    *Subscriber `gorm:"foreignKey:SubscriberID"`
+	IncidentID    *uint
+	Kind          string    `gorm:"not null"`
+	Subject       string    `gorm:"not null"`
+	Body          string    `gorm:"not null"`
+	Status        string    `gorm:"not null"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null"`
+	LastError     *string
+	CreatedAt     *time.Time
+	SentAt        *time.Time
+}
+
+func (em *EmailMessage) TableName() string {
+	return "email_message"
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func (db *DB) GetRegions() ([]*Region, error) {
+	var regions []*Region
+	if err := db.g.Model(&Region{}).Order("name").Find(&regions).Error; err != nil {
+		return nil, err
+	}
+
+	return regions, nil
+}
+
+func (db *DB) GetRegion(id uint) (*Region, error) {
+	region := &Region{}
+	r := db.g.Model(&Region{}).Where("id = ?", id).First(region)
+	if r.Error != nil {
+		if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrDBRegionDSNotExist
+		}
+		return nil, r.Error
+	}
+
+	return region, nil
+}
+
+func (db *DB) GetRegionByName(name string) (*Region, error) {
+	region := &Region{}
+	r := db.g.Model(&Region{}).Where("name = ?", name).First(region)
+	if r.Error != nil {
+		if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrDBRegionDSNotExist
+		}
+		return nil, r.Error
+	}
+
+	return region, nil
+}
+
+// RegionExists checks if the region with the name is registered.
+func (db *DB) RegionExists(name string) (bool, error) {
+	var count int64
+	if err := db.g.Model(&Region{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (db *DB) SaveRegion(region *Region) (uint, error) {
+	exists, err := db.RegionExists(region.Name)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, ErrDBRegionExists
+	}
+
+	if err = db.g.Create(region).Error; err != nil {
+		return 0, err
+	}
+
+	return region.ID, nil
+}
+
+// ModifyRegion updates the region, if the name is changed, the region attribute of the components is renamed too.
+func (db *DB) ModifyRegion(region *Region) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		stored := &Region{}
+		if err := tx.Model(&Region{}).Where("id = ?", region.ID).First(stored).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDBRegionDSNotExist
+			}
+			return err
+		}
+
+		if stored.Name != region.Name {
+			var count int64
+			if err := tx.Model(&Region{}).Where("name = ?", region.Name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrDBRegionExists
+			}
+
+			err := tx.Model(&ComponentAttr{}).
+				Where("name = ? AND value = ?", regionAttrName, stored.Name).
+				Update("value", region.Name).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(region).Select("name", "description", "modified_at").Updates(region).Error
+	})
+}
+
+// DeleteRegion removes the region, it's not allowed if any not deleted component refers to it.
+func (db *DB) DeleteRegion(id uint) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		region := &Region{}
+		if err := tx.Model(&Region{}).Where("id = ?", id).First(region).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDBRegionDSNotExist
+			}
+			return err
+		}
+
+		var count int64
+		err := tx.Model(&ComponentAttr{}).
+			Joins("JOIN component c ON c.id = component_attribute.component_id").
+			Where("component_attribute.name = ? AND component_attribute.value = ?", regionAttrName, region.Name).
+			Where("c.deleted_at IS NULL").
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDBRegionInUse
+		}
+
+		return tx.Delete(region).Error
+	})
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The search expressions use the english configuration and should be the same as the expressions
+// of the indexes in the 000016_event_search migration, otherwise the indexes are not used.
+const (
+	eventSearchVector = "(setweight(to_tsvector('english', coalesce(incident.text, '')), 'A') || " +
+		"setweight(to_tsvector('english', coalesce(incident.description, '')), 'B'))"
+	updateSearchVector = "to_tsvector('english', incident_status.text)"
+	// searchQuery supports the web search syntax: "quoted phrases", OR and -excluded words.
+	searchQuery = "websearch_to_tsquery('english', @search)"
+
+	searchCondition = "(" + eventSearchVector + " @@ " + searchQuery + " OR EXISTS (" +
+		"SELECT 1 FROM incident_status WHERE incident_status.incident_id = incident.id AND " +
+		updateSearchVector + " @@ " + searchQuery + "))"
+	// searchRank is the best rank of the title and the description or of the updates,
+	// the matches of the title are ranked higher by the weights.
+	searchRank = "GREATEST(ts_rank(" + eventSearchVector + ", " + searchQuery + "), COALESCE((" +
+		"SELECT max(ts_rank(" + updateSearchVector + ", " + searchQuery + ")) FROM incident_status " +
+		"WHERE incident_status.incident_id = incident.id), 0))"
+
+	// the snippets are HTML escaped, only the matched words are wrapped in <mark> tags
+	searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+	searchHeadline        = "ts_headline('english', replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), " +
+		"'>', '&gt;'), " + searchQuery + ", '" + searchHeadlineOptions + "')"
+)
+
+// EventHighlight is the snippets of the event fields, which match the search.
+type EventHighlight struct {
+	IncidentID  uint
+	Title       string
+	Description string
+	// Updates are the snippets of the matched updates by the IncidentStatus ID.
+	Updates map[uint]string
+}
+
+func applySearchFilter(base *gorm.DB, search string) *gorm.DB {
+	return base.Where(searchCondition, map[string]any{"search": search})
+}
+
+// searchOrder sorts the events by the search rank and by the start date.
+func searchOrder(search string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.NamedExpr{
+		SQL:  searchRank + " DESC, incident.start_date DESC, incident.id DESC",
+		Vars: []any{map[string]any{"search": search}},
+	}}
+}
+
+// headline returns the expression of the highlighted snippet of the column.
+func headline(column string) string {
+	return fmt.Sprintf(searchHeadline, column)
+}
+
+// GetEventHighlights returns the highlighted snippets of the events by the search.
+// The title is always returned, the description and the updates only if they match.
+func (db *DB) GetEventHighlights(search string, eventIDs []uint) (map[uint]*EventHighlight, error) {
+	highlights := make(map[uint]*EventHighlight, len(eventIDs))
+	if search == "" || len(eventIDs) == 0 {
+		return highlights, nil
+	}
+
+	args := map[string]any{"search": search, "ids": eventIDs}
+
+	var events []struct {
+		ID          uint
+		Title       string
+		Description string
+		Matched     bool
+	}
+	err := db.g.Raw(
+		"SELECT incident.id, "+
+			headline("incident.text")+" AS title, "+
+			headline("coalesce(incident.description, '')")+" AS description, "+
+			"to_tsvector('english', coalesce(incident.description, '')) @@ "+searchQuery+" AS matched "+
+			"FROM incident WHERE incident.id IN @ids", args,
+	).Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range events {
+		h := &EventHighlight{IncidentID: e.ID, Title: e.Title, Updates: make(map[uint]string)}
+		if e.Matched {
+			h.Description = e.Description
+		}
+		highlights[e.ID] = h
+	}
+
+	var updates []struct {
+		ID         uint
+		IncidentID uint
+		Text       string
+	}
+	err = db.g.Raw(
+		"SELECT incident_status.id, incident_status.incident_id, "+headline("incident_status.text")+" AS text "+
+			"FROM incident_status WHERE incident_status.incident_id IN @ids AND "+
+			updateSearchVector+" @@ "+searchQuery, args,
+	).Scan(&updates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range updates {
+		if h, ok := highlights[u.IncidentID]; ok {
+			h.Updates[u.ID] = u.Text
+		}
+	}
+
+	return highlights, nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+// StatusStats is the current state of the dashboard, it's collected from the database on every scrape.
+type StatusStats struct {
+	// OpenIncidentsByImpact is the number of not finished incidents by the impact.
+	OpenIncidentsByImpact map[int]int64
+	// ActiveMaintenances is the number of approved maintenances, which are in progress now.
+	ActiveMaintenances int64
+	// ImpactedComponentsByRegion is the number of components affected by open incidents by the region.
+	ImpactedComponentsByRegion map[string]int64
+}
+
+// incidentImpacts are the impacts of incidents, every impact is reported even if there are no open incidents.
+var incidentImpacts = []int{1, 2, 3} //nolint:gochecknoglobals
+
+// GetStatusStats returns the current state of the dashboard for the metrics.
+func (db *DB) GetStatusStats() (*StatusStats, error) {
+	stats := &StatusStats{
+		OpenIncidentsByImpact:      make(map[int]int64, len(incidentImpacts)),
+		ImpactedComponentsByRegion: make(map[string]int64),
+	}
+	for _, impact := range incidentImpacts {
+		stats.OpenIncidentsByImpact[impact] = 0
+	}
+
+	var impacts []struct {
+		Impact int
+		Count  int64
+	}
+	err := db.g.Model(&Incident{}).
+		Select("impact, count(*) AS count").
+		Where("type = ? AND end_date IS NULL", event.TypeIncident).
+		Group("impact").
+		Scan(&impacts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range impacts {
+		stats.OpenIncidentsByImpact[row.Impact] = row.Count
+	}
+
+	now := time.Now().UTC()
+	err = db.g.Model(&Incident{}).
+		Where("type = ? AND start_date <= ? AND end_date > ?", event.TypeMaintenance, now, now).
+		Where("status NOT IN (?)", append(event.MaintenanceReviewStatuses(), event.MaintenanceCancelled)).
+		Count(&stats.ActiveMaintenances).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var regions []struct {
+		Region string
+		Count  int64
+	}
+	err = db.g.Model(&Component{}).
+		Select("ca.value AS region, count(DISTINCT component.id) AS count").
+		Joins("JOIN component_attribute ca ON ca.component_id = component.id AND ca.name = ?", regionAttrName).
+		Joins("JOIN incident_component_relation icr ON icr.component_id = component.id").
+		Joins("JOIN incident ON incident.id = icr.incident_id").
+		Where("incident.type = ? AND incident.end_date IS NULL", event.TypeIncident).
+		Where("component.deleted_at IS NULL").
+		Group("ca.value").
+		Scan(&regions).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range regions {
+		stats.ImpactedComponentsByRegion[row.Region] = row.Count
+	}
+
+	return stats, nil
+}
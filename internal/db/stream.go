@@ -0,0 +1,40 @@
+package db
+
+import (
+	"time"
+)
+
+func (db *DB) SaveStreamMessage(msg *StreamMessage) error {
+	return db.g.Create(msg).Error
+}
+
+// GetStreamMessages returns messages after the given ID in the ascending order.
+func (db *DB) GetStreamMessages(afterID uint, limit int) ([]*StreamMessage, error) {
+	var messages []*StreamMessage
+	r := db.g.Model(&StreamMessage{}).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&messages)
+
+	if r.Error != nil {
+		return nil, r.Error
+	}
+
+	return messages, nil
+}
+
+func (db *DB) GetLastStreamMessageID() (uint, error) {
+	var id uint
+	r := db.g.Model(&StreamMessage{}).Select("COALESCE(MAX(id), 0)").Scan(&id)
+	if r.Error != nil {
+		return 0, r.Error
+	}
+
+	return id, nil
+}
+
+func (db *DB) DeleteStreamMessagesBefore(before time.Time) (int64, error) {
+	r := db.g.Where("created_at < ?", before).Delete(&StreamMessage{})
+	return r.RowsAffected, r.Error
+}
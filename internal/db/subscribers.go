@@ -0,0 +1,168 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveSubscriber stores the unconfirmed subscriber with the confirmation message.
+func (db *DB) SaveSubscriber(sub *Subscriber, confirmation *EmailMessage) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sub).Error; err != nil {
+			return err
+		}
+
+		confirmation.SubscriberID = sub.ID
+		return tx.Create(confirmation).Error
+	})
+}
+
+// GetSubscribersByEmail returns the confirmed and the unconfirmed subscriptions of the email.
+func (db *DB) GetSubscribersByEmail(email string) ([]*Subscriber, error) {
+	var subscribers []*Subscriber
+	if err := db.g.Model(&Subscriber{}).Where("email = ?", email).Order("id").Find(&subscribers).Error; err != nil {
+		return nil, err
+	}
+
+	return subscribers, nil
+}
+
+func (db *DB) GetConfirmedSubscribers() ([]*Subscriber, error) {
+	var subscribers []*Subscriber
+	r := db.g.Model(&Subscriber{}).Where("confirmed_at IS NOT NULL").Order("id").Find(&subscribers)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+
+	return subscribers, nil
+}
+
+// ConfirmSubscriber confirms the subscription, the other subscriptions of the email are replaced by it.
+// The repeated confirmation returns the confirmed subscriber.
+func (db *DB) ConfirmSubscriber(confirmToken string) (*Subscriber, error) {
+	sub := &Subscriber{}
+	err := db.transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("confirm_token = ?", confirmToken).First(sub).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDBSubscriberDSNotExist
+			}
+			return err
+		}
+
+		if sub.ConfirmedAt != nil {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		if err := tx.Model(sub).Update("confirmed_at", now).Error; err != nil {
+			return err
+		}
+		sub.ConfirmedAt = &now
+
+		return tx.Where("email = ? AND id <> ?", sub.Email, sub.ID).Delete(&Subscriber{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// DeleteSubscriber removes the subscription by the unsubscribe token, the pending messages are removed too.
+func (db *DB) DeleteSubscriber(unsubscribeToken string) (*Subscriber, error) {
+	sub := &Subscriber{}
+	r := db.g.Where("unsubscribe_token = ?", unsubscribeToken).First(sub)
+	if r.Error != nil {
+		if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrDBSubscriberDSNotExist
+		}
+		return nil, r.Error
+	}
+
+	if err := db.g.Delete(sub).Error; err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// DeleteUnconfirmedSubscribers removes the subscriptions, which are not confirmed before the date.
+func (db *DB) DeleteUnconfirmedSubscribers(before time.Time) (int64, error) {
+	r := db.g.Where("confirmed_at IS NULL AND created_at < ?", before.UTC()).Delete(&Subscriber{})
+	return r.RowsAffected, r.Error
+}
+
+// GetLastEmailNotification returns the last notified state of the event.
+func (db *DB) GetLastEmailNotification(incidentID uint) (*EmailNotification, error) {
+	notification := &EmailNotification{}
+	r := db.g.Where("incident_id = ?", incidentID).Order("id DESC").First(notification)
+	if r.Error != nil {
+		if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrDBEmailNotificationDSNotExist
+		}
+		return nil, r.Error
+	}
+
+	return notification, nil
+}
+
+// SaveEmailNotification stores the notified state of the event with the messages for the subscribers.
+func (db *DB) SaveEmailNotification(notification *EmailNotification, messages []*EmailMessage) error {
+	return db.transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(notification).Error; err != nil {
+			return err
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		return tx.Create(messages).Error
+	})
+}
+
+// ClaimDueEmailMessages returns pending messages, which should be sent now, and claims them for the lease period.
+// The rows are locked with SKIP LOCKED, so the senders of other replicas get the other messages.
+// The claimed messages are sent again after the lease, if the sender doesn't update them.
+func (db *DB) ClaimDueEmailMessages(limit int, lease time.Duration) ([]*EmailMessage, error) {
+	var messages []*EmailMessage
+	err := db.transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		var ids []uint
+		r := tx.Model(&EmailMessage{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", EmailMessagePending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Pluck("id", &ids)
+		if r.Error != nil {
+			return r.Error
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		r = tx.Model(&EmailMessage{}).Where("id IN (?)", ids).Update("next_attempt_at", now.Add(lease))
+		if r.Error != nil {
+			return r.Error
+		}
+
+		return tx.Model(&EmailMessage{}).Preload("Subscriber").Where("id IN (?)", ids).Order("id").Find(&messages).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (db *DB) ModifyEmailMessage(message *EmailMessage) error {
+	r := db.g.Model(message).
+		Select("status", "attempts", "next_attempt_at", "last_error", "sent_at").
+		Updates(message)
+	return r.Error
+}
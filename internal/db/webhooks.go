@@ -0,0 +1,143 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookDeliveriesParams struct {
+	Status string
+	Limit  int
+}
+
+func (db *DB) GetWebhooks() ([]*Webhook, error) {
+	var webhooks []*Webhook
+	if err := db.g.Model(&Webhook{}).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (db *DB) GetActiveWebhooks() ([]*Webhook, error) {
+	var webhooks []*Webhook
+	if err := db.g.Model(&Webhook{}).Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (db *DB) GetWebhook(id uint) (*Webhook, error) {
+	webhook := &Webhook{}
+	r := db.g.Model(&Webhook{}).Where("id = ?", id).First(webhook)
+	if r.Error != nil {
+		if errors.Is(r.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrDBWebhookDSNotExist
+		}
+		return nil, r.Error
+	}
+
+	return webhook, nil
+}
+
+func (db *DB) SaveWebhook(webhook *Webhook) (uint, error) {
+	if err := db.g.Create(webhook).Error; err != nil {
+		return 0, err
+	}
+
+	return webhook.ID, nil
+}
+
+func (db *DB) ModifyWebhook(webhook *Webhook) error {
+	// Select is used to update the zero values, like "active": false
+	r := db.g.Model(webhook).Select("url", "secret", "event_types", "active", "modified_at").Updates(webhook)
+	return r.Error
+}
+
+func (db *DB) DeleteWebhook(id uint) error {
+	r := db.g.Delete(&Webhook{}, id)
+	if r.Error != nil {
+		return r.Error
+	}
+
+	if r.RowsAffected == 0 {
+		return ErrDBWebhookDSNotExist
+	}
+
+	return nil
+}
+
+func (db *DB) SaveWebhookDeliveries(deliveries []*WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return db.g.Create(deliveries).Error
+}
+
+// ClaimDueWebhookDeliveries returns pending deliveries, which should be sent now, and claims them for the lease period.
+// The rows are locked with SKIP LOCKED, so the senders of other replicas get the other deliveries.
+// The claimed deliveries are sent again after the lease, if the sender doesn't update them.
+func (db *DB) ClaimDueWebhookDeliveries(limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := db.transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		var ids []uint
+		r := tx.Model(&WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Pluck("id", &ids)
+		if r.Error != nil {
+			return r.Error
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		r = tx.Model(&WebhookDelivery{}).Where("id IN (?)", ids).Update("next_attempt_at", now.Add(lease))
+		if r.Error != nil {
+			return r.Error
+		}
+
+		return tx.Model(&WebhookDelivery{}).Preload("Webhook").Where("id IN (?)", ids).Order("id").Find(&deliveries).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (db *DB) ModifyWebhookDelivery(delivery *WebhookDelivery) error {
+	r := db.g.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_code", "last_error", "delivered_at").
+		Updates(delivery)
+	return r.Error
+}
+
+// GetWebhookDeliveries returns the delivery log for the webhook, the latest deliveries go first.
+func (db *DB) GetWebhookDeliveries(webhookID uint, params *WebhookDeliveriesParams) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	r := db.g.Model(&WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	if params != nil && params.Status != "" {
+		r = r.Where("status = ?", params.Status)
+	}
+
+	if params != nil && params.Limit > 0 {
+		r = r.Limit(params.Limit)
+	}
+
+	if err := r.Order("id DESC").Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
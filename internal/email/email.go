@@ -0,0 +1,307 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+type Kind string
+
+const (
+	KindConfirmation       Kind = "confirmation"
+	KindIncidentOpened     Kind = "incident.opened"
+	KindIncidentImpact     Kind = "incident.impact_changed"
+	KindIncidentUpdated    Kind = "incident.updated"
+	KindIncidentResolved   Kind = "incident.resolved"
+	KindMaintenancePlanned Kind = "maintenance.planned"
+	KindMaintenanceStarted Kind = "maintenance.started"
+)
+
+const (
+	subjectPrefix = "[Status Dashboard] "
+	timeFormat    = "2006-01-02 15:04 MST"
+)
+
+//nolint:gochecknoglobals
+var headings = map[Kind]string{
+	KindIncidentOpened:     "Incident opened",
+	KindIncidentImpact:     "Incident impact changed",
+	KindIncidentUpdated:    "Incident update",
+	KindIncidentResolved:   "Incident resolved",
+	KindMaintenancePlanned: "Maintenance planned",
+	KindMaintenanceStarted: "Maintenance started",
+}
+
+//nolint:gochecknoglobals
+var templateFuncs = template.FuncMap{
+	"time": func(t time.Time) string { return t.UTC().Format(timeFormat) },
+}
+
+const eventText = `{{.Heading}}: {{.Title}}
+{{if .Impact}}
+Impact: {{.Impact}}{{end}}
+Status: {{.Status}}
+Start: {{time .StartDate}}{{if .EndDate}}
+End: {{time .EndDate}}{{end}}
+
+Components:
+{{range .Components}}- {{.}}
+{{end}}{{if .Description}}
+{{.Description}}
+{{end}}{{with .Update}}
+Update at {{time .Timestamp}}, {{.Status}}:
+{{.Text}}
+{{end}}`
+
+const confirmationText = `Please confirm the subscription to the Status Dashboard notifications.
+
+The messages are sent for the incidents and the maintenances of:
+{{range .}}- {{.}}
+{{end}}`
+
+//nolint:gochecknoglobals
+var (
+	eventTemplate        = template.Must(template.New("event").Funcs(templateFuncs).Parse(eventText))
+	confirmationTemplate = template.Must(template.New("confirmation").Parse(confirmationText))
+)
+
+type eventData struct {
+	Heading     string
+	Title       string
+	Description string
+	Impact      string
+	Status      event.Status
+	StartDate   time.Time
+	EndDate     *time.Time
+	Components  []string
+	Update      *db.IncidentStatus
+}
+
+// NotificationKind returns the kind of the message for the change of the event, the empty kind means no message.
+// The event is compared with the last notified state, last is nil if the event isn't notified yet.
+// The incidents are notified when they are opened, changed, updated or resolved,
+// the maintenances are notified when they are planned and started.
+func NotificationKind(inc *db.Incident, last *db.EmailNotification) Kind {
+	switch inc.Type {
+	case event.TypeIncident:
+		return incidentKind(inc, last)
+	case event.TypeMaintenance:
+		return maintenanceKind(inc, last)
+	}
+
+	return ""
+}
+
+func incidentKind(inc *db.Incident, last *db.EmailNotification) Kind {
+	if last == nil {
+		// the incidents, which are created already closed, are not notified
+		if inc.EndDate != nil {
+			return ""
+		}
+		return KindIncidentOpened
+	}
+
+	if inc.EndDate != nil {
+		if Kind(last.Kind) == KindIncidentResolved {
+			return ""
+		}
+		return KindIncidentResolved
+	}
+
+	if inc.Impact != nil && *inc.Impact != last.Impact {
+		return KindIncidentImpact
+	}
+
+	if update := latestUpdate(inc); update != nil && update.ID != last.StatusID {
+		return KindIncidentUpdated
+	}
+
+	return ""
+}
+
+func maintenanceKind(inc *db.Incident, last *db.EmailNotification) Kind {
+	var lastKind Kind
+	if last != nil {
+		lastKind = Kind(last.Kind)
+	}
+
+	switch inc.Status { //nolint:exhaustive
+	case event.MaintenancePlanned, event.MaintenanceModified:
+		if lastKind == "" {
+			return KindMaintenancePlanned
+		}
+	case event.MaintenanceInProgress:
+		if lastKind != KindMaintenanceStarted {
+			return KindMaintenanceStarted
+		}
+	}
+
+	return ""
+}
+
+// NewNotification returns the notified state of the event.
+func NewNotification(kind Kind, inc *db.Incident) *db.EmailNotification {
+	notification := &db.EmailNotification{IncidentID: inc.ID, Kind: string(kind), Status: inc.Status}
+	if inc.Impact != nil {
+		notification.Impact = *inc.Impact
+	}
+	if update := latestUpdate(inc); update != nil {
+		notification.StatusID = update.ID
+	}
+
+	return notification
+}
+
+// NewEventMessage returns the subject and the text of the message about the event.
+func NewEventMessage(kind Kind, inc *db.Incident) (string, string, error) {
+	heading, ok := headings[kind]
+	if !ok {
+		return "", "", fmt.Errorf("unknown message kind: %s", kind)
+	}
+
+	data := eventData{
+		Heading:    heading,
+		Status:     inc.Status,
+		EndDate:    inc.EndDate,
+		Components: componentNames(inc.Components),
+		Update:     latestUpdate(inc),
+	}
+	if inc.Text != nil {
+		data.Title = *inc.Text
+	}
+	if inc.Description != nil {
+		data.Description = *inc.Description
+	}
+	if inc.StartDate != nil {
+		data.StartDate = *inc.StartDate
+	}
+	if inc.Type == event.TypeIncident && inc.Impact != nil {
+		data.Impact = impactText(*inc.Impact)
+	}
+
+	var body strings.Builder
+	if err := eventTemplate.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+
+	return subjectPrefix + heading + ": " + data.Title, body.String(), nil
+}
+
+// NewConfirmationMessage returns the confirmation message of the subscription,
+// the confirmation link is added by the sender.
+func NewConfirmationMessage(components []db.Component, regions []string) (*db.EmailMessage, error) {
+	items := componentNames(components)
+	for _, region := range regions {
+		items = append(items, "all components in the region "+region)
+	}
+
+	var body strings.Builder
+	if err := confirmationTemplate.Execute(&body, items); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	return &db.EmailMessage{
+		Kind:          string(KindConfirmation),
+		Subject:       subjectPrefix + "Confirm the subscription",
+		Body:          body.String(),
+		Status:        db.EmailMessagePending,
+		NextAttemptAt: now,
+		CreatedAt:     &now,
+	}, nil
+}
+
+// Enqueue stores the messages for the confirmed subscribers of the event components,
+// if the change of the event should be notified. The messages are sent by the Sender.
+func Enqueue(dbInst *db.DB, eventID uint) error {
+	subscribers, err := dbInst.GetConfirmedSubscribers()
+	if err != nil {
+		return err
+	}
+
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	inc, err := dbInst.GetIncident(int(eventID))
+	if err != nil {
+		return err
+	}
+
+	last, err := dbInst.GetLastEmailNotification(eventID)
+	if err != nil && !errors.Is(err, db.ErrDBEmailNotificationDSNotExist) {
+		return err
+	}
+
+	kind := NotificationKind(inc, last)
+	if kind == "" {
+		return nil
+	}
+
+	subject, body, err := NewEventMessage(kind, inc)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var messages []*db.EmailMessage
+	for _, sub := range subscribers {
+		if !sub.Matches(inc.Components) {
+			continue
+		}
+
+		messages = append(messages, &db.EmailMessage{
+			SubscriberID:  sub.ID,
+			IncidentID:    &inc.ID,
+			Kind:          string(kind),
+			Subject:       subject,
+			Body:          body,
+			Status:        db.EmailMessagePending,
+			NextAttemptAt: now,
+			CreatedAt:     &now,
+		})
+	}
+
+	return dbInst.SaveEmailNotification(NewNotification(kind, inc), messages)
+}
+
+func latestUpdate(inc *db.Incident) *db.IncidentStatus {
+	var update *db.IncidentStatus
+	for i := range inc.Statuses {
+		st := &inc.Statuses[i]
+		if update == nil || !st.Timestamp.Before(update.Timestamp) {
+			update = st
+		}
+	}
+
+	return update
+}
+
+func componentNames(components []db.Component) []string {
+	names := make([]string, len(components))
+	for i, comp := range components {
+		names[i] = comp.Name
+		if region := comp.Region(); region != "" {
+			names[i] = fmt.Sprintf("%s (%s)", comp.Name, region)
+		}
+	}
+
+	return names
+}
+
+func impactText(impact int) string {
+	switch impact {
+	case 1:
+		return "minor"
+	case 2: //nolint:mnd
+		return "major"
+	}
+
+	return "outage"
+}
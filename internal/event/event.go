@@ -66,10 +66,20 @@ const (
 	MaintenanceCompleted  Status = "completed"
 	MaintenanceCancelled  Status = "cancelled"
 )
+
+// Review workflow statuses for maintenances created by sd_creators.
+// The flow is: pending review -> reviewed -> planned (set by the checker).
+const (
+	MaintenancePendingReview Status = "pending review"
+	MaintenanceReviewed      Status = "reviewed"
+)
+
 const (
-	maintenancePlannedText    = "Maintenance is planned."
-	maintenanceInProgressText = "Maintenance is in progress."
-	maintenanceCompletedText  = "Maintenance is completed."
+	maintenancePlannedText       = "Maintenance is planned."
+	maintenanceInProgressText    = "Maintenance is in progress."
+	maintenanceCompletedText     = "Maintenance is completed."
+	maintenancePendingReviewText = "Maintenance is waiting for review."
+	maintenanceReviewedText      = "Maintenance is reviewed."
 )
 
 func MaintenancePlannedStatusText() string {
@@ -84,16 +94,36 @@ func MaintenanceCompletedStatusText() string {
 	return maintenanceCompletedText
 }
 
+func MaintenancePendingReviewStatusText() string {
+	return maintenancePendingReviewText
+}
+
+func MaintenanceReviewedStatusText() string {
+	return maintenanceReviewedText
+}
+
 func IsMaintenanceStatus(status Status) bool {
 	switch status {
 	case MaintenancePlanned, MaintenanceInProgress, MaintenanceModified,
-		MaintenanceCompleted, MaintenanceCancelled:
+		MaintenanceCompleted, MaintenanceCancelled,
+		MaintenancePendingReview, MaintenanceReviewed:
 		return true
 	}
 
 	return false
 }
 
+// MaintenanceReviewStatuses returns statuses of maintenances which are not approved yet.
+func MaintenanceReviewStatuses() []Status {
+	return []Status{MaintenancePendingReview, MaintenanceReviewed}
+}
+
+// IsMaintenanceReviewStatus checks if a maintenance is still in the review workflow,
+// such maintenances are not announced publicly yet.
+func IsMaintenanceReviewStatus(status Status) bool {
+	return status == MaintenancePendingReview || status == MaintenanceReviewed
+}
+
 // Info event section
 
 const (
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+const (
+	gormPluginName  = "metrics"
+	gormStartKey    = "metrics:start"
+	unknownDBTable  = "unknown"
+	callbackBefore  = "metrics:before_"
+	callbackAfter   = "metrics:after_"
+	callbackPattern = "*"
+)
+
+// GormPlugin measures the duration of the database queries.
+type GormPlugin struct{}
+
+// InstrumentDB registers the callbacks of the database metrics, the metrics are collected by the process registry.
+func InstrumentDB(dbInst *db.DB) error {
+	return dbInst.Use(&GormPlugin{})
+}
+
+func (p *GormPlugin) Name() string {
+	return gormPluginName
+}
+
+func (p *GormPlugin) Initialize(g *gorm.DB) error {
+	cb := g.Callback()
+
+	return errors.Join(
+		cb.Create().Before(callbackPattern).Register(callbackBefore+"create", startQuery),
+		cb.Create().After(callbackPattern).Register(callbackAfter+"create", finishQuery("create")),
+		cb.Query().Before(callbackPattern).Register(callbackBefore+"query", startQuery),
+		cb.Query().After(callbackPattern).Register(callbackAfter+"query", finishQuery("query")),
+		cb.Update().Before(callbackPattern).Register(callbackBefore+"update", startQuery),
+		cb.Update().After(callbackPattern).Register(callbackAfter+"update", finishQuery("update")),
+		cb.Delete().Before(callbackPattern).Register(callbackBefore+"delete", startQuery),
+		cb.Delete().After(callbackPattern).Register(callbackAfter+"delete", finishQuery("delete")),
+		cb.Row().Before(callbackPattern).Register(callbackBefore+"row", startQuery),
+		cb.Row().After(callbackPattern).Register(callbackAfter+"row", finishQuery("row")),
+		cb.Raw().Before(callbackPattern).Register(callbackBefore+"raw", startQuery),
+		cb.Raw().After(callbackPattern).Register(callbackAfter+"raw", finishQuery("raw")),
+	)
+}
+
+func startQuery(g *gorm.DB) {
+	g.InstanceSet(gormStartKey, time.Now())
+}
+
+func finishQuery(operation string) func(*gorm.DB) {
+	return func(g *gorm.DB) {
+		value, ok := g.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := unknownDBTable
+		if g.Statement != nil && g.Statement.Table != "" {
+			table = g.Statement.Table
+		}
+
+		dbQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
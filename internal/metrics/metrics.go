@@ -0,0 +1,129 @@
+// Package metrics contains the Prometheus metrics of the API, the database layer and the checker.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "status_dashboard"
+
+// Checker names used as the "checker" label.
+const (
+	CheckerMaintenance       = "maintenance"
+	CheckerMaintenanceSeries = "maintenance_series"
+	CheckerInfo              = "info"
+)
+
+// unmatchedRoute is the route label for requests without a registered route,
+// the raw path is not used to keep the label cardinality low.
+const unmatchedRoute = "unmatched"
+
+// Registry keeps the process wide metrics, the metrics of the DB state are added by the API handler.
+var Registry = prometheus.NewRegistry() //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database queries by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	checkerLastRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "checker",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix time of the last checker run.",
+	}, []string{"checker"})
+
+	checkerLastDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "checker",
+		Name:      "last_run_duration_seconds",
+		Help:      "Duration of the last checker run.",
+	}, []string{"checker"})
+
+	checkerLastTransitions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "checker",
+		Name:      "last_run_transitions",
+		Help:      "Number of event status transitions made by the last checker run.",
+	}, []string{"checker"})
+
+	checkerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "checker",
+		Name:      "transitions_total",
+		Help:      "Number of event status transitions made by the checker.",
+	}, []string{"checker"})
+
+	checkerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "checker",
+		Name:      "errors_total",
+		Help:      "Number of failed checker runs.",
+	}, []string{"checker"})
+)
+
+func init() { //nolint:gochecknoinits
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, dbQueryDuration,
+		checkerLastRun, checkerLastDuration, checkerLastTransitions, checkerTransitions, checkerErrors,
+	)
+}
+
+// ObserveRequest records the finished HTTP request, route is the registered route pattern.
+func ObserveRequest(method, route string, status int, latency time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(latency.Seconds())
+}
+
+// ObserveCheckerRun records the checker run, which started at the start time and made the transitions.
+func ObserveCheckerRun(checker string, start time.Time, transitions int, err error) {
+	checkerLastRun.WithLabelValues(checker).Set(float64(start.Unix()))
+	checkerLastDuration.WithLabelValues(checker).Set(time.Since(start).Seconds())
+	checkerLastTransitions.WithLabelValues(checker).Set(float64(transitions))
+	checkerTransitions.WithLabelValues(checker).Add(float64(transitions))
+
+	if err != nil {
+		checkerErrors.WithLabelValues(checker).Inc()
+	}
+}
+
+// Handler returns the HTTP handler for the process wide metrics and the metrics of the additional gatherers.
+func Handler(gatherers ...prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(
+		append(prometheus.Gatherers{Registry}, gatherers...),
+		promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError},
+	)
+}
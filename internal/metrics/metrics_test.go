@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+type statusSourceMock struct {
+	stats *db.StatusStats
+	err   error
+}
+
+func (s *statusSourceMock) GetStatusStats() (*db.StatusStats, error) {
+	return s.stats, s.err
+}
+
+func TestObserveRequest(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404"))
+
+	ObserveRequest(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+	ObserveRequest(http.MethodGet, "/v2/components/:id", http.StatusOK, time.Millisecond)
+
+	assert.InDelta(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")), 0)
+	assert.Positive(t, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/v2/components/:id", "200")))
+}
+
+func TestObserveCheckerRun(t *testing.T) {
+	start := time.Now().Add(-time.Second)
+	errorsBefore := testutil.ToFloat64(checkerErrors.WithLabelValues(CheckerInfo))
+
+	ObserveCheckerRun(CheckerInfo, start, 3, nil)
+	assert.InDelta(t, 3, testutil.ToFloat64(checkerLastTransitions.WithLabelValues(CheckerInfo)), 0)
+	assert.InDelta(t, float64(start.Unix()), testutil.ToFloat64(checkerLastRun.WithLabelValues(CheckerInfo)), 0)
+	assert.GreaterOrEqual(t, testutil.ToFloat64(checkerLastDuration.WithLabelValues(CheckerInfo)), 1.0)
+
+	ObserveCheckerRun(CheckerInfo, start, 0, errors.New("db is down"))
+	assert.InDelta(t, 0, testutil.ToFloat64(checkerLastTransitions.WithLabelValues(CheckerInfo)), 0)
+	assert.InDelta(t, errorsBefore+1, testutil.ToFloat64(checkerErrors.WithLabelValues(CheckerInfo)), 0)
+}
+
+func TestInstrumentDB(t *testing.T) {
+	dbInst, mock, err := db.NewWithMock()
+	require.NoError(t, err)
+	require.NoError(t, InstrumentDB(dbInst))
+
+	mock.ExpectQuery(`^SELECT (.+) FROM "region"`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	_, err = dbInst.GetRegions()
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(dbQueryDuration, "status_dashboard_db_query_duration_seconds"))
+}
+
+func TestStatusCollector(t *testing.T) {
+	source := &statusSourceMock{stats: &db.StatusStats{
+		OpenIncidentsByImpact:      map[int]int64{1: 0, 2: 1, 3: 2},
+		ActiveMaintenances:         1,
+		ImpactedComponentsByRegion: map[string]int64{"EU-DE": 3},
+	}}
+	collector := NewStatusCollector(source, zap.NewNop())
+
+	expected := `
+# HELP status_dashboard_active_maintenances Number of maintenances in progress.
+# TYPE status_dashboard_active_maintenances gauge
+status_dashboard_active_maintenances 1
+# HELP status_dashboard_impacted_components Number of components affected by open incidents by region.
+# TYPE status_dashboard_impacted_components gauge
+status_dashboard_impacted_components{region="EU-DE"} 3
+# HELP status_dashboard_open_incidents Number of open incidents by impact.
+# TYPE status_dashboard_open_incidents gauge
+status_dashboard_open_incidents{impact="1"} 0
+status_dashboard_open_incidents{impact="2"} 1
+status_dashboard_open_incidents{impact="3"} 2
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+
+	t.Log("the process metrics are served even if the status metrics are failed")
+	source.err = errors.New("db is down")
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	w := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+	assert.NotContains(t, w.Body.String(), "status_dashboard_active_maintenances")
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+// StatusSource provides the current state of the dashboard.
+type StatusSource interface {
+	GetStatusStats() (*db.StatusStats, error)
+}
+
+// StatusCollector exports the business metrics of the dashboard.
+type StatusCollector struct {
+	source StatusSource
+	log    *zap.Logger
+
+	openIncidents      *prometheus.Desc
+	activeMaintenances *prometheus.Desc
+	impactedComponents *prometheus.Desc
+}
+
+func NewStatusCollector(source StatusSource, log *zap.Logger) *StatusCollector {
+	return &StatusCollector{
+		source: source,
+		log:    log,
+		openIncidents: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "open_incidents"),
+			"Number of open incidents by impact.",
+			[]string{"impact"}, nil,
+		),
+		activeMaintenances: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_maintenances"),
+			"Number of maintenances in progress.",
+			nil, nil,
+		),
+		impactedComponents: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "impacted_components"),
+			"Number of components affected by open incidents by region.",
+			[]string{"region"}, nil,
+		),
+	}
+}
+
+func (sc *StatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sc.openIncidents
+	ch <- sc.activeMaintenances
+	ch <- sc.impactedComponents
+}
+
+func (sc *StatusCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := sc.source.GetStatusStats()
+	if err != nil {
+		sc.log.Error("failed to collect the status metrics", zap.Error(err))
+		ch <- prometheus.NewInvalidMetric(sc.openIncidents, err)
+		return
+	}
+
+	for impact, count := range stats.OpenIncidentsByImpact {
+		ch <- prometheus.MustNewConstMetric(
+			sc.openIncidents, prometheus.GaugeValue, float64(count), strconv.Itoa(impact),
+		)
+	}
+
+	ch <- prometheus.MustNewConstMetric(sc.activeMaintenances, prometheus.GaugeValue, float64(stats.ActiveMaintenances))
+
+	for region, count := range stats.ImpactedComponentsByRegion {
+		ch <- prometheus.MustNewConstMetric(sc.impactedComponents, prometheus.GaugeValue, float64(count), region)
+	}
+}
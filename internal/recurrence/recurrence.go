@@ -0,0 +1,456 @@
+// Package recurrence implements the subset of the iCalendar recurrence rules (RFC 5545 RRULE),
+// which is used for the recurring maintenances.
+//
+// Supported parts: FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, BYDAY, BYMONTHDAY, COUNT and UNTIL.
+// The occurrences are calculated in UTC, the time of the day is taken from the start of the series.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+const (
+	rulePrefix     = "RRULE:"
+	untilFormat    = "20060102T150405Z"
+	untilDate      = "20060102"
+	maxMonthDay    = 31
+	maxWeekdayNum  = 5
+	daysInWeek     = 7
+	ordinalTeenMin = 11
+	ordinalTeenMax = 13
+	ordinalBase    = 10
+)
+
+var (
+	ErrInvalidRule     = errors.New("recurrence rule is invalid")
+	ErrUnsupportedPart = errors.New("recurrence rule part is not supported")
+	ErrUnsupportedFreq = errors.New("recurrence frequency is not supported, use DAILY, WEEKLY or MONTHLY")
+	ErrCountAndUntil   = errors.New("recurrence rule can't have both COUNT and UNTIL")
+	ErrPartNotAllowed  = errors.New("recurrence rule part is not allowed for the frequency")
+)
+
+// WeekdayNum is the BYDAY value, N is the ordinal of the weekday in the month, 0 means every weekday.
+// The negative N counts from the end of the month, as example -1FR is the last Friday.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule is the parsed recurrence rule.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+func weekdayCodes() map[string]time.Weekday {
+	return map[string]time.Weekday{
+		"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+		"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+	}
+}
+
+func weekdayCode(day time.Weekday) string {
+	return strings.ToUpper(day.String()[:2])
+}
+
+// Parse parses the rule like "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", the "RRULE:" prefix is optional.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), rulePrefix)
+	if value == "" {
+		return nil, ErrInvalidRule
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRule, part)
+		}
+
+		if err := rule.setPart(strings.ToUpper(name), strings.ToUpper(val)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *Rule) setPart(name, val string) error {
+	var err error
+	switch name {
+	case "FREQ":
+		r.Freq = Frequency(val)
+	case "INTERVAL":
+		r.Interval, err = parsePositive(val)
+	case "COUNT":
+		r.Count, err = parsePositive(val)
+	case "UNTIL":
+		r.Until, err = parseUntil(val)
+	case "BYDAY":
+		r.ByDay, err = parseByDay(val)
+	case "BYMONTHDAY":
+		r.ByMonthDay, err = parseByMonthDay(val)
+	case "WKST":
+		// the weeks always start on Monday
+		if val != "MO" {
+			err = fmt.Errorf("%w: WKST=%s", ErrUnsupportedPart, val)
+		}
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedPart, name)
+	}
+
+	return err
+}
+
+func (r *Rule) validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly:
+	case "":
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	default:
+		return ErrUnsupportedFreq
+	}
+
+	if r.Count > 0 && r.Until != nil {
+		return ErrCountAndUntil
+	}
+
+	if r.Freq != Monthly {
+		if len(r.ByMonthDay) > 0 {
+			return fmt.Errorf("%w: BYMONTHDAY", ErrPartNotAllowed)
+		}
+		for _, wd := range r.ByDay {
+			if wd.N != 0 {
+				return fmt.Errorf("%w: BYDAY with the ordinal", ErrPartNotAllowed)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parsePositive(val string) (int, error) {
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: %s should be a positive number", ErrInvalidRule, val)
+	}
+
+	return n, nil
+}
+
+func parseUntil(val string) (*time.Time, error) {
+	t, err := time.Parse(untilFormat, val)
+	if err != nil {
+		var errDate error
+		// the date without the time includes the whole day
+		if t, errDate = time.Parse(untilDate, val); errDate != nil {
+			return nil, fmt.Errorf("%w: UNTIL=%s", ErrInvalidRule, val)
+		}
+		t = t.Add(time.Hour*24 - time.Second)
+	}
+
+	return &t, nil
+}
+
+func parseByDay(val string) ([]WeekdayNum, error) {
+	codes := weekdayCodes()
+	values := strings.Split(val, ",")
+	days := make([]WeekdayNum, 0, len(values))
+	for _, v := range values {
+		if len(v) < 2 { //nolint:mnd
+			return nil, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, v)
+		}
+
+		day, ok := codes[v[len(v)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, v)
+		}
+
+		var n int
+		if prefix := v[:len(v)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -maxWeekdayNum || n > maxWeekdayNum {
+				return nil, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, v)
+			}
+		}
+
+		days = append(days, WeekdayNum{Weekday: day, N: n})
+	}
+
+	return days, nil
+}
+
+func parseByMonthDay(val string) ([]int, error) {
+	values := strings.Split(val, ",")
+	days := make([]int, 0, len(values))
+	for _, v := range values {
+		day, err := strconv.Atoi(v)
+		if err != nil || day == 0 || day < -maxMonthDay || day > maxMonthDay {
+			return nil, fmt.Errorf("%w: BYMONTHDAY=%s", ErrInvalidRule, v)
+		}
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// String returns the normalised rule without the "RRULE:" prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = weekdayCode(wd.Weekday)
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilFormat))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Between returns the occurrences of the series, which starts at the start, in the range [from, to).
+// COUNT is counted from the start of the series, so the occurrences before from are calculated too.
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	start = start.UTC()
+
+	var result []time.Time
+	var count int
+	for period := 0; ; period += r.Interval {
+		periodStart, candidates := r.candidates(start, period)
+		if !periodStart.Before(to) {
+			return result
+		}
+
+		for _, t := range candidates {
+			if t.Before(start) {
+				continue
+			}
+			if (r.Until != nil && t.After(*r.Until)) || !t.Before(to) {
+				return result
+			}
+
+			count++
+			if !t.Before(from) {
+				result = append(result, t)
+			}
+			if r.Count > 0 && count >= r.Count {
+				return result
+			}
+		}
+	}
+}
+
+// candidates returns the beginning of the period and the sorted occurrences in it.
+// The period is the day, the week or the month with the number n since the start.
+func (r *Rule) candidates(start time.Time, n int) (time.Time, []time.Time) {
+	hour, minute, sec := start.Clock()
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, sec, 0, time.UTC)
+	}
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch r.Freq {
+	case Daily:
+		day := startDay.AddDate(0, 0, n)
+		if len(r.ByDay) > 0 && !r.hasWeekday(day.Weekday()) {
+			return day, nil
+		}
+		return day, []time.Time{at(day)}
+	case Weekly:
+		// the weeks start on Monday
+		offset := (int(startDay.Weekday()) + daysInWeek - 1) % daysInWeek
+		week := startDay.AddDate(0, 0, n*daysInWeek-offset)
+		if len(r.ByDay) == 0 {
+			return week, []time.Time{at(week.AddDate(0, 0, offset))}
+		}
+
+		var result []time.Time
+		for i := range daysInWeek {
+			day := week.AddDate(0, 0, i)
+			if r.hasWeekday(day.Weekday()) {
+				result = append(result, at(day))
+			}
+		}
+		return week, result
+	default:
+		month := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		days := r.monthDays(month, start.Day())
+		result := make([]time.Time, len(days))
+		for i, day := range days {
+			result[i] = at(day)
+		}
+		return month, result
+	}
+}
+
+func (r *Rule) hasWeekday(day time.Weekday) bool {
+	return slices.ContainsFunc(r.ByDay, func(wd WeekdayNum) bool { return wd.Weekday == day })
+}
+
+// monthDays returns the sorted days of the month, which match BYMONTHDAY and BYDAY.
+// The day of the series start is used without them.
+func (r *Rule) monthDays(month time.Time, startDay int) []time.Time {
+	last := month.AddDate(0, 1, -1).Day()
+
+	var days []time.Time
+	for d := 1; d <= last; d++ {
+		day := month.AddDate(0, 0, d-1)
+		if r.matchesMonthDay(day, last, startDay) {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+func (r *Rule) matchesMonthDay(day time.Time, last, startDay int) bool {
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		return day.Day() == startDay
+	}
+
+	if len(r.ByMonthDay) > 0 && !slices.ContainsFunc(r.ByMonthDay, func(d int) bool {
+		return d == day.Day() || d < 0 && last+d+1 == day.Day()
+	}) {
+		return false
+	}
+
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	// the ordinal of the weekday from the beginning and from the end of the month
+	n := (day.Day()-1)/daysInWeek + 1
+	fromEnd := -((last-day.Day())/daysInWeek + 1)
+	return slices.ContainsFunc(r.ByDay, func(wd WeekdayNum) bool {
+		return wd.Weekday == day.Weekday() && (wd.N == 0 || wd.N == n || wd.N == fromEnd)
+	})
+}
+
+// Describe returns the human-readable rule for the feeds, as example "every 2 weeks on Tuesday at 22:00 UTC".
+func (r *Rule) Describe(start time.Time) string {
+	var b strings.Builder
+
+	units := map[Frequency]string{Daily: "day", Weekly: "week", Monthly: "month"}
+	if r.Interval > 1 {
+		b.WriteString(fmt.Sprintf("every %d %ss", r.Interval, units[r.Freq]))
+	} else {
+		b.WriteString("every " + units[r.Freq])
+	}
+
+	var on []string
+	for _, d := range r.ByMonthDay {
+		switch {
+		case d == -1:
+			on = append(on, "the last day")
+		case d < 0:
+			on = append(on, fmt.Sprintf("the %s last day", ordinal(-d)))
+		default:
+			on = append(on, "day "+strconv.Itoa(d))
+		}
+	}
+	for _, wd := range r.ByDay {
+		switch {
+		case wd.N == -1:
+			on = append(on, "the last "+wd.Weekday.String())
+		case wd.N < 0:
+			on = append(on, fmt.Sprintf("the %s last %s", ordinal(-wd.N), wd.Weekday))
+		case wd.N > 0:
+			on = append(on, fmt.Sprintf("the %s %s", ordinal(wd.N), wd.Weekday))
+		default:
+			on = append(on, wd.Weekday.String())
+		}
+	}
+	// without BYDAY and BYMONTHDAY the day is taken from the start
+	if len(on) == 0 && r.Freq == Weekly {
+		on = append(on, start.UTC().Weekday().String())
+	}
+	if len(on) == 0 && r.Freq == Monthly {
+		on = append(on, "day "+strconv.Itoa(start.UTC().Day()))
+	}
+	if len(on) > 0 {
+		b.WriteString(" on " + strings.Join(on, ", "))
+	}
+
+	b.WriteString(" at " + start.UTC().Format("15:04") + " UTC")
+
+	if r.Count > 0 {
+		b.WriteString(fmt.Sprintf(", %d times", r.Count))
+	}
+	if r.Until != nil {
+		b.WriteString(", until " + r.Until.UTC().Format(time.DateOnly))
+	}
+
+	return b.String()
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < ordinalTeenMin || n%100 > ordinalTeenMax {
+		switch n % ordinalBase {
+		case 1:
+			suffix = "st"
+		case 2: //nolint:mnd
+			suffix = "nd"
+		case 3: //nolint:mnd
+			suffix = "rd"
+		}
+	}
+
+	return strconv.Itoa(n) + suffix
+}
+
+// DescribeRule returns the human-readable rule of the series, which starts at the start.
+// The rule is returned as is if it can't be parsed.
+func DescribeRule(value string, start time.Time) string {
+	rule, err := Parse(value)
+	if err != nil {
+		return value
+	}
+
+	return rule.Describe(start)
+}
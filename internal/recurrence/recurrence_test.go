@@ -0,0 +1,140 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	rule, err := Parse("RRULE:freq=weekly;interval=2;byday=TU,TH;count=4")
+	require.NoError(t, err)
+	assert.Equal(t, Weekly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []WeekdayNum{{Weekday: time.Tuesday}, {Weekday: time.Thursday}}, rule.ByDay)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=4", rule.String())
+
+	rule, err = Parse("FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20260101")
+	require.NoError(t, err)
+	assert.Equal(t, []WeekdayNum{{Weekday: time.Friday, N: -1}}, rule.ByDay)
+	assert.Equal(t, "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20260101T235959Z", rule.String())
+
+	for _, value := range []string{
+		"",
+		"FREQ=YEARLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYHOUR=10",
+		"FREQ=WEEKLY;BYDAY=2TU",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	} {
+		_, err = Parse(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	// Tuesday
+	start := time.Date(2025, 1, 7, 22, 0, 0, 0, time.UTC)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		rule     string
+		from     time.Time
+		expected []string
+	}{
+		{
+			rule:     "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			from:     from,
+			expected: []string{"2025-01-07", "2025-01-21", "2025-02-04"},
+		},
+		{
+			rule:     "FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20250120",
+			from:     from,
+			expected: []string{"2025-01-09", "2025-01-13", "2025-01-16", "2025-01-20"},
+		},
+		{
+			rule:     "FREQ=DAILY;INTERVAL=3;BYDAY=MO,TU,WE,TH,FR;COUNT=3",
+			from:     from,
+			expected: []string{"2025-01-07", "2025-01-10", "2025-01-13"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			from:     from,
+			expected: []string{"2025-01-31", "2025-02-28"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;BYDAY=2TU",
+			from:     from,
+			expected: []string{"2025-01-14", "2025-02-11"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=1,-1",
+			from:     from,
+			expected: []string{"2025-01-31", "2025-02-01", "2025-02-28"},
+		},
+		{
+			rule:     "FREQ=MONTHLY",
+			from:     from,
+			expected: []string{"2025-01-07", "2025-02-07"},
+		},
+		{
+			// COUNT is counted from the start, not from the range
+			rule:     "FREQ=WEEKLY;COUNT=4",
+			from:     time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
+			expected: []string{"2025-01-21", "2025-01-28"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			rule, err := Parse(tc.rule)
+			require.NoError(t, err)
+
+			occurrences := rule.Between(start, tc.from, to)
+			dates := make([]string, len(occurrences))
+			for i, o := range occurrences {
+				assert.Equal(t, "22:00", o.Format("15:04"))
+				dates[i] = o.Format(time.DateOnly)
+			}
+			assert.Equal(t, tc.expected, dates)
+		})
+	}
+}
+
+func TestMonthlyStartDaySkipsShortMonths(t *testing.T) {
+	rule, err := Parse("FREQ=MONTHLY")
+	require.NoError(t, err)
+
+	start := time.Date(2025, 1, 31, 6, 0, 0, 0, time.UTC)
+	occurrences := rule.Between(start, start, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.Len(t, occurrences, 3)
+	assert.Equal(t, time.March, occurrences[1].Month())
+	assert.Equal(t, time.May, occurrences[2].Month())
+}
+
+func TestDescribe(t *testing.T) {
+	start := time.Date(2025, 1, 7, 22, 0, 0, 0, time.UTC)
+
+	testCases := map[string]string{
+		"FREQ=DAILY":                         "every day at 22:00 UTC",
+		"FREQ=WEEKLY":                        "every week on Tuesday at 22:00 UTC",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH": "every 2 weeks on Monday, Thursday at 22:00 UTC",
+		"FREQ=MONTHLY;BYDAY=-1FR;COUNT=6":    "every month on the last Friday at 22:00 UTC, 6 times",
+		"FREQ=MONTHLY;BYDAY=2TU":             "every month on the 2nd Tuesday at 22:00 UTC",
+		"FREQ=MONTHLY;UNTIL=20251231":        "every month on day 7 at 22:00 UTC, until 2025-12-31",
+	}
+
+	for value, expected := range testCases {
+		rule, err := Parse(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, rule.Describe(start), value)
+	}
+}
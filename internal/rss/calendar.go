@@ -0,0 +1,217 @@
+package rss
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/recurrence"
+)
+
+const (
+	calendarContentType = "text/calendar; charset=utf-8"
+	calendarProdID      = "-//Status Dashboard//Events Calendar//EN"
+	calendarTimeFormat  = "20060102T150405Z"
+	// calendarMaxEvents limits the number of the latest events in the calendar.
+	calendarMaxEvents = 200
+	// calendarHistory is the period of the finished events, which are still published in the calendar.
+	calendarHistory = time.Hour * 24 * 90
+	// calendarLineLimit is the max length of a content line in octets, longer lines are folded.
+	calendarLineLimit = 75
+)
+
+// HandleICS returns the iCalendar feed with maintenances and info events.
+// It supports the same region (mt) and component (srv) filters as the RSS feed.
+func HandleICS(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params, ok := parseFeedParams(c, dbInst)
+		logger.Info(
+			"calendar requested", zap.String("region", params.region), zap.String("component", params.componentName),
+		)
+		if !ok {
+			return
+		}
+
+		params.types = []string{event.TypeMaintenance, event.TypeInformation}
+
+		events, err := getEvents(dbInst, logger, params, calendarMaxEvents)
+		if err != nil {
+			raiseGetEventsErr(c, params, err)
+			return
+		}
+
+		baseURL := fmt.Sprintf("%s://%s", c.Request.URL.Scheme, c.Request.Host)
+		calendar := buildCalendar(
+			events, baseURL, c.Request.Host, prepareFeedTitle(params.region, params.componentName), time.Now().UTC(),
+		)
+
+		c.Header("Content-Type", calendarContentType)
+		c.String(http.StatusOK, calendar)
+	}
+}
+
+// buildCalendar renders the events as VCALENDAR according to RFC 5545.
+func buildCalendar(events []*db.Incident, baseURL, host, title string, now time.Time) string {
+	var b strings.Builder
+
+	writeCalendarLine(&b, "BEGIN", "VCALENDAR")
+	writeCalendarLine(&b, "VERSION", "2.0")
+	writeCalendarLine(&b, "PRODID", calendarProdID)
+	writeCalendarLine(&b, "CALSCALE", "GREGORIAN")
+	writeCalendarLine(&b, "METHOD", "PUBLISH")
+	writeCalendarLine(&b, "X-WR-CALNAME", escapeCalendarText(title))
+
+	for _, e := range events {
+		if e.StartDate == nil || e.EndDate != nil && e.EndDate.Before(now.Add(-calendarHistory)) {
+			continue
+		}
+		writeCalendarEvent(&b, e, baseURL, host, now)
+	}
+
+	writeCalendarLine(&b, "END", "VCALENDAR")
+
+	return b.String()
+}
+
+func writeCalendarEvent(b *strings.Builder, e *db.Incident, baseURL, host string, now time.Time) {
+	writeCalendarLine(b, "BEGIN", "VEVENT")
+	writeCalendarLine(b, "UID", calendarUID(e.ID, host))
+	writeCalendarLine(b, "DTSTAMP", now.Format(calendarTimeFormat))
+	if e.ModifiedAt != nil {
+		writeCalendarLine(b, "LAST-MODIFIED", e.ModifiedAt.UTC().Format(calendarTimeFormat))
+	}
+	writeCalendarLine(b, "DTSTART", e.StartDate.UTC().Format(calendarTimeFormat))
+	if e.EndDate != nil {
+		writeCalendarLine(b, "DTEND", e.EndDate.UTC().Format(calendarTimeFormat))
+	}
+	writeCalendarLine(b, "SEQUENCE", fmt.Sprintf("%d", calendarSequence(e)))
+	writeCalendarLine(b, "STATUS", calendarStatus(e))
+	writeCalendarLine(b, "SUMMARY", escapeCalendarText(calendarSummary(e)))
+	writeCalendarLine(b, "DESCRIPTION", escapeCalendarText(calendarDescription(e)))
+	writeCalendarLine(b, "CATEGORIES", strings.ToUpper(e.Type))
+	writeCalendarLine(b, "URL", fmt.Sprintf("%s/incidents/%d", baseURL, e.ID))
+	writeCalendarLine(b, "TRANSP", "TRANSPARENT")
+	writeCalendarLine(b, "END", "VEVENT")
+}
+
+// calendarUID returns the UID of the event, it depends only on the event ID,
+// so calendar clients update the existing entry instead of creating a new one.
+func calendarUID(eventID uint, host string) string {
+	return fmt.Sprintf("event-%d@%s", eventID, host)
+}
+
+// calendarSequence returns the revision of the event.
+// Every manual modification increases the event version, the events modified before the versioning
+// are counted by the modified and cancelled statuses.
+func calendarSequence(e *db.Incident) int {
+	var changes int
+	for _, s := range e.Statuses {
+		switch s.Status { //nolint:exhaustive
+		// the cancelled status is the same for maintenances and info events
+		case event.MaintenanceModified, event.MaintenanceCancelled:
+			changes++
+		}
+	}
+
+	return max(e.Version-1, changes, 0)
+}
+
+func calendarStatus(e *db.Incident) string {
+	if e.Status == event.MaintenanceCancelled || e.Status == event.InfoCancelled {
+		return "CANCELLED"
+	}
+
+	return "CONFIRMED"
+}
+
+func calendarSummary(e *db.Incident) string {
+	var title string
+	if e.Text != nil {
+		title = *e.Text
+	}
+
+	if e.Type == event.TypeMaintenance {
+		return fmt.Sprintf("Maintenance: %s", title)
+	}
+
+	return fmt.Sprintf("Info: %s", title)
+}
+
+func calendarDescription(e *db.Incident) string {
+	var b strings.Builder
+
+	if e.Description != nil && *e.Description != "" {
+		b.WriteString(*e.Description)
+		b.WriteString("\n\n")
+	}
+
+	names := make([]string, 0, len(e.Components))
+	for _, c := range e.Components {
+		names = append(names, fmt.Sprintf("%s (%s)", c.Name, c.Region()))
+	}
+	b.WriteString(fmt.Sprintf("Affected services: %s", strings.Join(names, ", ")))
+
+	if e.Series != nil {
+		b.WriteString(fmt.Sprintf("\nRecurring maintenance: %s",
+			recurrence.DescribeRule(e.Series.RRule, e.Series.StartDate)))
+	}
+
+	if len(e.Statuses) > 0 {
+		last := e.Statuses[0]
+		for _, s := range e.Statuses[1:] {
+			if s.Timestamp.After(last.Timestamp) {
+				last = s
+			}
+		}
+		b.WriteString(fmt.Sprintf("\nLast update at %s UTC: %s - %s",
+			last.Timestamp.UTC().Format(time.DateTime), last.Status, last.Text,
+		))
+	}
+
+	return b.String()
+}
+
+// escapeCalendarText escapes a TEXT value, see RFC 5545 section 3.3.11.
+func escapeCalendarText(text string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+
+	return r.Replace(text)
+}
+
+// writeCalendarLine writes a content line, the lines longer than 75 octets are folded, see RFC 5545 section 3.1.
+func writeCalendarLine(b *strings.Builder, name, value string) {
+	line := name + ":" + value
+
+	limit := calendarLineLimit
+	for len(line) > limit {
+		// don't split multibyte characters
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of the continuation line is counted too
+		limit = calendarLineLimit - 1
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80 //nolint:mnd
+}
@@ -0,0 +1,121 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestBuildCalendar(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	start := now.Add(time.Hour * 24)
+	end := start.Add(time.Hour * 2)
+	oldEnd := now.Add(-calendarHistory - time.Hour)
+	impact := 0
+	mntText := "Database upgrade"
+	mntDescription := "New engine version; short downtime, reconnect is required"
+	infoText := "Old info"
+
+	events := []*db.Incident{
+		{
+			ID:          10,
+			Text:        &mntText,
+			Description: &mntDescription,
+			StartDate:   &start,
+			EndDate:     &end,
+			Impact:      &impact,
+			Type:        event.TypeMaintenance,
+			Status:      event.MaintenanceCancelled,
+			Version:     2,
+			Components: []db.Component{
+				{ID: 1, Name: "Relational Database Service", Attrs: []db.ComponentAttr{{Name: "region", Value: "EU-DE"}}},
+			},
+			Statuses: []db.IncidentStatus{
+				{Status: event.MaintenancePlanned, Text: "planned", Timestamp: now.Add(-time.Hour * 48)},
+				{Status: event.MaintenanceModified, Text: "moved", Timestamp: now.Add(-time.Hour * 24)},
+				{Status: event.MaintenanceCancelled, Text: "cancelled by the owner", Timestamp: now.Add(-time.Hour)},
+			},
+		},
+		{
+			ID:        11,
+			Text:      &infoText,
+			StartDate: &oldEnd,
+			EndDate:   &oldEnd,
+			Impact:    &impact,
+			Type:      event.TypeInformation,
+			Status:    event.InfoCompleted,
+		},
+	}
+
+	calendar := buildCalendar(events, testBaseURL, "status.example.com", generalTitle, now)
+
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 1, strings.Count(calendar, "BEGIN:VEVENT"))
+	assert.Contains(t, calendar, "UID:event-10@status.example.com\r\n")
+	assert.Contains(t, calendar, "DTSTART:20250521T120000Z\r\n")
+	assert.Contains(t, calendar, "DTEND:20250521T140000Z\r\n")
+	assert.Contains(t, calendar, "SEQUENCE:2\r\n")
+	assert.Contains(t, calendar, "STATUS:CANCELLED\r\n")
+	assert.Contains(t, calendar, "SUMMARY:Maintenance: Database upgrade\r\n")
+	assert.Contains(t, calendar, "URL:https://status.example.com/incidents/10\r\n")
+	assert.NotContains(t, calendar, "event-11@")
+
+	// all lines are folded to 75 octets
+	unfolded := strings.ReplaceAll(calendar, "\r\n ", "")
+	for _, line := range strings.Split(calendar, "\r\n") {
+		assert.LessOrEqual(t, len(line), calendarLineLimit, line)
+	}
+	assert.Contains(t, unfolded, "DESCRIPTION:New engine version\\; short downtime\\, reconnect is required\\n\\n"+
+		"Affected services: Relational Database Service (EU-DE)\\n"+
+		"Last update at 2025-05-20 11:00:00 UTC: cancelled - cancelled by the owner\r\n")
+}
+
+func TestCalendarSequence(t *testing.T) {
+	inc := &db.Incident{Version: 1}
+	assert.Equal(t, 0, calendarSequence(inc))
+
+	inc.Version = 4
+	assert.Equal(t, 3, calendarSequence(inc))
+
+	// events created before the versioning have the default version
+	inc.Version = 1
+	inc.Statuses = []db.IncidentStatus{
+		{Status: event.MaintenancePlanned},
+		{Status: event.MaintenanceModified},
+		{Status: event.MaintenanceInProgress},
+	}
+	assert.Equal(t, 1, calendarSequence(inc))
+}
+
+func TestCalendarDescriptionSeries(t *testing.T) {
+	start := time.Date(2025, 5, 20, 22, 0, 0, 0, time.UTC)
+	inc := &db.Incident{
+		Components: []db.Component{{ID: 1, Name: "Object Storage Service"}},
+		Series:     &db.MaintenanceSeries{RRule: "FREQ=WEEKLY;BYDAY=TU", StartDate: start},
+	}
+
+	assert.Contains(t, calendarDescription(inc), "\nRecurring maintenance: every week on Tuesday at 22:00 UTC")
+}
+
+func TestWriteCalendarLine(t *testing.T) {
+	var b strings.Builder
+	value := strings.Repeat("ü", 80)
+	writeCalendarLine(&b, "SUMMARY", value)
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	require.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), calendarLineLimit)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	assert.Equal(t, "SUMMARY:"+value, strings.ReplaceAll(strings.Join(lines, "\r\n"), "\r\n ", ""))
+}
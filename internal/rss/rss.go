@@ -14,6 +14,7 @@ import (
 	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
 	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/recurrence"
 )
 
 const generalTitle = "Incidents | Status Dashboard"
@@ -43,43 +44,57 @@ func getIncidentImpactsStr(impact int) string {
 	return outageImpactTextRepr
 }
 
+const (
+	rssContentType      = "application/rss+xml"
+	atomContentType     = "application/atom+xml"
+	jsonFeedContentType = "application/feed+json"
+)
+
+// HandleRSS returns the events feed, the format is negotiated by the Accept header.
+// RSS 2.0 is used by default, Atom 1.0 and JSON Feed 1.1 are returned if they are explicitly requested.
 func HandleRSS(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		region := c.Query("mt")
-		componentName := c.Query("srv")
-		logger.Info("rss feed requested", zap.String("region", region), zap.String("component", componentName))
+	return handleFeed(dbInst, logger, "")
+}
 
-		if componentName != "" && region == "" {
-			apiErrors.RaiseStatusNotFoundErr(c, errRSSWrongParams)
-			return
+// HandleAtom returns the events feed in the Atom 1.0 format.
+func HandleAtom(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return handleFeed(dbInst, logger, atomContentType)
+}
+
+// HandleJSONFeed returns the events feed in the JSON Feed 1.1 format.
+func HandleJSONFeed(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return handleFeed(dbInst, logger, jsonFeedContentType)
+}
+
+func handleFeed(dbInst *db.DB, logger *zap.Logger, fixedFormat string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := fixedFormat
+		if format == "" {
+			format = negotiateFormat(c)
 		}
 
-		if region != "" && !validateRegion(region) {
-			apiErrors.RaiseStatusNotFoundErr(c, fmt.Errorf("the region '%s' is not valid", region))
+		params, ok := parseFeedParams(c, dbInst)
+		logger.Info(
+			"feed requested", zap.String("region", params.region),
+			zap.String("component", params.componentName), zap.String("format", format),
+		)
+		if !ok {
 			return
 		}
 
 		baseURL := fmt.Sprintf("%s://%s", c.Request.URL.Scheme, c.Request.Host)
-		params := feedParams{
-			region:        region,
-			componentName: componentName,
-		}
 
 		events, err := getEvents(dbInst, logger, params, maxEvents)
 		if err != nil {
-			if componentName != "" {
-				apiErrors.RaiseStatusNotFoundErr(c, err)
-				return
-			}
-			apiErrors.RaiseInternalErr(c, err)
+			raiseGetEventsErr(c, params, err)
 			return
 		}
 
-		feedTitle := prepareFeedTitle(region, componentName)
+		feedTitle := prepareFeedTitle(params.region, params.componentName)
 
 		feed := &feeds.Feed{
 			Title:       feedTitle,
-			Link:        &feeds.Link{Href: baseURL + "/rss/" + c.Request.URL.RawQuery, Rel: "self"},
+			Link:        &feeds.Link{Href: baseURL + c.Request.URL.RequestURI(), Rel: "self"},
 			Description: feedTitle + " - Incidents",
 			Created:     time.Now(),
 		}
@@ -94,38 +109,155 @@ func HandleRSS(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
 
 		feed.Items = feedItems
 
-		rss, err := feed.ToRss()
+		body, err := renderFeed(feed, format, baseURL)
 		if err != nil {
 			apiErrors.RaiseInternalErr(c, err)
 			return
 		}
 
-		c.Header("Content-Type", "application/rss+xml")
-		c.String(http.StatusOK, rss)
+		c.Header("Content-Type", format)
+		c.String(http.StatusOK, body)
+	}
+}
+
+// negotiateFormat picks the feed format from the Accept header, RSS is used if nothing matches.
+func negotiateFormat(c *gin.Context) string {
+	format := c.NegotiateFormat(rssContentType, atomContentType, jsonFeedContentType)
+	if format == "" {
+		return rssContentType
 	}
+
+	return format
 }
 
-// TODO: add list of valid regions to the config or to the db
-func validateRegion(region string) bool {
-	regions := [4]string{"EU-DE", "EU-NL", "EU-CH2", "Global"}
-	for _, r := range regions {
-		if r == region {
-			return true
+func renderFeed(feed *feeds.Feed, format string, baseURL string) (string, error) {
+	switch format {
+	case atomContentType:
+		return feed.ToAtom()
+	case jsonFeedContentType:
+		jsonFeed := (&feeds.JSON{Feed: feed}).JSONFeed()
+		jsonFeed.HomePageUrl = baseURL
+		jsonFeed.FeedUrl = feed.Link.Href
+		for _, item := range jsonFeed.Items {
+			// Item descriptions are plain text, JSON Feed requires the item content to be present.
+			item.ContentText = item.Summary
 		}
+		return jsonFeed.ToJSON()
 	}
-	return false
+
+	return feed.ToRss()
+}
+
+// validateRegion checks if the region is registered in the regions table.
+func validateRegion(dbInst *db.DB, region string) (bool, error) {
+	return dbInst.RegionExists(region)
 }
 
+// feedParams are the filters of the feeds, types are the event types, all types are used if it's empty.
+// The feeds return the events, which were active at any time between the startDate and the endDate,
+// or at the time of at.
 type feedParams struct {
 	region        string
 	componentName string
+	types         []string
+	startDate     *time.Time
+	endDate       *time.Time
+	at            *time.Time
+}
+
+// parseFeedParams reads the region (mt) and component (srv) filters from the query.
+// If the filters are invalid, the error is raised and false is returned.
+func parseFeedParams(c *gin.Context, dbInst *db.DB) (feedParams, bool) {
+	params := feedParams{
+		region:        c.Query("mt"),
+		componentName: c.Query("srv"),
+	}
+
+	if params.componentName != "" && params.region == "" {
+		apiErrors.RaiseStatusNotFoundErr(c, errRSSWrongParams)
+		return params, false
+	}
+
+	if err := parseFeedTimeWindow(c, &params); err != nil {
+		apiErrors.RaiseBadRequestErr(c, err)
+		return params, false
+	}
+
+	if params.region != "" {
+		valid, err := validateRegion(dbInst, params.region)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return params, false
+		}
+		if !valid {
+			apiErrors.RaiseStatusNotFoundErr(c, fmt.Errorf("the region '%s' is not valid", params.region))
+			return params, false
+		}
+	}
+
+	return params, true
+}
+
+// parseFeedTimeWindow reads the start_date, the end_date and the at filters in the RFC 3339 format.
+func parseFeedTimeWindow(c *gin.Context, params *feedParams) error {
+	var err error
+	if params.startDate, err = parseFeedTime(c, "start_date"); err != nil {
+		return err
+	}
+	if params.endDate, err = parseFeedTime(c, "end_date"); err != nil {
+		return err
+	}
+	if params.at, err = parseFeedTime(c, "at"); err != nil {
+		return err
+	}
+
+	if params.at != nil && (params.startDate != nil || params.endDate != nil) {
+		return errRSSWrongParams
+	}
+	if params.startDate != nil && params.endDate != nil && params.endDate.Before(*params.startDate) {
+		return errRSSWrongParams
+	}
+
+	return nil
+}
+
+func parseFeedTime(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errRSSWrongParams
+	}
+	t = t.UTC()
+
+	return &t, nil
+}
+
+// raiseGetEventsErr returns not found for an unknown component, other errors are internal.
+func raiseGetEventsErr(c *gin.Context, params feedParams, err error) {
+	if params.componentName != "" {
+		apiErrors.RaiseStatusNotFoundErr(c, err)
+		return
+	}
+	apiErrors.RaiseInternalErr(c, err)
 }
 
 func getEvents(dbInstance *db.DB, log *zap.Logger, params feedParams, maxIncidents int) ([]*db.Incident, error) {
 	var incidents []*db.Incident
 	var err error
 
-	incParams := &db.IncidentsParams{LastCount: maxIncidents}
+	incParams := &db.IncidentsParams{
+		Types:           params.types,
+		LastCount:       maxIncidents,
+		ExcludeStatuses: event.MaintenanceReviewStatuses(),
+		StartDate:       params.startDate,
+		EndDate:         params.endDate,
+		Overlap:         true,
+		At:              params.at,
+	}
 
 	switch {
 	case params.componentName != "" && params.region != "":
@@ -179,6 +311,11 @@ func createFeedItems(incident *db.Incident, baseURL string) []*feeds.Item {
 }
 
 func createIncidentFeedItems(incident *db.Incident, baseURL string) []*feeds.Item {
+	// the items describe the affected services, the incident without the components has nothing to show
+	if len(incident.Components) == 0 {
+		return nil
+	}
+
 	var feedItems []*feeds.Item
 
 	impact := getIncidentImpactsStr(*incident.Impact)
@@ -187,7 +324,8 @@ func createIncidentFeedItems(incident *db.Incident, baseURL string) []*feeds.Ite
 	if len(incident.Components) > 1 {
 		title = fmt.Sprintf("Status change of multiple services to %s", impact)
 	} else {
-		title = fmt.Sprintf("%s status changed to %s", incident.Components[0].Name, impact)
+		compImpact := getIncidentImpactsStr(incident.ComponentImpact(incident.Components[0].ID))
+		title = fmt.Sprintf("%s status changed to %s", incident.Components[0].Name, compImpact)
 	}
 
 	var description strings.Builder
@@ -197,6 +335,10 @@ func createIncidentFeedItems(incident *db.Incident, baseURL string) []*feeds.Ite
 	for i := range len(incident.Components) {
 		c := incident.Components[i]
 		description.WriteString(fmt.Sprintf("%s in %s", c.Name, c.Region()))
+		if incident.HasComponentImpacts() && len(incident.Components) > 1 {
+			// the impact differs between the components, so it's shown for each of them
+			description.WriteString(fmt.Sprintf(" (%s)", getIncidentImpactsStr(incident.ComponentImpact(c.ID))))
+		}
 		if i != len(incident.Components)-1 {
 			description.WriteString(", ")
 		} else {
@@ -210,6 +352,8 @@ func createIncidentFeedItems(incident *db.Incident, baseURL string) []*feeds.Ite
 	}
 
 	item := &feeds.Item{
+		Id:          feedItemID(baseURL, incident.ID, 0),
+		IsPermaLink: "false",
 		Title:       title,
 		Link:        &feeds.Link{Href: fmt.Sprintf("%s/incidents/%d", baseURL, incident.ID)},
 		Created:     *incident.StartDate,
@@ -238,6 +382,8 @@ func createIncidentFeedItems(incident *db.Incident, baseURL string) []*feeds.Ite
 		d += fmt.Sprintf(": %s - %s", s.Status, s.Text)
 
 		upd := &feeds.Item{
+			Id:          feedItemID(baseURL, incident.ID, s.ID),
+			IsPermaLink: "false",
 			Title:       fmt.Sprintf("Update published for: %s", *incident.Text),
 			Link:        &feeds.Link{Href: fmt.Sprintf("%s/incidents/%d", baseURL, incident.ID)},
 			Created:     s.Timestamp,
@@ -288,6 +434,10 @@ func createMaintenanceFeedItems(maintenance *db.Incident, baseURL string) []*fee
 				maintenance.EndDate.Format(time.DateTime),
 				s.Text,
 			)
+			if maintenance.Series != nil {
+				description += fmt.Sprintf(" The maintenance recurs %s.",
+					recurrence.DescribeRule(maintenance.Series.RRule, maintenance.Series.StartDate))
+			}
 		case event.MaintenanceInProgress:
 			title = fmt.Sprintf("Maintenance started for %s", compShortNames)
 			description = fmt.Sprintf("A maintenance started for %s planned until %s UTC: %s",
@@ -317,6 +467,8 @@ func createMaintenanceFeedItems(maintenance *db.Incident, baseURL string) []*fee
 		}
 
 		upd := &feeds.Item{
+			Id:          feedItemID(baseURL, maintenance.ID, s.ID),
+			IsPermaLink: "false",
 			Title:       title,
 			Link:        &feeds.Link{Href: fmt.Sprintf("%s/incidents/%d", baseURL, maintenance.ID)},
 			Created:     s.Timestamp,
@@ -329,6 +481,16 @@ func createMaintenanceFeedItems(maintenance *db.Incident, baseURL string) []*fee
 	return feedItems
 }
 
+// feedItemID returns a stable item identifier, the statusID is 0 for the item of the event itself.
+// The identifier is used as RSS guid, Atom id and JSON Feed item id, so readers don't duplicate items.
+func feedItemID(baseURL string, eventID uint, statusID uint) string {
+	if statusID == 0 {
+		return fmt.Sprintf("%s/incidents/%d", baseURL, eventID)
+	}
+
+	return fmt.Sprintf("%s/incidents/%d#status-%d", baseURL, eventID, statusID)
+}
+
 func prepareFeedTitle(region string, component string) string {
 	if region == "" {
 		return generalTitle
@@ -0,0 +1,192 @@
+package rss
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/feeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const testBaseURL = "https://status.example.com"
+
+func testMaintenanceFeed(t *testing.T) *feeds.Feed {
+	t.Helper()
+
+	start := time.Date(2025, 5, 10, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour * 2)
+	impact := 0
+	text := "Maintenance"
+
+	mnt := &db.Incident{
+		ID:        42,
+		Text:      &text,
+		StartDate: &start,
+		EndDate:   &end,
+		Impact:    &impact,
+		Type:      event.TypeMaintenance,
+		Components: []db.Component{
+			{ID: 1, Name: "Elastic Cloud Server", Attrs: []db.ComponentAttr{
+				{Name: "region", Value: "EU-DE"},
+				{Name: "type", Value: "ecs"},
+			}},
+		},
+		Statuses: []db.IncidentStatus{
+			{ID: 7, Status: event.MaintenancePlanned, Text: "planned works", Timestamp: start.Add(-time.Hour * 24)},
+			{ID: 8, Status: event.MaintenanceInProgress, Text: "started", Timestamp: start},
+		},
+	}
+
+	items := createFeedItems(mnt, testBaseURL)
+	require.Len(t, items, 2)
+
+	return &feeds.Feed{
+		Title:       generalTitle,
+		Link:        &feeds.Link{Href: testBaseURL + "/v2/rss/", Rel: "self"},
+		Description: generalTitle + " - Incidents",
+		Created:     start,
+		Items:       items,
+	}
+}
+
+func TestCreateIncidentFeedItemsComponentImpacts(t *testing.T) {
+	start := time.Date(2025, 5, 10, 10, 0, 0, 0, time.UTC)
+	outage, minor := 3, 1
+	text := "Incident"
+
+	inc := &db.Incident{
+		ID:        43,
+		Text:      &text,
+		StartDate: &start,
+		Impact:    &outage,
+		Type:      event.TypeIncident,
+		Components: []db.Component{
+			{ID: 1, Name: "Elastic Cloud Server", Attrs: []db.ComponentAttr{{Name: "region", Value: "EU-DE"}}},
+			{ID: 2, Name: "Object Storage Service", Attrs: []db.ComponentAttr{{Name: "region", Value: "EU-DE"}}},
+		},
+		ComponentImpacts: []db.IncidentComponent{
+			{IncidentID: 43, ComponentID: 1, Impact: &outage},
+			{IncidentID: 43, ComponentID: 2, Impact: &minor},
+		},
+	}
+
+	items := createFeedItems(inc, testBaseURL)
+	require.Len(t, items, 1)
+	assert.Equal(t, "Status change of multiple services to Service outage", items[0].Title)
+	assert.Contains(t, items[0].Description, "Elastic Cloud Server in EU-DE (Service outage)")
+	assert.Contains(t, items[0].Description, "Object Storage Service in EU-DE (Minor incident (i.e. performance impact))")
+
+	inc.Components = inc.Components[1:]
+	items = createFeedItems(inc, testBaseURL)
+	require.Len(t, items, 1)
+	assert.Equal(t, "Object Storage Service status changed to Minor incident (i.e. performance impact)", items[0].Title)
+
+	inc.Components = nil
+	assert.Empty(t, createFeedItems(inc, testBaseURL))
+}
+
+func TestRenderFeed(t *testing.T) {
+	feed := testMaintenanceFeed(t)
+
+	rss, err := renderFeed(feed, rssContentType, testBaseURL)
+	require.NoError(t, err)
+	assert.Contains(t, rss, "<rss version=\"2.0\"")
+	assert.Contains(t, rss, "<title>Maintenance planned for ecs</title>")
+	assert.Contains(t, rss, "<guid isPermaLink=\"false\">https://status.example.com/incidents/42#status-7</guid>")
+
+	atom, err := renderFeed(feed, atomContentType, testBaseURL)
+	require.NoError(t, err)
+	assert.Contains(t, atom, "<feed xmlns=\"http://www.w3.org/2005/Atom\"")
+	assert.Contains(t, atom, "<title>Maintenance started for ecs</title>")
+	assert.Contains(t, atom, "<id>https://status.example.com/incidents/42#status-8</id>")
+
+	jsonFeed, err := renderFeed(feed, jsonFeedContentType, testBaseURL)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Version     string `json:"version"`
+		HomePageURL string `json:"home_page_url"`
+		FeedURL     string `json:"feed_url"`
+		Items       []struct {
+			ID          string `json:"id"`
+			URL         string `json:"url"`
+			Title       string `json:"title"`
+			ContentText string `json:"content_text"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(jsonFeed), &decoded))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", decoded.Version)
+	assert.Equal(t, testBaseURL, decoded.HomePageURL)
+	assert.Equal(t, testBaseURL+"/v2/rss/", decoded.FeedURL)
+	require.Len(t, decoded.Items, 2)
+	assert.Equal(t, "https://status.example.com/incidents/42#status-7", decoded.Items[0].ID)
+	assert.Equal(t, "https://status.example.com/incidents/42", decoded.Items[0].URL)
+	assert.Equal(t, "Maintenance planned for ecs", decoded.Items[0].Title)
+	assert.True(t, strings.HasPrefix(decoded.Items[0].ContentText, "A maintenance is planned for Elastic Cloud Server"))
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := map[string]string{
+		"":                                     rssContentType,
+		"*/*":                                  rssContentType,
+		"text/html,application/xml;q=0.9,*/*":  rssContentType,
+		"application/atom+xml":                 atomContentType,
+		"application/feed+json":                jsonFeedContentType,
+		"application/json":                     rssContentType,
+		"application/feed+json, application/*": jsonFeedContentType,
+	}
+
+	for accept, expected := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/v2/rss/", nil)
+		if accept != "" {
+			c.Request.Header.Set("Accept", accept)
+		}
+		assert.Equal(t, expected, negotiateFormat(c), "accept: %s", accept)
+	}
+}
+
+func TestParseFeedTimeWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := map[string]bool{
+		"":                                true,
+		"start_date=2025-05-01T00:00:00Z": true,
+		"at=2025-05-01T10:00:00%2B02:00":  true,
+		"start_date=2025-05-01T00:00:00Z&end_date=2025-06-01T00:00:00Z": true,
+		"start_date=2025-05-01": false,
+		"at=now":                false,
+		"at=2025-05-01T00:00:00Z&end_date=2025-06-01T00:00:00Z":         false,
+		"start_date=2025-06-01T00:00:00Z&end_date=2025-05-01T00:00:00Z": false,
+	}
+
+	for query, valid := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/rss/?"+query, nil)
+
+		var params feedParams
+		err := parseFeedTimeWindow(c, &params)
+		if !valid {
+			assert.ErrorIs(t, err, errRSSWrongParams, "query: %s", query)
+			continue
+		}
+		assert.NoError(t, err, "query: %s", query)
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/rss/?at=2025-05-01T10:00:00%2B02:00", nil)
+	var params feedParams
+	assert.NoError(t, parseFeedTimeWindow(c, &params))
+	assert.Equal(t, time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC), *params.at)
+}
@@ -0,0 +1,68 @@
+package statuspage
+
+import (
+	"bytes"
+	"text/template"
+	"unicode/utf8"
+)
+
+const (
+	// the approximate width of a character of the 11px Verdana font and the padding of a badge part
+	badgeCharWidth = 7
+	badgePadding   = 10
+)
+
+// badgeText is the flat badge in the shields.io style, the texts are escaped by the html function.
+const badgeText = `<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" ` +
+	`aria-label="{{html .Label}}: {{html .Message}}">
+<title>{{html .Label}}: {{html .Message}}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/>` +
+	`<stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="{{.LabelWidth}}" height="20" fill="#555"/>
+<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>
+<rect width="{{.Width}}" height="20" fill="url(#s)"/>
+</g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="{{.LabelX}}" y="14">{{html .Label}}</text>
+<text x="{{.MessageX}}" y="14">{{html .Message}}</text>
+</g>
+</svg>
+`
+
+//nolint:gochecknoglobals
+var badgeTemplate = template.Must(template.New("badge").Parse(badgeText))
+
+type badgeData struct {
+	Label        string
+	Message      string
+	Color        string
+	LabelWidth   int
+	MessageWidth int
+	Width        int
+	LabelX       int
+	MessageX     int
+}
+
+// Badge renders the SVG badge with the label and the status.
+func Badge(label string, st Status) ([]byte, error) {
+	message := string(st)
+	data := badgeData{
+		Label:        label,
+		Message:      message,
+		Color:        st.Color(),
+		LabelWidth:   utf8.RuneCountInString(label)*badgeCharWidth + badgePadding,
+		MessageWidth: utf8.RuneCountInString(message)*badgeCharWidth + badgePadding,
+	}
+	data.Width = data.LabelWidth + data.MessageWidth
+	data.LabelX = data.LabelWidth / 2                     //nolint:mnd
+	data.MessageX = data.LabelWidth + data.MessageWidth/2 //nolint:mnd
+
+	var b bytes.Buffer
+	if err := badgeTemplate.Execute(&b, data); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,237 @@
+package statuspage
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const (
+	pageContentType  = "text/html; charset=utf-8"
+	badgeContentType = "image/svg+xml; charset=utf-8"
+	// badgeMaxAge is the browser cache period of the badges, the embedding sites refresh the badges by it.
+	badgeMaxAge    = "max-age=60"
+	refreshSeconds = 60
+	timeFormat     = "2006-01-02 15:04 MST"
+)
+
+//go:embed templates/status.html
+var templatesFS embed.FS //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var pageTemplate = template.Must(template.New("status.html").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.UTC().Format(timeFormat) },
+	"join": strings.Join,
+}).ParseFS(templatesFS, "templates/status.html"))
+
+// HandlePage renders the status page: the component status per region, the active events and
+// the upcoming maintenances. The page is filtered by the region query parameter.
+// The maintenances in the review are not shown.
+func HandlePage(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region := c.Query("region")
+		logger.Debug("status page requested", zap.String("region", region))
+
+		if region != "" {
+			exists, err := dbInst.RegionExists(region)
+			if err != nil {
+				apiErrors.RaiseInternalErr(c, err)
+				return
+			}
+			if !exists {
+				apiErrors.RaiseStatusNotFoundErr(c, fmt.Errorf("%w: %s", apiErrors.ErrRegionDSNotExist, region))
+				return
+			}
+		}
+
+		components, err := dbInst.GetComponentsWithValues()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		active, err := getActiveEvents(dbInst, nil)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		now := time.Now().UTC()
+		upcoming, err := dbInst.GetEvents(&db.IncidentsParams{
+			Types:           []string{event.TypeMaintenance},
+			StartDate:       &now,
+			ExcludeStatuses: append(event.MaintenanceReviewStatuses(), event.MaintenanceCancelled),
+		})
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		names := regionNames(components)
+		if region != "" {
+			components = componentsInRegion(components, region)
+			active = eventsInRegion(active, region)
+			upcoming = eventsInRegion(upcoming, region)
+		}
+
+		page := Build(components, active, upcoming, now)
+		page.Region = region
+		page.RegionNames = names
+		page.RefreshSeconds = refreshSeconds
+
+		var b bytes.Buffer
+		if err = pageTemplate.Execute(&b, page); err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, pageContentType, b.Bytes())
+	}
+}
+
+// HandleComponentBadge returns the SVG badge with the highest current impact of the component.
+func HandleComponentBadge(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apiErrors.RaiseBadRequestErr(c, apiErrors.ErrComponentInvalidFormat)
+			return
+		}
+		logger.Debug("component badge requested", zap.Int("componentID", id))
+
+		comp, err := dbInst.GetComponent(id)
+		if err != nil {
+			if errors.Is(err, db.ErrDBComponentDSNotExist) {
+				apiErrors.RaiseStatusNotFoundErr(c, apiErrors.NewErrComponentDSNotExist(id))
+				return
+			}
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		active, err := getActiveEvents(dbInst, []int{id})
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		st := ComponentStatuses(active)[comp.ID].Worse(StatusOperational)
+		writeBadge(c, comp.Name, st)
+	}
+}
+
+// HandleRegionBadge returns the SVG badge with the highest current impact of the region components.
+func HandleRegionBadge(dbInst *db.DB, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region := c.Param("name")
+		logger.Debug("region badge requested", zap.String("region", region))
+
+		exists, err := dbInst.RegionExists(region)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+		if !exists {
+			apiErrors.RaiseStatusNotFoundErr(c, fmt.Errorf("%w: %s", apiErrors.ErrRegionDSNotExist, region))
+			return
+		}
+
+		components, err := dbInst.GetComponentsWithValues()
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		active, err := getActiveEvents(dbInst, nil)
+		if err != nil {
+			apiErrors.RaiseInternalErr(c, err)
+			return
+		}
+
+		statuses := ComponentStatuses(active)
+		st := StatusOperational
+		for _, comp := range componentsInRegion(components, region) {
+			st = st.Worse(statuses[comp.ID])
+		}
+
+		writeBadge(c, region, st)
+	}
+}
+
+func writeBadge(c *gin.Context, label string, st Status) {
+	badge, err := Badge(label, st)
+	if err != nil {
+		apiErrors.RaiseInternalErr(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, badgeContentType, badge)
+}
+
+// BadgeCacheControlMW sets the browser cache period of the badges.
+// It's registered before the read cache, because the cached responses don't keep the headers.
+func BadgeCacheControlMW() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", badgeMaxAge)
+		c.Next()
+	}
+}
+
+// getActiveEvents returns the public active events, optionally only of the components.
+func getActiveEvents(dbInst *db.DB, componentIDs []int) ([]*db.Incident, error) {
+	isActive := true
+	return dbInst.GetEvents(&db.IncidentsParams{
+		IsActive:        &isActive,
+		ComponentIDs:    componentIDs,
+		ExcludeStatuses: event.MaintenanceReviewStatuses(),
+	})
+}
+
+func regionNames(components []db.Component) []string {
+	var names []string
+	for _, comp := range components {
+		if region := comp.Region(); region != "" && !slices.Contains(names, region) {
+			names = append(names, region)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+func componentsInRegion(components []db.Component, region string) []db.Component {
+	var result []db.Component
+	for _, comp := range components {
+		if comp.Region() == region {
+			result = append(result, comp)
+		}
+	}
+
+	return result
+}
+
+// eventsInRegion returns the events, which affect at least one component of the region.
+func eventsInRegion(events []*db.Incident, region string) []*db.Incident {
+	var result []*db.Incident
+	for _, inc := range events {
+		if slices.ContainsFunc(inc.Components, func(comp db.Component) bool { return comp.Region() == region }) {
+			result = append(result, inc)
+		}
+	}
+
+	return result
+}
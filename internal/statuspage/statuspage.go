@@ -0,0 +1,262 @@
+// Package statuspage renders the lightweight public status page and the status badges.
+// The page is server-rendered by html/template and works without JavaScript.
+package statuspage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+// maxUpcoming limits the number of the upcoming maintenances on the page.
+const maxUpcoming = 20
+
+// Status is the current state of the component, the region or the whole page.
+type Status string
+
+const (
+	StatusOperational Status = "operational"
+	StatusMaintenance Status = "maintenance"
+	StatusMinor       Status = "minor"
+	StatusMajor       Status = "major"
+	StatusOutage      Status = "outage"
+)
+
+// StatusByImpact returns the status of the incident impact.
+func StatusByImpact(impact int) Status {
+	switch impact {
+	case 0:
+		return StatusOperational
+	case 1:
+		return StatusMinor
+	case 2: //nolint:mnd
+		return StatusMajor
+	}
+
+	return StatusOutage
+}
+
+func (s Status) rank() int {
+	switch s {
+	case StatusMaintenance:
+		return 1
+	case StatusMinor:
+		return 2 //nolint:mnd
+	case StatusMajor:
+		return 3 //nolint:mnd
+	case StatusOutage:
+		return 4 //nolint:mnd
+	case StatusOperational:
+	}
+
+	return 0
+}
+
+// Worse returns the higher status of two.
+func (s Status) Worse(other Status) Status {
+	if other.rank() > s.rank() {
+		return other
+	}
+	if s == "" {
+		return StatusOperational
+	}
+	return s
+}
+
+// Text returns the human-readable status.
+func (s Status) Text() string {
+	switch s {
+	case StatusMaintenance:
+		return "Under maintenance"
+	case StatusMinor:
+		return "Minor incident"
+	case StatusMajor:
+		return "Major incident"
+	case StatusOutage:
+		return "Service outage"
+	case StatusOperational:
+	}
+
+	return "Operational"
+}
+
+// Color returns the color of the status, it's the same for the page and the badges.
+func (s Status) Color() string {
+	switch s {
+	case StatusMaintenance:
+		return "#007ec6"
+	case StatusMinor:
+		return "#dfb317"
+	case StatusMajor:
+		return "#fe7d37"
+	case StatusOutage:
+		return "#e05d44"
+	case StatusOperational:
+	}
+
+	return "#4c1"
+}
+
+type Page struct {
+	// Region is the selected region, the page shows all regions if it's empty.
+	Region string
+	// RegionNames are all regions for the navigation.
+	RegionNames    []string
+	Status         Status
+	Regions        []*Region
+	Active         []*Event
+	Upcoming       []*Event
+	UpdatedAt      time.Time
+	RefreshSeconds int
+}
+
+type Region struct {
+	Name       string
+	Status     Status
+	Components []*Component
+}
+
+type Component struct {
+	ID     uint
+	Name   string
+	Status Status
+}
+
+type Event struct {
+	ID          uint
+	Title       string
+	Description string
+	Type        string
+	Status      event.Status
+	Level       Status
+	StartDate   *time.Time
+	EndDate     *time.Time
+	Components  []string
+	Update      *db.IncidentStatus
+}
+
+// ComponentStatuses returns the status of every component of the active events.
+// The incidents set the status by the impact, the maintenances set the maintenance status,
+// the information events don't change the status.
+func ComponentStatuses(active []*db.Incident) map[uint]Status {
+	statuses := make(map[uint]Status)
+	for _, inc := range active {
+		for _, comp := range inc.Components {
+			if st := componentLevel(inc, comp.ID); st != StatusOperational {
+				statuses[comp.ID] = statuses[comp.ID].Worse(st)
+			}
+		}
+	}
+
+	return statuses
+}
+
+// Build prepares the page: the components are grouped by the region and sorted by the name,
+// the active events are sorted by the level and the upcoming maintenances by the start date.
+// The upcoming events are the events, which start after now.
+func Build(components []db.Component, active, upcoming []*db.Incident, now time.Time) *Page {
+	statuses := ComponentStatuses(active)
+	page := &Page{Status: StatusOperational, UpdatedAt: now.UTC()}
+
+	regions := make(map[string]*Region)
+	for _, comp := range components {
+		name := comp.Region()
+		r, ok := regions[name]
+		if !ok {
+			r = &Region{Name: name, Status: StatusOperational}
+			regions[name] = r
+			page.Regions = append(page.Regions, r)
+		}
+
+		st := statuses[comp.ID].Worse(StatusOperational)
+		r.Components = append(r.Components, &Component{ID: comp.ID, Name: comp.Name, Status: st})
+		r.Status = r.Status.Worse(st)
+		page.Status = page.Status.Worse(st)
+	}
+
+	sort.Slice(page.Regions, func(i, j int) bool { return page.Regions[i].Name < page.Regions[j].Name })
+	for _, r := range page.Regions {
+		sort.SliceStable(r.Components, func(i, j int) bool { return r.Components[i].Name < r.Components[j].Name })
+	}
+
+	for _, inc := range active {
+		page.Active = append(page.Active, newEvent(inc))
+	}
+	sort.SliceStable(page.Active, func(i, j int) bool {
+		return page.Active[i].Level.rank() > page.Active[j].Level.rank()
+	})
+
+	for _, inc := range upcoming {
+		if inc.StartDate == nil || !inc.StartDate.After(now) {
+			continue
+		}
+		page.Upcoming = append(page.Upcoming, newEvent(inc))
+	}
+	sort.SliceStable(page.Upcoming, func(i, j int) bool {
+		return page.Upcoming[i].StartDate.Before(*page.Upcoming[j].StartDate)
+	})
+	if len(page.Upcoming) > maxUpcoming {
+		page.Upcoming = page.Upcoming[:maxUpcoming]
+	}
+
+	return page
+}
+
+func eventLevel(inc *db.Incident) Status {
+	switch inc.Type {
+	case event.TypeIncident:
+		if inc.Impact != nil {
+			return StatusByImpact(*inc.Impact)
+		}
+	case event.TypeMaintenance:
+		return StatusMaintenance
+	}
+
+	return StatusOperational
+}
+
+// componentLevel returns the level of the event for the component, the incident impact can differ for the components.
+func componentLevel(inc *db.Incident, componentID uint) Status {
+	if inc.Type == event.TypeIncident && inc.Impact != nil {
+		return StatusByImpact(inc.ComponentImpact(componentID))
+	}
+
+	return eventLevel(inc)
+}
+
+func newEvent(inc *db.Incident) *Event {
+	e := &Event{
+		ID:        inc.ID,
+		Type:      inc.Type,
+		Status:    inc.Status,
+		Level:     eventLevel(inc),
+		StartDate: inc.StartDate,
+		EndDate:   inc.EndDate,
+	}
+	if inc.Text != nil {
+		e.Title = *inc.Text
+	}
+	if inc.Description != nil {
+		e.Description = *inc.Description
+	}
+
+	for _, comp := range inc.Components {
+		name := comp.Name
+		if region := comp.Region(); region != "" {
+			name += " (" + region + ")"
+		}
+		e.Components = append(e.Components, name)
+	}
+	sort.Strings(e.Components)
+
+	for i := range inc.Statuses {
+		st := &inc.Statuses[i]
+		if e.Update == nil || !st.Timestamp.Before(e.Update.Timestamp) {
+			e.Update = st
+		}
+	}
+
+	return e
+}
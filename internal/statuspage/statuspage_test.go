@@ -0,0 +1,151 @@
+package statuspage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func newComponent(id uint, name, region string) db.Component {
+	return db.Component{ID: id, Name: name, Attrs: []db.ComponentAttr{{Name: "region", Value: region}}}
+}
+
+func newIncident(id uint, eventType string, impact int, status event.Status, start time.Time,
+	components ...db.Component) *db.Incident {
+	title := "event"
+	return &db.Incident{
+		ID: id, Text: &title, Type: eventType, Impact: &impact, Status: status, StartDate: &start,
+		Components: components,
+	}
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2025, 5, 10, 10, 0, 0, 0, time.UTC)
+	ecsDE := newComponent(1, "Elastic Cloud Server", "EU-DE")
+	evsDE := newComponent(2, "Elastic Volume Service", "EU-DE")
+	ecsNL := newComponent(3, "Elastic Cloud Server", "EU-NL")
+	dnsNL := newComponent(4, "Domain Name Service", "EU-NL")
+
+	minor := newIncident(1, event.TypeIncident, 1, event.IncidentAnalysing, now.Add(-time.Hour), ecsDE, ecsNL)
+	major := newIncident(2, event.TypeIncident, 2, event.IncidentFixing, now.Add(-time.Hour), ecsDE)
+	maintenance := newIncident(3, event.TypeMaintenance, 0, event.MaintenanceInProgress, now.Add(-time.Hour), dnsNL)
+	info := newIncident(4, event.TypeInformation, 0, event.InfoActive, now.Add(-time.Hour), evsDE)
+	later := newIncident(5, event.TypeMaintenance, 0, event.MaintenancePlanned, now.Add(time.Hour*48), evsDE)
+	sooner := newIncident(6, event.TypeMaintenance, 0, event.MaintenancePlanned, now.Add(time.Hour*24), evsDE)
+
+	page := Build(
+		[]db.Component{dnsNL, evsDE, ecsNL, ecsDE},
+		[]*db.Incident{minor, maintenance, info, major},
+		[]*db.Incident{later, sooner, maintenance},
+		now,
+	)
+
+	assert.Equal(t, StatusMajor, page.Status)
+	require.Len(t, page.Regions, 2)
+
+	de := page.Regions[0]
+	assert.Equal(t, "EU-DE", de.Name)
+	assert.Equal(t, StatusMajor, de.Status)
+	require.Len(t, de.Components, 2)
+	assert.Equal(t, "Elastic Cloud Server", de.Components[0].Name)
+	assert.Equal(t, StatusMajor, de.Components[0].Status)
+	assert.Equal(t, StatusOperational, de.Components[1].Status, "the info events don't change the status")
+
+	nl := page.Regions[1]
+	assert.Equal(t, StatusMinor, nl.Status)
+	assert.Equal(t, StatusMaintenance, nl.Components[0].Status)
+	assert.Equal(t, StatusMinor, nl.Components[1].Status)
+
+	require.Len(t, page.Active, 4)
+	assert.Equal(t, uint(2), page.Active[0].ID)
+	assert.Equal(t, uint(1), page.Active[1].ID)
+	assert.Equal(t, []string{"Elastic Cloud Server (EU-DE)", "Elastic Cloud Server (EU-NL)"}, page.Active[1].Components)
+
+	require.Len(t, page.Upcoming, 2, "the started maintenance is not upcoming")
+	assert.Equal(t, uint(6), page.Upcoming[0].ID)
+	assert.Equal(t, uint(5), page.Upcoming[1].ID)
+
+	empty := Build([]db.Component{ecsDE}, nil, nil, now)
+	assert.Equal(t, StatusOperational, empty.Status)
+	assert.Equal(t, StatusOperational, empty.Regions[0].Components[0].Status)
+}
+
+func TestComponentStatuses(t *testing.T) {
+	now := time.Date(2025, 5, 10, 10, 0, 0, 0, time.UTC)
+	ecsDE := newComponent(1, "Elastic Cloud Server", "EU-DE")
+	evsDE := newComponent(2, "Elastic Volume Service", "EU-DE")
+	dnsDE := newComponent(3, "Domain Name Service", "EU-DE")
+
+	minor, outage := 1, 3
+	inc := newIncident(1, event.TypeIncident, outage, event.IncidentAnalysing, now, ecsDE, evsDE, dnsDE)
+	inc.ComponentImpacts = []db.IncidentComponent{
+		{IncidentID: 1, ComponentID: 1, Impact: &outage},
+		{IncidentID: 1, ComponentID: 2, Impact: &minor},
+	}
+
+	statuses := ComponentStatuses([]*db.Incident{inc})
+	assert.Equal(t, StatusOutage, statuses[1])
+	assert.Equal(t, StatusMinor, statuses[2], "the component has the own impact")
+	assert.Equal(t, StatusOutage, statuses[3], "the component without the own impact has the incident impact")
+}
+
+func TestRenderPage(t *testing.T) {
+	now := time.Date(2025, 5, 10, 10, 0, 0, 0, time.UTC)
+	ecs := newComponent(1, "Elastic Cloud Server", "EU-DE")
+	inc := newIncident(1, event.TypeIncident, 3, event.IncidentAnalysing, now.Add(-time.Hour), ecs)
+	title := "<script>alert(1)</script>"
+	inc.Text = &title
+	inc.Statuses = []db.IncidentStatus{
+		{ID: 1, Status: event.IncidentAnalysing, Text: "The incident is detected.", Timestamp: now.Add(-time.Hour)},
+	}
+
+	page := Build([]db.Component{ecs}, []*db.Incident{inc}, nil, now)
+	page.RegionNames = []string{"EU-DE"}
+	page.RefreshSeconds = refreshSeconds
+
+	var b bytes.Buffer
+	require.NoError(t, pageTemplate.Execute(&b, page))
+	html := b.String()
+
+	assert.Contains(t, html, `<meta http-equiv="refresh" content="60">`)
+	assert.Contains(t, html, `<a href="?region=EU-DE">EU-DE</a>`)
+	assert.Contains(t, html, "Service outage")
+	assert.Contains(t, html, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "The incident is detected.")
+	assert.Contains(t, html, "from 2025-05-10 09:00 UTC")
+	assert.Contains(t, html, "No maintenances are planned.")
+	assert.Contains(t, html, "background: #e05d44")
+}
+
+func TestBadge(t *testing.T) {
+	badge, err := Badge("EU-DE <main>", StatusMinor)
+	require.NoError(t, err)
+
+	var svg struct {
+		Width string `xml:"width,attr"`
+		Title string `xml:"title"`
+	}
+	require.NoError(t, xml.Unmarshal(badge, &svg), "the badge is valid XML")
+	assert.Equal(t, "EU-DE <main>: minor", svg.Title)
+	assert.Equal(t, "139", svg.Width)
+	assert.Contains(t, string(badge), `fill="#dfb317"`)
+}
+
+func TestStatus(t *testing.T) {
+	assert.Equal(t, StatusOperational, StatusByImpact(0))
+	assert.Equal(t, StatusMinor, StatusByImpact(1))
+	assert.Equal(t, StatusMajor, StatusByImpact(2))
+	assert.Equal(t, StatusOutage, StatusByImpact(3))
+
+	assert.Equal(t, StatusOperational, Status("").Worse(StatusOperational))
+	assert.Equal(t, StatusMajor, StatusMaintenance.Worse(StatusMajor))
+	assert.Equal(t, StatusOutage, StatusOutage.Worse(StatusMinor))
+}
@@ -0,0 +1,158 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+const (
+	pollPeriod  = time.Second * 2
+	prunePeriod = time.Hour
+	// Retention is the period, when the stream can be resumed with Last-Event-ID.
+	Retention = time.Hour * 24
+
+	batchSize = 500
+	// subscriberBuffer is the channel size, the slow subscriber is disconnected when the buffer is full.
+	subscriberBuffer = 64
+)
+
+// Broker polls new messages from the database and sends them to the subscribers.
+// The database is used to share the messages between the API instances and the checker.
+type Broker struct {
+	db  *db.DB
+	log *zap.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan *Message]struct{}
+	lastID      uint
+}
+
+func NewBroker(dbInst *db.DB, log *zap.Logger) *Broker {
+	return &Broker{db: dbInst, log: log, subscribers: make(map[chan *Message]struct{})}
+}
+
+func (b *Broker) Run(done chan struct{}) {
+	b.log.Info("stream broker is started")
+
+	lastID, err := b.db.GetLastStreamMessageID()
+	if err != nil {
+		b.log.Error("failed to get the last stream message id", zap.Error(err))
+	}
+	b.lastID = lastID
+
+	pollTicker := time.NewTicker(pollPeriod)
+	defer pollTicker.Stop()
+	pruneTicker := time.NewTicker(prunePeriod)
+	defer pruneTicker.Stop()
+
+	for { //nolint:nolintlint
+		select {
+		case <-done:
+			b.closeSubscribers()
+			return
+		case <-pollTicker.C:
+			if err = b.poll(); err != nil {
+				b.log.Error("failed to poll stream messages", zap.Error(err))
+			}
+		case <-pruneTicker.C:
+			deleted, errPrune := b.db.DeleteStreamMessagesBefore(time.Now().UTC().Add(-Retention))
+			if errPrune != nil {
+				b.log.Error("failed to prune stream messages", zap.Error(errPrune))
+				continue
+			}
+			b.log.Debug("old stream messages are pruned", zap.Int64("deleted", deleted))
+		}
+	}
+}
+
+func (b *Broker) poll() error {
+	messages, err := b.db.GetStreamMessages(b.lastID, batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		b.lastID = m.ID
+		msg, errDecode := decode(m)
+		if errDecode != nil {
+			b.log.Error("failed to decode stream message", zap.Error(errDecode), zap.Uint("id", m.ID))
+			continue
+		}
+		b.broadcast(msg)
+	}
+
+	return nil
+}
+
+func (b *Broker) broadcast(msg *Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			b.log.Warn("the stream subscriber is too slow, disconnect it")
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the channel with new messages, the channel is closed if the subscriber is too slow.
+// The returned function must be called to unsubscribe.
+func (b *Broker) Subscribe() (<-chan *Message, func()) {
+	ch := make(chan *Message, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Replay returns stored messages after the given ID, it's used to resume the stream.
+// The messages are read by batches till the last stored one.
+func (b *Broker) Replay(afterID uint) ([]*Message, error) {
+	var messages []*Message
+	for {
+		stored, err := b.db.GetStreamMessages(afterID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(stored) == 0 {
+			return messages, nil
+		}
+
+		for _, m := range stored {
+			afterID = m.ID
+			msg, errDecode := decode(m)
+			if errDecode != nil {
+				b.log.Error("failed to decode stream message", zap.Error(errDecode), zap.Uint("id", m.ID))
+				continue
+			}
+			messages = append(messages, msg)
+		}
+	}
+}
+
+func (b *Broker) closeSubscribers() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
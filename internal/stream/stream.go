@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
+)
+
+// Message is a stream message, the ID is used as SSE "id" and it's returned by clients in Last-Event-ID header.
+type Message struct {
+	ID      uint
+	Payload *webhook.Payload
+}
+
+// Publish stores the event change, the Broker sends it to the subscribers.
+func Publish(dbInst *db.DB, action webhook.Action, eventID uint) error {
+	inc, err := dbInst.GetIncident(int(eventID))
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(webhook.NewPayload(action, inc))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return dbInst.SaveStreamMessage(&db.StreamMessage{
+		IncidentID: inc.ID,
+		Action:     string(action),
+		Payload:    string(body),
+		CreatedAt:  &now,
+	})
+}
+
+func decode(msg *db.StreamMessage) (*Message, error) {
+	payload := &webhook.Payload{}
+	if err := json.Unmarshal([]byte(msg.Payload), payload); err != nil {
+		return nil, err
+	}
+
+	return &Message{ID: msg.ID, Payload: payload}, nil
+}
+
+// Filter selects messages for the subscriber, empty fields match all messages.
+type Filter struct {
+	ComponentIDs []uint
+	Types        []string
+	// HideNotPublic hides maintenances in the review workflow, it's used for anonymous users.
+	HideNotPublic bool
+}
+
+func (f *Filter) Match(msg *Message) bool {
+	ev := msg.Payload.Event
+
+	if f.HideNotPublic && event.IsMaintenanceReviewStatus(ev.Status) {
+		return false
+	}
+
+	if len(f.Types) > 0 && !slices.Contains(f.Types, ev.Type) {
+		return false
+	}
+
+	if f.ComponentIDs != nil {
+		for _, id := range ev.Components {
+			if slices.Contains(f.ComponentIDs, id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,140 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
+)
+
+func TestFilterMatch(t *testing.T) {
+	newMessage := func(eventType string, status event.Status, components ...uint) *Message {
+		return &Message{
+			ID: 1,
+			Payload: &webhook.Payload{
+				Action: webhook.ActionUpdated,
+				Event:  webhook.Event{Type: eventType, Status: status, Components: components},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		filter   Filter
+		msg      *Message
+		expected bool
+	}{
+		{
+			name:     "Empty filter matches all",
+			filter:   Filter{},
+			msg:      newMessage(event.TypeIncident, event.IncidentDetected, 1),
+			expected: true,
+		},
+		{
+			name:     "Type matches",
+			filter:   Filter{Types: []string{event.TypeMaintenance, event.TypeIncident}},
+			msg:      newMessage(event.TypeIncident, event.IncidentDetected, 1),
+			expected: true,
+		},
+		{
+			name:     "Type doesn't match",
+			filter:   Filter{Types: []string{event.TypeMaintenance}},
+			msg:      newMessage(event.TypeIncident, event.IncidentDetected, 1),
+			expected: false,
+		},
+		{
+			name:     "One of components matches",
+			filter:   Filter{ComponentIDs: []uint{2, 3}},
+			msg:      newMessage(event.TypeIncident, event.IncidentDetected, 1, 3),
+			expected: true,
+		},
+		{
+			name:     "Components don't match",
+			filter:   Filter{ComponentIDs: []uint{2}},
+			msg:      newMessage(event.TypeIncident, event.IncidentDetected, 1, 3),
+			expected: false,
+		},
+		{
+			name:     "Empty region has no components",
+			filter:   Filter{ComponentIDs: []uint{}},
+			msg:      newMessage(event.TypeIncident, event.IncidentDetected, 1),
+			expected: false,
+		},
+		{
+			name:     "Pending review maintenance is hidden",
+			filter:   Filter{HideNotPublic: true},
+			msg:      newMessage(event.TypeMaintenance, event.MaintenancePendingReview, 1),
+			expected: false,
+		},
+		{
+			name:     "Planned maintenance is public",
+			filter:   Filter{HideNotPublic: true},
+			msg:      newMessage(event.TypeMaintenance, event.MaintenancePlanned, 1),
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.filter.Match(tc.msg))
+		})
+	}
+}
+
+func TestBrokerBroadcast(t *testing.T) {
+	b := NewBroker(nil, zaptest.NewLogger(t))
+
+	messages, unsubscribe := b.Subscribe()
+	msg := &Message{ID: 10, Payload: &webhook.Payload{Action: webhook.ActionCreated}}
+	b.broadcast(msg)
+	assert.Equal(t, msg, <-messages)
+
+	unsubscribe()
+	_, ok := <-messages
+	assert.False(t, ok, "the channel should be closed after unsubscribe")
+	// the second call is safe
+	unsubscribe()
+
+	slow, _ := b.Subscribe()
+	for i := range subscriberBuffer + 1 {
+		b.broadcast(&Message{ID: uint(i)})
+	}
+	count := 0
+	for range slow {
+		count++
+	}
+	assert.Equal(t, subscriberBuffer, count, "the slow subscriber should be disconnected")
+}
+
+func TestBrokerReplay(t *testing.T) {
+	dbInst, mock, err := db.NewWithMock()
+	require.NoError(t, err)
+	b := NewBroker(dbInst, zaptest.NewLogger(t))
+
+	columns := []string{"id", "incident_id", "action", "payload"}
+	expectPage := func(afterID, count int) {
+		rows := sqlmock.NewRows(columns)
+		for i := 1; i <= count; i++ {
+			rows.AddRow(afterID+i, 1, webhook.ActionUpdated, `{"action":"event.updated"}`)
+		}
+		mock.ExpectQuery(`^SELECT \* FROM "event_stream" WHERE id > \$1 ORDER BY id LIMIT \$2`).
+			WithArgs(afterID, batchSize).
+			WillReturnRows(rows)
+	}
+	expectPage(10, batchSize)
+	expectPage(10+batchSize, 1)
+	expectPage(10+batchSize+1, 0)
+
+	messages, err := b.Replay(10)
+	require.NoError(t, err)
+	require.Len(t, messages, batchSize+1, "all batches should be replayed")
+	assert.Equal(t, uint(11), messages[0].ID)
+	assert.Equal(t, uint(10+batchSize+1), messages[batchSize].ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
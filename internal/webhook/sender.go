@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/conf"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
+)
+
+const (
+	defaultPeriod  = time.Second * 15
+	requestTimeout = time.Second * 10
+	batchSize      = 100
+	// claimLease is the period, in which the claimed deliveries are not sent by other replicas.
+	// The sender stops the batch before the lease ends, the rest of the deliveries are claimed again later.
+	claimLease = time.Minute * 5
+
+	// The delay between attempts is doubled every time: 30s, 1m, 2m, ... up to maxBackoff.
+	MaxAttempts = 8
+	baseBackoff = time.Second * 30
+	maxBackoff  = time.Hour
+)
+
+// Sender sends pending webhook deliveries and retries failed ones with the exponential backoff.
+type Sender struct {
+	db     *db.DB
+	log    *zap.Logger
+	client *http.Client
+}
+
+func New(c *conf.Config, log *zap.Logger) (*Sender, error) {
+	dbNew, err := db.New(c)
+	if err != nil {
+		return nil, err
+	}
+	if err = metrics.InstrumentDB(dbNew); err != nil {
+		return nil, err
+	}
+	return &Sender{db: dbNew, log: log, client: &http.Client{Timeout: requestTimeout}}, nil
+}
+
+func (s *Sender) Run(done chan struct{}) {
+	s.log.Info("webhook sender is started")
+	ticker := time.NewTicker(defaultPeriod)
+	defer ticker.Stop()
+
+	for { //nolint:nolintlint
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.SendPending(); err != nil {
+				s.log.Error("error to send webhooks", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *Sender) Shutdown(done chan struct{}) error {
+	s.log.Info("start to shutdown webhook sender")
+	done <- struct{}{}
+	close(done)
+	return s.db.Close()
+}
+
+func (s *Sender) SendPending() error {
+	deadline := time.Now().Add(claimLease - requestTimeout)
+	deliveries, err := s.db.ClaimDueWebhookDeliveries(batchSize, claimLease)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deliveries {
+		if time.Now().After(deadline) {
+			s.log.Warn("the claim of the deliveries is expiring, the rest of them is sent later")
+			return nil
+		}
+
+		s.send(d)
+		if err = s.db.ModifyWebhookDelivery(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Sender) send(d *db.WebhookDelivery) {
+	log := s.log.With(zap.Uint("deliveryID", d.ID), zap.Uint("webhookID", d.WebhookID))
+	d.Attempts++
+
+	if d.Webhook == nil || !d.Webhook.Active {
+		log.Info("the webhook is disabled, skip the delivery")
+		d.Status = db.WebhookDeliveryFailed
+		d.LastError = stringPtr("webhook is disabled")
+		return
+	}
+
+	code, err := s.post(d)
+	if code != 0 {
+		d.ResponseCode = &code
+	}
+
+	if err == nil {
+		now := time.Now().UTC()
+		d.Status = db.WebhookDeliveryDelivered
+		d.DeliveredAt = &now
+		d.LastError = nil
+		log.Info("the webhook is delivered", zap.Int("attempts", d.Attempts))
+		return
+	}
+
+	d.LastError = stringPtr(err.Error())
+	if d.Attempts >= MaxAttempts {
+		d.Status = db.WebhookDeliveryFailed
+		log.Error("the webhook delivery is failed, no attempts left", zap.Error(err))
+		return
+	}
+
+	d.NextAttemptAt = time.Now().UTC().Add(NextAttemptDelay(d.Attempts))
+	log.Warn("the webhook delivery is failed, retry later", zap.Error(err), zap.Time("nextAttemptAt", d.NextAttemptAt))
+}
+
+func (s *Sender) post(d *db.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	req, err := http.NewRequest(http.MethodPost, d.Webhook.URL, bytes.NewReader(body)) //nolint:noctx
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ActionHeader, d.Action)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(SignatureHeader, Sign(d.Webhook.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// NextAttemptDelay returns the delay before the next attempt, attempts is the number of failed attempts.
+func NextAttemptDelay(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return delay
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
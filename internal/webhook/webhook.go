@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+type Action string
+
+const (
+	ActionCreated   Action = "event.created"
+	ActionUpdated   Action = "event.updated"
+	ActionExtracted Action = "event.extracted"
+	ActionMerged    Action = "event.merged"
+)
+
+const (
+	// SignatureHeader contains HMAC-SHA256 of the request body, the format is "sha256=<hex>".
+	SignatureHeader = "X-SD-Signature"
+	ActionHeader    = "X-SD-Event"
+	DeliveryHeader  = "X-SD-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+type Payload struct {
+	Action    Action    `json:"action"`
+	Timestamp time.Time `json:"timestamp"`
+	Event     Event     `json:"event"`
+}
+
+type Event struct {
+	ID          uint         `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	Type        string       `json:"type"`
+	Impact      *int         `json:"impact"`
+	Status      event.Status `json:"status"`
+	System      bool         `json:"system"`
+	StartDate   *time.Time   `json:"start_date"`
+	EndDate     *time.Time   `json:"end_date,omitempty"`
+	Components  []uint       `json:"components"`
+	// Update is the latest status update of the event.
+	Update *Update `json:"update,omitempty"`
+}
+
+type Update struct {
+	Status    event.Status `json:"status"`
+	Text      string       `json:"text"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+func NewPayload(action Action, inc *db.Incident) *Payload {
+	components := make([]uint, len(inc.Components))
+	for i, comp := range inc.Components {
+		components[i] = comp.ID
+	}
+
+	var title, description string
+	if inc.Text != nil {
+		title = *inc.Text
+	}
+	if inc.Description != nil {
+		description = *inc.Description
+	}
+
+	var update *Update
+	for _, st := range inc.Statuses {
+		if update == nil || !st.Timestamp.Before(update.Timestamp) {
+			update = &Update{Status: st.Status, Text: st.Text, Timestamp: st.Timestamp}
+		}
+	}
+
+	return &Payload{
+		Action:    action,
+		Timestamp: time.Now().UTC(),
+		Event: Event{
+			ID:          inc.ID,
+			Title:       title,
+			Description: description,
+			Type:        inc.Type,
+			Impact:      inc.Impact,
+			Status:      inc.Status,
+			System:      inc.System,
+			StartDate:   inc.StartDate,
+			EndDate:     inc.EndDate,
+			Components:  components,
+			Update:      update,
+		},
+	}
+}
+
+// Enqueue stores deliveries for all active webhooks, which are subscribed to the event type.
+// The deliveries are sent by the Sender.
+func Enqueue(dbInst *db.DB, action Action, eventID uint) error {
+	webhooks, err := dbInst.GetActiveWebhooks()
+	if err != nil {
+		return err
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	inc, err := dbInst.GetIncident(int(eventID))
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(NewPayload(action, inc))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var deliveries []*db.WebhookDelivery
+	for _, wh := range webhooks {
+		if !wh.Accepts(inc.Type) {
+			continue
+		}
+
+		deliveries = append(deliveries, &db.WebhookDelivery{
+			WebhookID:     wh.ID,
+			IncidentID:    inc.ID,
+			Action:        string(action),
+			Payload:       string(body),
+			Status:        db.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     &now,
+		})
+	}
+
+	return dbInst.SaveWebhookDeliveries(deliveries)
+}
+
+// Sign returns the signature of the body, subscribers should compare it with the SignatureHeader value.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
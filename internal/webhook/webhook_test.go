@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '{"action":"event.created"}' | openssl dgst -sha256 -hmac "secret"
+	signature := Sign("secret", []byte(`{"action":"event.created"}`))
+	assert.Equal(t, "sha256=350c6bb879d8f14d1d477acba153f257c9f5383a9ccfe6895d94791231e239b5", signature)
+	assert.NotEqual(t, signature, Sign("another", []byte(`{"action":"event.created"}`)))
+}
+
+func TestNextAttemptDelay(t *testing.T) {
+	assert.Equal(t, time.Second*30, NextAttemptDelay(1))
+	assert.Equal(t, time.Minute, NextAttemptDelay(2))
+	assert.Equal(t, time.Minute*2, NextAttemptDelay(3))
+	assert.Equal(t, time.Hour, NextAttemptDelay(MaxAttempts))
+}
+
+func TestSend(t *testing.T) {
+	var receivedSignature, receivedAction string
+	var receivedBody []byte
+	responseCode := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedSignature = r.Header.Get(SignatureHeader)
+		receivedAction = r.Header.Get(ActionHeader)
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(responseCode)
+	}))
+	defer srv.Close()
+
+	s := &Sender{log: zaptest.NewLogger(t), client: srv.Client()}
+	newDelivery := func() *db.WebhookDelivery {
+		return &db.WebhookDelivery{
+			ID:      1,
+			Webhook: &db.Webhook{ID: 1, URL: srv.URL, Secret: "secret", Active: true},
+			Action:  string(ActionCreated),
+			Payload: `{"action":"event.created"}`,
+			Status:  db.WebhookDeliveryPending,
+		}
+	}
+
+	t.Run("Delivered", func(t *testing.T) {
+		d := newDelivery()
+		s.send(d)
+		assert.Equal(t, db.WebhookDeliveryDelivered, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		require.NotNil(t, d.ResponseCode)
+		assert.Equal(t, http.StatusOK, *d.ResponseCode)
+		assert.Equal(t, Sign("secret", []byte(d.Payload)), receivedSignature)
+		assert.Equal(t, string(ActionCreated), receivedAction)
+		assert.JSONEq(t, d.Payload, string(receivedBody))
+	})
+
+	t.Run("Retry on error", func(t *testing.T) {
+		responseCode = http.StatusInternalServerError
+		d := newDelivery()
+		s.send(d)
+		assert.Equal(t, db.WebhookDeliveryPending, d.Status)
+		assert.True(t, d.NextAttemptAt.After(time.Now().UTC()))
+		require.NotNil(t, d.LastError)
+	})
+
+	t.Run("Failed after the last attempt", func(t *testing.T) {
+		responseCode = http.StatusBadGateway
+		d := newDelivery()
+		d.Attempts = MaxAttempts - 1
+		s.send(d)
+		assert.Equal(t, db.WebhookDeliveryFailed, d.Status)
+		assert.Equal(t, MaxAttempts, d.Attempts)
+	})
+}
@@ -8,8 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
 )
 
 const (
@@ -51,3 +56,31 @@ func sha256sum(s string) string {
 	h.Write([]byte(s))
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+func TestAuthPostgresTokenStore(t *testing.T) {
+	t.Log("start to test the postgres token store")
+
+	_, d, _ := initTests(t)
+
+	store, err := auth.NewTokenStore("postgres?ttl=1m", d)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	token := auth.TokenRepr{AccessToken: "access", RefreshToken: "refresh"}
+	require.NoError(t, store.Store(ctx, "challenge", token))
+
+	stored, err := store.Pop(ctx, "challenge")
+	require.NoError(t, err)
+	assert.Equal(t, token, stored)
+
+	// the token is read only once
+	_, err = store.Pop(ctx, "challenge")
+	require.ErrorIs(t, err, auth.ErrTokenNotFound)
+
+	// the expired tokens are not returned and removed on the next save
+	require.NoError(t, d.SaveAuthToken(&db.AuthToken{
+		Key: "expired", Data: "{}", ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	}))
+	_, err = store.Pop(ctx, "expired")
+	require.ErrorIs(t, err, auth.ErrTokenNotFound)
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/cache"
+	"github.com/stackmon/otc-status-dashboard/internal/conf"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+func TestCacheInvalidation(t *testing.T) {
+	t.Log("start to test the cache invalidation by the database writes")
+
+	d, err := db.New(&conf.Config{DB: databaseURL, Cache: "internal?size=10"})
+	require.NoError(t, err)
+	defer d.Close()
+
+	readCache := d.Cache()
+	require.NotNil(t, readCache)
+
+	ctx := t.Context()
+	_, gen, err := readCache.Get(ctx, "/v2/regions")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	require.NoError(t, readCache.Set(ctx, "/v2/regions", gen, &cache.Entry{Body: []byte("[]")}))
+
+	// reads don't invalidate the cache
+	_, err = d.GetRegions()
+	require.NoError(t, err)
+	_, _, err = readCache.Get(ctx, "/v2/regions")
+	require.NoError(t, err)
+
+	id, err := d.SaveRegion(&db.Region{Name: "cache-test-region"})
+	require.NoError(t, err)
+	_, newGen, err := readCache.Get(ctx, "/v2/regions")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	// the response built before the write isn't stored
+	require.NoError(t, readCache.Set(ctx, "/v2/regions", gen, &cache.Entry{Body: []byte("[]")}))
+	_, _, err = readCache.Get(ctx, "/v2/regions")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	require.NoError(t, readCache.Set(ctx, "/v2/regions", newGen, &cache.Entry{Body: []byte("[]")}))
+	require.NoError(t, d.DeleteRegion(id))
+	_, _, err = readCache.Get(ctx, "/v2/regions")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+}
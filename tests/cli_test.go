@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/cli"
+	"github.com/stackmon/otc-status-dashboard/internal/conf"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestCLIEvents(t *testing.T) {
+	t.Log("start to test the admin commands for events")
+	r, d, _ := initTests(t)
+
+	compIDs := make([]int, 0, 2)
+	for i := range 2 {
+		w := v2JSONRequest(t, r, http.MethodPost, "/v2/components", &v2.PostComponentData{
+			Name: fmt.Sprintf("CLI Service %d", i),
+			Attributes: []v2.ComponentAttribute{
+				{Name: "type", Value: fmt.Sprintf("cli%d", i)},
+				{Name: "region", Value: "EU-DE"},
+				{Name: "category", Value: "Test"},
+			},
+		})
+		require.Equal(t, http.StatusCreated, w.Code)
+		comp := &v2.Component{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), comp))
+		compIDs = append(compIDs, comp.ID)
+	}
+
+	t.Log("create two incidents")
+	first := runCLIEventID(t, "events", "create", "-title", "cli incident",
+		"-impact", "2", "-components", strconv.Itoa(compIDs[0]))
+	second := runCLIEventID(t, "events", "create", "-title", "cli incident 2",
+		"-impact", "1", "-components", strconv.Itoa(compIDs[1]))
+
+	out := runCLI(t, "events", "list", "-type", event.TypeIncident, "-active")
+	assert.Contains(t, out, "cli incident 2")
+
+	t.Log("move the only component of the second incident, the incident is closed")
+	runCLI(t, "events", "move", "-component", strconv.Itoa(compIDs[1]),
+		"-from", strconv.Itoa(second), "-to", strconv.Itoa(first))
+
+	inc, err := d.GetIncident(second)
+	require.NoError(t, err)
+	assert.NotNil(t, inc.EndDate)
+	assert.Equal(t, event.IncidentResolved, inc.Status)
+
+	t.Log("add an update and close the first incident")
+	runCLI(t, "events", "update", "-id", strconv.Itoa(first),
+		"-status", string(event.IncidentFixing), "-message", "fixing from the CLI")
+	runCLI(t, "events", "close", "-id", strconv.Itoa(first))
+
+	inc, err = d.GetIncident(first)
+	require.NoError(t, err)
+	assert.NotNil(t, inc.EndDate)
+	assert.Equal(t, event.IncidentResolved, inc.Status)
+	assert.Len(t, inc.Components, 2)
+}
+
+func runCLI(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	require.NoError(t, cli.Run(&conf.Config{DB: databaseURL}, zap.NewNop(), args, &out))
+
+	return out.String()
+}
+
+// runCLIEventID runs the command and returns the ID of the event from the output.
+func runCLIEventID(t *testing.T, args ...string) int {
+	t.Helper()
+
+	out := runCLI(t, args...)
+	match := regexp.MustCompile(`event (\d+)`).FindStringSubmatch(out)
+	require.Len(t, match, 2, out)
+
+	id, err := strconv.Atoi(match[1])
+	require.NoError(t, err)
+
+	return id
+}
@@ -2,7 +2,6 @@ package tests
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -10,9 +9,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -23,6 +19,7 @@ import (
 	gormpostgres "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"github.com/stackmon/otc-status-dashboard/internal/alertmanager"
 	"github.com/stackmon/otc-status-dashboard/internal/api"
 	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
 	apiErrors "github.com/stackmon/otc-status-dashboard/internal/api/errors"
@@ -30,6 +27,8 @@ import (
 	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
 	"github.com/stackmon/otc-status-dashboard/internal/conf"
 	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/rss"
+	"github.com/stackmon/otc-status-dashboard/internal/statuspage"
 )
 
 const (
@@ -85,24 +84,13 @@ func TestMain(m *testing.M) {
 }
 
 func applyMigrations(dbURL string) error {
-	// Get the project root directory
-	migrationsPath := filepath.Join("..", "db", "migrations")
-
-	m, err := migrate.New(
-		fmt.Sprintf("file://%s", migrationsPath),
-		dbURL,
-	)
+	// the embedded migrations are applied in the same way as on the app startup
+	schema, err := db.PrepareSchema(context.Background(), dbURL, true)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
-	}
-	defer m.Close()
-
-	// Apply all migrations
-	if errMig := m.Up(); errMig != nil && !errors.Is(errMig, migrate.ErrNoChange) {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("migrations applied successfully")
+	log.Printf("migrations applied successfully, %s", schema)
 	return nil
 }
 
@@ -127,9 +115,15 @@ func initTests(t *testing.T) (*gin.Engine, *db.DB, *auth.Provider) {
 	cfg, err := conf.LoadConf()
 	require.NoError(t, err)
 
-	oa2Prov, err := auth.NewProvider(cfg.Keycloak.URL, cfg.Keycloak.Realm, cfg.Keycloak.ClientID, cfg.Keycloak.ClientSecret, cfg.Hostname, cfg.WebURL)
+	oa2Prov, err := auth.NewProvider(
+		auth.KeycloakConfig(cfg.Keycloak.URL, cfg.Keycloak.Realm, cfg.Keycloak.ClientID, cfg.Keycloak.ClientSecret),
+		cfg.Hostname, cfg.WebURL, 0, nil,
+	)
 	require.NoError(t, err)
 
+	// the routes are used without the authentication, as with the disabled authentication
+	r.Use(api.OptionalAuthenticationMW(&auth.Provider{Disabled: true}, d, logger, "", &auth.RoleGroups{}))
+
 	initRoutesAuth(t, r, oa2Prov, logger)
 	initRoutesV1(t, r, d, logger)
 	initRoutesV2(t, r, d, logger)
@@ -170,6 +164,14 @@ func initRoutesV2(t *testing.T, c *gin.Engine, dbInst *db.DB, logger *zap.Logger
 	v2Api.GET("components", v2.GetComponentsHandler(dbInst, logger))
 	v2Api.POST("components", v2.PostComponentHandler(dbInst, logger))
 	v2Api.GET("components/:id", v2.GetComponentHandler(dbInst, logger))
+	v2Api.PATCH("components/:id", v2.PatchComponentHandler(dbInst, logger))
+	v2Api.DELETE("components/:id", v2.DeleteComponentHandler(dbInst, logger))
+
+	v2Api.GET("regions", v2.GetRegionsHandler(dbInst, logger))
+	v2Api.GET("regions/:id", v2.GetRegionHandler(dbInst, logger))
+	v2Api.POST("regions", v2.PostRegionHandler(dbInst, logger))
+	v2Api.PATCH("regions/:id", v2.PatchRegionHandler(dbInst, logger))
+	v2Api.DELETE("regions/:id", v2.DeleteRegionHandler(dbInst, logger))
 
 	// Incidents routes are deprecated.
 	// They will be removed in the next iteration.
@@ -184,6 +186,9 @@ func initRoutesV2(t *testing.T, c *gin.Engine, dbInst *db.DB, logger *zap.Logger
 	v2Api.POST("incidents/:eventID/extract",
 		api.CheckEventExistenceMW(dbInst, logger),
 		v2.PostIncidentExtractHandler(dbInst, logger))
+	v2Api.POST("incidents/:eventID/merge",
+		api.CheckEventExistenceMW(dbInst, logger),
+		v2.PostIncidentMergeHandler(dbInst, logger))
 	v2Api.PATCH("incidents/:eventID/updates/:updateID",
 		api.CheckEventExistenceMW(dbInst, logger),
 		v2.PatchEventUpdateTextHandler(dbInst, logger))
@@ -200,11 +205,57 @@ func initRoutesV2(t *testing.T, c *gin.Engine, dbInst *db.DB, logger *zap.Logger
 	v2Api.POST("events/:eventID/extract",
 		api.CheckEventExistenceMW(dbInst, logger),
 		v2.PostIncidentExtractHandler(dbInst, logger))
+	v2Api.POST("events/:eventID/merge",
+		api.CheckEventExistenceMW(dbInst, logger),
+		v2.PostIncidentMergeHandler(dbInst, logger))
 	v2Api.PATCH("events/:eventID/updates/:updateID",
 		api.CheckEventExistenceMW(dbInst, logger),
 		v2.PatchEventUpdateTextHandler(dbInst, logger))
 
 	v2Api.GET("availability", v2.GetComponentsAvailabilityHandler(dbInst, logger))
+
+	// Webhooks routes.
+	v2Api.GET("webhooks", v2.GetWebhooksHandler(dbInst, logger))
+	v2Api.POST("webhooks", v2.PostWebhookHandler(dbInst, logger))
+	v2Api.GET("webhooks/:id", v2.GetWebhookHandler(dbInst, logger))
+	v2Api.PATCH("webhooks/:id", v2.PatchWebhookHandler(dbInst, logger))
+	v2Api.DELETE("webhooks/:id", v2.DeleteWebhookHandler(dbInst, logger))
+	v2Api.GET("webhooks/:id/deliveries", v2.GetWebhookDeliveriesHandler(dbInst, logger))
+
+	// API tokens routes.
+	v2Api.GET("tokens", v2.GetAPITokensHandler(dbInst, logger))
+	v2Api.POST("tokens", v2.PostAPITokenHandler(dbInst, logger))
+	v2Api.GET("tokens/:id", v2.GetAPITokenHandler(dbInst, logger))
+	v2Api.DELETE("tokens/:id", v2.DeleteAPITokenHandler(dbInst, logger))
+
+	v2Api.GET("audit", v2.GetAuditHandler(dbInst, logger))
+
+	alertRules, err := alertmanager.ParseRules("")
+	require.NoError(t, err)
+	v2Api.POST("alertmanager", v2.PostAlertmanagerHandler(dbInst, logger, alertRules))
+
+	// Maintenance series routes.
+	v2Api.GET("maintenance-series", v2.GetMaintenanceSeriesListHandler(dbInst, logger))
+	v2Api.POST("maintenance-series", v2.PostMaintenanceSeriesHandler(dbInst, logger))
+	v2Api.GET("maintenance-series/:id", v2.GetMaintenanceSeriesHandler(dbInst, logger))
+	v2Api.PATCH("maintenance-series/:id", v2.PatchMaintenanceSeriesHandler(dbInst, logger))
+	v2Api.DELETE("maintenance-series/:id", v2.DeleteMaintenanceSeriesHandler(dbInst, logger))
+
+	// Subscriptions routes.
+	v2Api.POST("subscriptions", v2.PostSubscriptionHandler(dbInst, logger))
+	v2Api.GET("subscriptions/confirm", v2.GetSubscriptionConfirmHandler(logger))
+	v2Api.POST("subscriptions/confirm", v2.PostSubscriptionConfirmHandler(dbInst, logger))
+	v2Api.GET("subscriptions/unsubscribe", v2.GetUnsubscribeHandler(logger))
+	v2Api.POST("subscriptions/unsubscribe", v2.UnsubscribeHandler(dbInst, logger))
+
+	// Feeds routes.
+	v2Api.GET("feed.json", rss.HandleJSONFeed(dbInst, logger))
+
+	// Status page and badges routes.
+	c.GET("status", statuspage.HandlePage(dbInst, logger))
+	badgesAPI := v2Api.Group("badges", statuspage.BadgeCacheControlMW())
+	badgesAPI.GET("components/:id", statuspage.HandleComponentBadge(dbInst, logger))
+	badgesAPI.GET("regions/:name", statuspage.HandleRegionBadge(dbInst, logger))
 }
 
 func truncateIncidents(t *testing.T) {
@@ -214,7 +265,10 @@ func truncateIncidents(t *testing.T) {
 	gormDB, err := gorm.Open(gormpostgres.Open(databaseURL), &gorm.Config{})
 	require.NoError(t, err, "failed to open gorm connection for truncation")
 
-	result := gormDB.Exec("TRUNCATE TABLE incident, incident_status, incident_component_relation RESTART IDENTITY")
+	result := gormDB.Exec(
+		"TRUNCATE TABLE incident, incident_status, incident_component_relation, audit_log, maintenance_series, " +
+			"maintenance_series_component_relation, alert, email_notification RESTART IDENTITY",
+	)
 	require.NoError(t, result.Error, "failed to truncate incident tables")
 
 	sqlDB, err := gormDB.DB()
@@ -222,3 +276,51 @@ func truncateIncidents(t *testing.T) {
 	err = sqlDB.Close()
 	require.NoError(t, err, "failed to close gorm connection for truncation")
 }
+
+func truncateWebhooks(t *testing.T) {
+	t.Helper()
+	t.Log("cleaning up webhook tables before test")
+
+	gormDB, err := gorm.Open(gormpostgres.Open(databaseURL), &gorm.Config{})
+	require.NoError(t, err, "failed to open gorm connection for truncation")
+
+	result := gormDB.Exec("TRUNCATE TABLE webhook, webhook_delivery RESTART IDENTITY")
+	require.NoError(t, result.Error, "failed to truncate webhook tables")
+
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err, "failed to get sql.DB from gorm for closing")
+	err = sqlDB.Close()
+	require.NoError(t, err, "failed to close gorm connection for truncation")
+}
+
+func truncateSubscribers(t *testing.T) {
+	t.Helper()
+	t.Log("cleaning up subscriber tables before test")
+
+	gormDB, err := gorm.Open(gormpostgres.Open(databaseURL), &gorm.Config{})
+	require.NoError(t, err, "failed to open gorm connection for truncation")
+
+	result := gormDB.Exec("TRUNCATE TABLE subscriber, email_message RESTART IDENTITY")
+	require.NoError(t, result.Error, "failed to truncate subscriber tables")
+
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err, "failed to get sql.DB from gorm for closing")
+	err = sqlDB.Close()
+	require.NoError(t, err, "failed to close gorm connection for truncation")
+}
+
+func truncateAPITokens(t *testing.T) {
+	t.Helper()
+	t.Log("cleaning up api tokens before test")
+
+	gormDB, err := gorm.Open(gormpostgres.Open(databaseURL), &gorm.Config{})
+	require.NoError(t, err, "failed to open gorm connection for truncation")
+
+	result := gormDB.Exec("TRUNCATE TABLE api_token RESTART IDENTITY")
+	require.NoError(t, result.Error, "failed to truncate api token table")
+
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err, "failed to get sql.DB from gorm for closing")
+	err = sqlDB.Close()
+	require.NoError(t, err, "failed to close gorm connection for truncation")
+}
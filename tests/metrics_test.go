@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/metrics"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	t.Log("start to test /metrics")
+	_, d, _ := initTests(t)
+
+	stats, err := d.GetStatusStats()
+	require.NoError(t, err)
+	assert.Len(t, stats.OpenIncidentsByImpact, 3)
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(metrics.NewStatusCollector(d, zap.NewNop())))
+
+	w := httptest.NewRecorder()
+	metrics.Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `status_dashboard_open_incidents{impact="3"}`)
+	assert.Contains(t, body, "status_dashboard_active_maintenances")
+	assert.Contains(t, body, "status_dashboard_db_query_duration_seconds")
+}
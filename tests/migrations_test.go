@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+func TestPrepareSchema(t *testing.T) {
+	t.Log("start to test the schema check and the embedded migrations")
+	ctx := context.Background()
+
+	latest, err := db.LatestSchemaVersion()
+	require.NoError(t, err)
+
+	t.Log("the schema is migrated by TestMain, the check passes without the migrations")
+	schema, err := db.PrepareSchema(ctx, databaseURL, false)
+	require.NoError(t, err)
+	assert.Equal(t, latest, schema.Current)
+	assert.False(t, schema.Dirty)
+
+	t.Log("the replicas start at once, the migrations are serialised by the advisory lock")
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = db.PrepareSchema(ctx, databaseURL, true)
+		}()
+	}
+	wg.Wait()
+	for _, errPrepare := range errs {
+		require.NoError(t, errPrepare)
+	}
+
+	t.Log("the migrate command prints the current and the latest version")
+	out := runCLI(t, "migrate", "version")
+	assert.Equal(t, fmt.Sprintf("version: %d, latest: %d, dirty: false\n", latest, latest), out)
+	out = runCLI(t, "migrate", "up")
+	assert.Equal(t, fmt.Sprintf("version: %d, latest: %d, dirty: false\n", latest, latest), out)
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestStatusPageAndBadges(t *testing.T) {
+	t.Log("start to test the status page and the badges")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	t.Log("all components are operational")
+	w := v2JSONRequest(t, r, http.MethodGet, "/status", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "All systems operational")
+	assert.Contains(t, w.Body.String(), "No maintenances are planned.")
+
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/badges/components/3", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), "<title>Elastic Cloud Server: operational</title>")
+
+	t.Log("the incident and the planned maintenance are shown")
+	impact := 2
+	system := false
+	require.NotNil(t, v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "status page incident",
+		Impact:     &impact,
+		Components: []int{3},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	}))
+
+	noImpact := 0
+	end := time.Now().Add(time.Hour * 26).UTC()
+	require.NotNil(t, v2CreateEvent(t, r, &v2.IncidentData{
+		Title:       "status page maintenance",
+		Description: "The planned upgrade.",
+		Impact:      &noImpact,
+		Components:  []int{4},
+		StartDate:   time.Now().Add(time.Hour * 24).UTC(),
+		EndDate:     &end,
+		System:      &system,
+		Type:        event.TypeMaintenance,
+	}))
+
+	w = v2JSONRequest(t, r, http.MethodGet, "/status", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Major incident")
+	assert.Contains(t, w.Body.String(), "status page incident")
+	assert.Contains(t, w.Body.String(), "status page maintenance")
+
+	t.Log("the region filter shows only the events of the region")
+	w = v2JSONRequest(t, r, http.MethodGet, "/status?region=EU-NL", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "status page incident")
+	assert.Contains(t, w.Body.String(), "status page maintenance")
+	w = v2JSONRequest(t, r, http.MethodGet, "/status?region=unknown", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	t.Log("the badges reflect the highest impact")
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/badges/components/3", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>Elastic Cloud Server: major</title>")
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/badges/regions/EU-DE", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>EU-DE: major</title>")
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/badges/regions/EU-NL", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>EU-NL: operational</title>", "the planned maintenance isn't active")
+
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/badges/components/999", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/badges/components/abc", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/badges/regions/unknown", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
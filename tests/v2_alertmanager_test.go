@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stackmon/otc-status-dashboard/internal/alertmanager"
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const v2AlertmanagerEndpoint = "/v2/alertmanager"
+
+func TestV2AlertmanagerHandler(t *testing.T) {
+	t.Log("start to test the Alertmanager receiver for /v2/alertmanager")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	startsAt := time.Now().Add(-time.Minute * 10).UTC()
+	ecsAlert := alertmanager.Alert{
+		Status:      alertmanager.StatusFiring,
+		Labels:      map[string]string{"alertname": "HighErrorRate", "type": "ecs", "region": "EU-DE", "severity": "major"},
+		Annotations: map[string]string{"summary": "High error rate of the ECS API"},
+		StartsAt:    startsAt,
+		Fingerprint: "ecs0001",
+	}
+	cceAlert := alertmanager.Alert{
+		Status:      alertmanager.StatusFiring,
+		Labels:      map[string]string{"alertname": "NodesNotReady", "type": "cce", "region": "EU-DE", "severity": "major"},
+		StartsAt:    startsAt.Add(time.Minute),
+		Fingerprint: "cce0001",
+	}
+
+	t.Log("the firing alert opens the system incident")
+	results := v2SendAlerts(t, r, ecsAlert)
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Skipped)
+	assert.Equal(t, []int{3}, results[0].Components)
+	require.Len(t, results[0].Incidents, 1)
+	incidentID := results[0].Incidents[0]
+
+	inc := v2GetEvent(t, r, incidentID)
+	assert.True(t, *inc.System)
+	assert.Equal(t, 2, *inc.Impact)
+	assert.Equal(t, "High error rate of the ECS API", inc.Title)
+	assert.Equal(t, []int{3}, inc.Components)
+
+	t.Log("the repeated notification is deduplicated")
+	results = v2SendAlerts(t, r, ecsAlert)
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Skipped)
+	assert.Len(t, v2GetEvent(t, r, incidentID).Updates, len(inc.Updates))
+
+	t.Log("the alert of another component joins the system incident with the same impact")
+	results = v2SendAlerts(t, r, cceAlert)
+	require.Len(t, results, 1)
+	assert.Equal(t, []int{incidentID}, results[0].Incidents)
+	assert.ElementsMatch(t, []int{1, 3}, v2GetEvent(t, r, incidentID).Components)
+
+	t.Log("the alert without the matched components is skipped")
+	results = v2SendAlerts(t, r, alertmanager.Alert{
+		Status:      alertmanager.StatusFiring,
+		Labels:      map[string]string{"alertname": "Watchdog"},
+		StartsAt:    startsAt,
+		Fingerprint: "watchdog",
+	})
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Skipped)
+
+	t.Log("the alert without the start date is skipped, its repeated notifications can't be deduplicated")
+	results = v2SendAlerts(t, r, alertmanager.Alert{
+		Status:      alertmanager.StatusFiring,
+		Labels:      ecsAlert.Labels,
+		Fingerprint: "ecs0002",
+	})
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Skipped)
+	assert.Empty(t, results[0].Incidents)
+
+	t.Log("the resolved alert adds the update, the incident is still affected by the other alert")
+	ecsAlert.Status = alertmanager.StatusResolved
+	ecsAlert.EndsAt = time.Now().UTC()
+	results = v2SendAlerts(t, r, ecsAlert)
+	require.Len(t, results, 1)
+	assert.Equal(t, []int{incidentID}, results[0].Incidents)
+
+	inc = v2GetEvent(t, r, incidentID)
+	assert.Nil(t, inc.EndDate)
+	assert.Contains(t, inc.Updates[len(inc.Updates)-1].Text, "Elastic Cloud Server")
+
+	results = v2SendAlerts(t, r, ecsAlert)
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Skipped)
+
+	t.Log("the last resolved alert resolves the incident")
+	cceAlert.Status = alertmanager.StatusResolved
+	cceAlert.EndsAt = time.Now().UTC()
+	results = v2SendAlerts(t, r, cceAlert)
+	require.Len(t, results, 1)
+	assert.Equal(t, []int{incidentID}, results[0].Incidents)
+
+	inc = v2GetEvent(t, r, incidentID)
+	assert.Equal(t, event.IncidentResolved, inc.Status)
+	assert.NotNil(t, inc.EndDate)
+
+	w := v2WebhookRequest(t, r, http.MethodPost, v2AlertmanagerEndpoint, map[string]any{"alerts": []map[string]any{
+		{"status": "unknown", "fingerprint": "bad"},
+	}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func v2SendAlerts(t *testing.T, r *gin.Engine, alerts ...alertmanager.Alert) []*v2.AlertResult {
+	t.Helper()
+
+	w := v2WebhookRequest(t, r, http.MethodPost, v2AlertmanagerEndpoint, &alertmanager.Message{
+		Version:  "4",
+		Status:   alerts[0].Status,
+		Receiver: "status-dashboard",
+		Alerts:   alerts,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Result []*v2.AlertResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return resp.Result
+}
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api"
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const v2TokensEndpoint = "/v2/tokens"
+
+func TestV2APITokensHandler(t *testing.T) {
+	t.Log("start to test api tokens for /v2/tokens")
+	truncateAPITokens(t)
+	truncateIncidents(t)
+	r, dbInst, prov := initTests(t)
+
+	t.Log("check the token validation")
+	w := v2WebhookRequest(t, r, http.MethodPost, v2TokensEndpoint, &v2.APITokenData{
+		Name: "ci", Owner: "team", Scopes: []string{"incident:delete"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	expired := time.Now().Add(-time.Hour).UTC()
+	w = v2WebhookRequest(t, r, http.MethodPost, v2TokensEndpoint, &v2.APITokenData{
+		Name: "ci", Owner: "team", Scopes: []string{string(auth.ScopeIncidentCreate)}, ExpiresAt: &expired,
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = v2WebhookRequest(t, r, http.MethodPost, v2TokensEndpoint, &v2.APITokenData{
+		Name: "ci", Owner: "team", Scopes: []string{string(auth.ScopeIncidentCreate)}, Components: []int{100500},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("issue a token for the incidents of the first component")
+	w = v2WebhookRequest(t, r, http.MethodPost, v2TokensEndpoint, &v2.APITokenData{
+		Name: "ci", Owner: "team", Scopes: []string{string(auth.ScopeIncidentCreate)}, Components: []int{1},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	created := &v2.APIToken{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+	assert.True(t, strings.HasPrefix(created.Token, auth.APITokenPrefix))
+	assert.Equal(t, []uint{1}, created.Components)
+
+	tokenURL := fmt.Sprintf("%s/%d", v2TokensEndpoint, created.ID)
+	w = v2WebhookRequest(t, r, http.MethodGet, tokenURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	stored := &v2.APIToken{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), stored))
+	assert.Empty(t, stored.Token, "the token should be returned only after the creation")
+	assert.Nil(t, stored.LastUsedAt)
+
+	t.Log("use the token to create events")
+	logger, _ := zap.NewDevelopment()
+	tr := gin.New()
+	tr.Use(api.ErrorHandle())
+	tr.POST(v2EventsEndpoint,
+		api.AuthenticationMW(prov, dbInst, logger, "", &auth.RoleGroups{}),
+		api.RequireRoleMW(auth.RoleCreator, logger,
+			auth.ScopeIncidentCreate, auth.ScopeMaintenanceCreate, auth.ScopeInfoCreate),
+		api.ValidateComponentsMW(dbInst, logger),
+		v2.PostIncidentHandler(dbInst, logger),
+	)
+
+	impact := 1
+	system := false
+	incident := &v2.IncidentData{
+		Title:      "token incident",
+		Impact:     &impact,
+		Components: []int{1},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	}
+
+	w = v2TokenRequest(t, tr, created.Token, incident)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = v2TokenRequest(t, tr, "", incident)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = v2TokenRequest(t, tr, auth.APITokenPrefix+"unknown", incident)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	t.Log("the token can't change other components and event types")
+	incident.Components = []int{2}
+	w = v2TokenRequest(t, tr, created.Token, incident)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	zero := 0
+	endDate := time.Now().Add(time.Hour).UTC()
+	w = v2TokenRequest(t, tr, created.Token, &v2.IncidentData{
+		Title:      "token maintenance",
+		Impact:     &zero,
+		Components: []int{1},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		EndDate:    &endDate,
+		System:     &system,
+		Type:       event.TypeMaintenance,
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	t.Log("revoke the token")
+	w = v2WebhookRequest(t, r, http.MethodDelete, tokenURL, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	incident.Components = []int{1}
+	w = v2TokenRequest(t, tr, created.Token, incident)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = v2WebhookRequest(t, r, http.MethodGet, v2TokensEndpoint, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []*v2.APIToken `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.NotNil(t, list.Data[0].RevokedAt)
+	assert.NotNil(t, list.Data[0].LastUsedAt)
+
+	w = v2WebhookRequest(t, r, http.MethodDelete, fmt.Sprintf("%s/%d", v2TokensEndpoint, 100500), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func v2TokenRequest(t *testing.T, r *gin.Engine, token string, inc *v2.IncidentData) *httptest.ResponseRecorder {
+	t.Helper()
+
+	data, err := json.Marshal(inc)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, v2EventsEndpoint, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.ServeHTTP(w, req)
+
+	return w
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stackmon/otc-status-dashboard/internal/api"
+	"github.com/stackmon/otc-status-dashboard/internal/api/auth"
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/audit"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const (
+	v2AuditEndpoint = "/v2/audit"
+	auditTestUser   = "audit-user"
+)
+
+func TestV2AuditHandler(t *testing.T) {
+	t.Log("start to test the audit log for /v2/audit")
+	truncateIncidents(t)
+	r, dbInst, _ := initTests(t)
+
+	logger, _ := zap.NewDevelopment()
+	ar := gin.New()
+	ar.Use(api.ErrorHandle())
+	ar.Use(func(c *gin.Context) {
+		auth.SetIdentity(c, auth.RoleAdmin, auditTestUser)
+	})
+	ar.POST(v2EventsEndpoint, api.ValidateComponentsMW(dbInst, logger), v2.PostIncidentHandler(dbInst, logger))
+	ar.GET(v2EventsEndpoint+"/:eventID",
+		api.CheckEventExistenceMW(dbInst, logger),
+		v2.GetIncidentHandler(dbInst, logger))
+	ar.PATCH(v2EventsEndpoint+"/:eventID",
+		api.CheckEventExistenceMW(dbInst, logger),
+		v2.PatchIncidentHandler(dbInst, logger))
+	ar.PATCH(v2EventsEndpoint+"/:eventID/updates/:updateID",
+		api.CheckEventExistenceMW(dbInst, logger),
+		v2.PatchEventUpdateTextHandler(dbInst, logger))
+
+	t.Log("create an incident by the user")
+	impact := 1
+	system := false
+	w := v2WebhookRequest(t, ar, http.MethodPost, v2EventsEndpoint, &v2.IncidentData{
+		Title:      "audit incident",
+		Impact:     &impact,
+		Components: []int{1},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	created := &v2.PostIncidentResp{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+	require.Len(t, created.Result, 1)
+	eventID := created.Result[0].IncidentID
+	eventURL := fmt.Sprintf("%s/%d", v2EventsEndpoint, eventID)
+
+	t.Log("change the title and the update text")
+	title := "audit incident, changed"
+	w = v2WebhookRequest(t, ar, http.MethodPatch, eventURL, &v2.PatchIncidentData{
+		Title:      &title,
+		Message:    "analysing",
+		Status:     event.IncidentAnalysing,
+		UpdateDate: time.Now().UTC(),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = v2WebhookRequest(t, ar, http.MethodPatch, eventURL+"/updates/0", map[string]string{"text": "fixed text"})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	t.Log("the principal is stored on the event and the updates")
+	stored := v2GetEvent(t, ar, eventID)
+	assert.Equal(t, auditTestUser, stored.Creator)
+	assert.Equal(t, auditTestUser, stored.ModifiedBy)
+	require.Len(t, stored.Updates, 2)
+	for _, upd := range stored.Updates {
+		assert.Equal(t, auditTestUser, upd.Author)
+	}
+
+	anonymous := v2GetEvent(t, r, eventID)
+	assert.Empty(t, anonymous.ModifiedBy)
+
+	t.Log("check the audit log of the event")
+	records := v2GetAudit(t, r, fmt.Sprintf("?event_id=%d", eventID))
+	require.Len(t, records, 3)
+	for _, rec := range records {
+		assert.Equal(t, auditTestUser, rec.Actor)
+		assert.Equal(t, uint(eventID), rec.EventID)
+	}
+	assert.Equal(t, "event.created", records[2].Action)
+	assert.Equal(t, "event.updated", records[0].Action)
+
+	var changes map[string]audit.Change
+	require.NoError(t, json.Unmarshal(records[1].Changes, &changes))
+	assert.Equal(t, "audit incident", changes["title"].Before)
+	assert.Equal(t, title, changes["title"].After)
+	assert.Contains(t, changes, "status")
+	assert.Contains(t, changes, "updates[1]")
+
+	require.NoError(t, json.Unmarshal(records[0].Changes, &changes))
+	require.Len(t, changes, 1)
+	upd, ok := changes["updates[0]"].After.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "fixed text", upd["text"])
+
+	t.Log("check the filters")
+	assert.Len(t, v2GetAudit(t, r, "?action=event.created"), 1)
+	assert.Len(t, v2GetAudit(t, r, "?limit=2"), 2)
+	assert.Empty(t, v2GetAudit(t, r, "?actor=unknown"))
+	since := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	assert.Empty(t, v2GetAudit(t, r, "?since="+since))
+
+	w = v2WebhookRequest(t, r, http.MethodGet, v2AuditEndpoint+"?since=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "audit log query has invalid format")
+}
+
+func v2GetAudit(t *testing.T, r *gin.Engine, query string) []*v2.AuditRecord {
+	t.Helper()
+
+	w := v2WebhookRequest(t, r, http.MethodGet, v2AuditEndpoint+query, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []*v2.AuditRecord `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return resp.Data
+}
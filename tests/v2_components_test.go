@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestV2ComponentLifecycle(t *testing.T) {
+	t.Log("start to test the component update and deletion")
+	r, _, _ := initTests(t)
+
+	w := v2JSONRequest(t, r, http.MethodPost, "/v2/components", &v2.PostComponentData{
+		Name: "Lifecycle Service",
+		Attributes: []v2.ComponentAttribute{
+			{Name: "type", Value: "lfs"},
+			{Name: "region", Value: "EU-DE"},
+			{Name: "category", Value: "Test"},
+		},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	created := &v2.Component{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+	componentURL := fmt.Sprintf("/v2/components/%d", created.ID)
+
+	t.Log("rename the component and move it to another region")
+	newName := "Lifecycle Service Renamed"
+	w = v2JSONRequest(t, r, http.MethodPatch, componentURL, &v2.PatchComponentData{
+		Name:       &newName,
+		Attributes: []v2.ComponentAttribute{{Name: "region", Value: "EU-NL"}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	updated := &db.Component{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), updated))
+	assert.Equal(t, newName, updated.Name)
+	assert.Equal(t, "EU-NL", updated.Region())
+	assert.Len(t, updated.Attrs, 3)
+
+	w = v2JSONRequest(t, r, http.MethodPatch, componentURL, &v2.PatchComponentData{
+		Attributes: []v2.ComponentAttribute{{Name: "region", Value: "EU-UNKNOWN"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("create an incident for the component, the component can't be deleted")
+	impact := 1
+	system := false
+	resp := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "incident for the lifecycle component",
+		Impact:     &impact,
+		Components: []int{created.ID},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.NotNil(t, resp)
+
+	w = v2JSONRequest(t, r, http.MethodDelete, componentURL, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	t.Log("resolve the incident and delete the component")
+	inc := v2GetEvent(t, r, resp.Result[0].IncidentID)
+	endDate := time.Now().UTC()
+	inc.EndDate = &endDate
+	v2PatchEvent(t, r, inc)
+
+	w = v2JSONRequest(t, r, http.MethodDelete, componentURL, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = v2JSONRequest(t, r, http.MethodDelete, componentURL, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	t.Log("the deleted component is hidden from the list, but it's available by id and in the history")
+	assert.False(t, v2ComponentsContain(t, r, "/v2/components", created.ID))
+	assert.True(t, v2ComponentsContain(t, r, "/v2/components?include_deleted=true", created.ID))
+
+	w = v2JSONRequest(t, r, http.MethodGet, componentURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	deleted := &db.Component{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), deleted))
+	assert.NotNil(t, deleted.DeletedAt)
+
+	inc = v2GetEvent(t, r, resp.Result[0].IncidentID)
+	assert.Equal(t, []int{created.ID}, inc.Components)
+
+	t.Log("new events can't use the deleted component")
+	resp = v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "incident for the deleted component",
+		Impact:     &impact,
+		Components: []int{created.ID},
+		StartDate:  time.Now().UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	assert.Nil(t, resp)
+
+	w = v2JSONRequest(t, r, http.MethodPatch, componentURL, &v2.PatchComponentData{Name: &newName})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestV2ComponentAddAttribute(t *testing.T) {
+	t.Log("start to test the update of the component without the attribute")
+	r, _, _ := initTests(t)
+
+	w := v2JSONRequest(t, r, http.MethodPost, "/v2/components", &v2.PostComponentData{
+		Name: "Attribute Service",
+		Attributes: []v2.ComponentAttribute{
+			{Name: "type", Value: "ats"},
+			{Name: "region", Value: "EU-DE"},
+			{Name: "category", Value: "Test"},
+		},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	created := &v2.Component{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+	componentURL := fmt.Sprintf("/v2/components/%d", created.ID)
+
+	t.Log("remove the category of the component, as the components created before the required attributes")
+	gormDB, err := gorm.Open(gormpostgres.Open(databaseURL), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gormDB.Exec(
+		"DELETE FROM component_attribute WHERE component_id = ? AND name = 'category'", created.ID,
+	).Error)
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	t.Log("the missing attribute is added by the update")
+	w = v2JSONRequest(t, r, http.MethodPatch, componentURL, &v2.PatchComponentData{
+		Attributes: []v2.ComponentAttribute{{Name: "category", Value: "Added"}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	updated := &db.Component{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), updated))
+	assert.Len(t, updated.Attrs, 3)
+
+	var category string
+	for _, attr := range updated.Attrs {
+		if attr.Name == "category" {
+			category = attr.Value
+		}
+	}
+	assert.Equal(t, "Added", category)
+}
+
+func v2ComponentsContain(t *testing.T, r *gin.Engine, url string, id int) bool {
+	t.Helper()
+
+	w := v2JSONRequest(t, r, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var components []*db.Component
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &components))
+	for _, comp := range components {
+		if int(comp.ID) == id {
+			return true
+		}
+	}
+
+	return false
+}
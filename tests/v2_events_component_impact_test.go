@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestV2EventComponentImpacts(t *testing.T) {
+	t.Log("start to test the impacts on the components of the incident for the endpoint /v2/events")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	minor, major, outage := 1, 2, 3
+	system := false
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	t.Log("the impacts can't be set for the components outside of the incident")
+	w := v2JSONRequest(t, r, http.MethodPost, "/v2/events", &v2.IncidentData{
+		Title:            "component impacts",
+		Impact:           &minor,
+		Components:       []int{1, 2},
+		ComponentImpacts: []v2.ComponentImpact{{Component: 3, Impact: &outage}},
+		StartDate:        start,
+		System:           &system,
+		Type:             event.TypeIncident,
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("create the incident with the different impacts on the components, the impact of the incident is the highest")
+	resp := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:            "component impacts",
+		Impact:           &minor,
+		Components:       []int{1, 2},
+		ComponentImpacts: []v2.ComponentImpact{{Component: 1, Impact: &outage}},
+		StartDate:        start,
+		System:           &system,
+		Type:             event.TypeIncident,
+	})
+	require.NotNil(t, resp)
+	id := resp.Result[0].IncidentID
+
+	inc := v2GetIncident(t, r, id)
+	assert.Equal(t, outage, *inc.Impact)
+	assert.ElementsMatch(t, []v2.ComponentImpact{
+		{Component: 1, Impact: &outage},
+		{Component: 2, Impact: &minor},
+	}, inc.ComponentImpacts)
+
+	t.Log("the impacts on the components can't be changed together with the impact of the incident")
+	w = v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", id), v2.PatchIncidentData{
+		Message:          "impact changed",
+		Status:           event.IncidentImpactChanged,
+		Impact:           &major,
+		ComponentImpacts: []v2.ComponentImpact{{Component: 2, Impact: &major}},
+		UpdateDate:       start.Add(time.Minute),
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("the change of the impact on the component requires the impact changed status")
+	w = v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", id), v2.PatchIncidentData{
+		Message:          "impact changed",
+		Status:           event.IncidentAnalysing,
+		ComponentImpacts: []v2.ComponentImpact{{Component: 1, Impact: &major}},
+		UpdateDate:       start.Add(time.Minute),
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", id), v2.PatchIncidentData{
+		Message:          "impact changed",
+		Status:           event.IncidentImpactChanged,
+		ComponentImpacts: []v2.ComponentImpact{{Component: 1, Impact: &major}},
+		UpdateDate:       start.Add(time.Minute),
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	inc = v2GetIncident(t, r, id)
+	assert.Equal(t, major, *inc.Impact)
+	assert.ElementsMatch(t, []v2.ComponentImpact{
+		{Component: 1, Impact: &major},
+		{Component: 2, Impact: &minor},
+	}, inc.ComponentImpacts)
+
+	t.Log("the change of the impact of the incident is applied to all components")
+	w = v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", id), v2.PatchIncidentData{
+		Message:    "impact changed",
+		Status:     event.IncidentImpactChanged,
+		Impact:     &outage,
+		UpdateDate: start.Add(time.Minute * 2),
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	inc = v2GetIncident(t, r, id)
+	assert.Equal(t, outage, *inc.Impact)
+	assert.Empty(t, inc.ComponentImpacts)
+}
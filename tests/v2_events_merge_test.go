@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestV2PostEventMergeHandler(t *testing.T) {
+	t.Log("start to test the merge of the incidents for the endpoint /v2/events/42/merge")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	start := time.Now().Add(-time.Hour * 3).UTC().Truncate(time.Second)
+	source := v2CreateOpenIncident(t, r, "source incident", 3, 1, start)
+	target := v2CreateOpenIncident(t, r, "target incident", 1, 2, start.Add(time.Hour))
+
+	v2PatchEventStatus(t, r, target, "the target is analysed", start.Add(time.Hour*2))
+	v2PatchEventStatus(t, r, source, "the source is analysed", start.Add(time.Hour*2+time.Minute))
+
+	t.Log("the incident can't be merged into itself or into the unknown incident")
+	w := v2JSONRequest(t, r, http.MethodPost, fmt.Sprintf("/v2/events/%d/merge", source),
+		v2.PostIncidentMergeData{Target: source})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = v2JSONRequest(t, r, http.MethodPost, fmt.Sprintf("/v2/events/%d/merge", source),
+		v2.PostIncidentMergeData{Target: 999999})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	t.Log("merge the source incident into the target incident")
+	w = v2JSONRequest(t, r, http.MethodPost, fmt.Sprintf("/v2/events/%d/merge", source),
+		v2.PostIncidentMergeData{Target: target})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	merged := v2GetIncident(t, r, target)
+	assert.ElementsMatch(t, []int{1, 2}, merged.Components)
+	assert.Equal(t, 3, *merged.Impact)
+	assert.Equal(t, start, merged.StartDate.UTC())
+	assert.Nil(t, merged.EndDate)
+
+	t.Log("the updates of the source are interleaved with the updates of the target by the timestamps")
+	texts := make([]string, len(merged.Updates))
+	for i, u := range merged.Updates {
+		texts[i] = u.Text
+		if i > 0 {
+			assert.False(t, u.Timestamp.Before(merged.Updates[i-1].Timestamp), "the updates should be chronological")
+		}
+	}
+	require.Len(t, texts, 5)
+	assert.Equal(t, start, merged.Updates[0].Timestamp.UTC(), "the first update is the copy of the source")
+	assert.Equal(t, start.Add(time.Hour), merged.Updates[1].Timestamp.UTC())
+	assert.Equal(t, "the target is analysed", texts[2])
+	assert.Equal(t, "the source is analysed", texts[3])
+	assert.Equal(t, start.Add(time.Hour*2+time.Minute), merged.Updates[3].Timestamp.UTC())
+	assert.Equal(t, event.OutDatedSystem, merged.Updates[4].Status)
+	assert.Contains(t, texts[4], fmt.Sprintf("<a href='/incidents/%d'>source incident</a> merged", source))
+
+	t.Log("the source incident is closed with the pointer to the target")
+	closed := v2GetIncident(t, r, source)
+	require.NotNil(t, closed.EndDate)
+	assert.Equal(t, event.IncidentResolved, closed.Status)
+	require.NotNil(t, closed.MergedInto)
+	assert.Equal(t, uint(target), *closed.MergedInto)
+
+	w = v2JSONRequest(t, r, http.MethodPost, fmt.Sprintf("/v2/events/%d/merge", source),
+		v2.PostIncidentMergeData{Target: target})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("the merge is published in the feed")
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/feed.json", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "merged into the incident"))
+	assert.True(t, strings.Contains(w.Body.String(), "Incident closed by system"))
+}
+
+func TestV2PostEventMergeComponentImpacts(t *testing.T) {
+	t.Log("start to test the merge of the incident with the impacts on the components")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	minor, outage := 1, 3
+	system := false
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	target := v2CreateOpenIncident(t, r, "target incident", minor, 1, start)
+	resp := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:            "source incident",
+		Impact:           &minor,
+		Components:       []int{2, 3},
+		ComponentImpacts: []v2.ComponentImpact{{Component: 2, Impact: &outage}},
+		StartDate:        start,
+		System:           &system,
+		Type:             event.TypeIncident,
+	})
+	require.NotNil(t, resp)
+	source := resp.Result[0].IncidentID
+
+	w := v2JSONRequest(t, r, http.MethodPost, fmt.Sprintf("/v2/events/%d/merge", source),
+		v2.PostIncidentMergeData{Target: target})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	t.Log("the component of the target keeps its impact, the components of the source keep their impacts")
+	merged := v2GetIncident(t, r, target)
+	assert.Equal(t, outage, *merged.Impact)
+	assert.ElementsMatch(t, []v2.ComponentImpact{
+		{Component: 1, Impact: &minor},
+		{Component: 2, Impact: &outage},
+		{Component: 3, Impact: &minor},
+	}, merged.ComponentImpacts)
+}
+
+func v2CreateOpenIncident(t *testing.T, r *gin.Engine, title string, impact, component int, start time.Time) int {
+	t.Helper()
+
+	system := false
+	resp := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      title,
+		Impact:     &impact,
+		Components: []int{component},
+		StartDate:  start,
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.NotNil(t, resp)
+
+	return resp.Result[0].IncidentID
+}
+
+func v2PatchEventStatus(t *testing.T, r *gin.Engine, id int, message string, date time.Time) {
+	t.Helper()
+
+	w := v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", id), v2.PatchIncidentData{
+		Message:    message,
+		Status:     event.IncidentAnalysing,
+		UpdateDate: date,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+type v2EventsCursorResponse struct {
+	Data       []*v2.Incident `json:"data"`
+	Pagination struct {
+		PageIndex    int    `json:"pageIndex"`
+		TotalRecords int    `json:"totalRecords"`
+		TotalPages   int    `json:"totalPages"`
+		NextCursor   string `json:"nextCursor"`
+	} `json:"pagination"`
+}
+
+func TestV2GetEventsCursorHandler(t *testing.T) {
+	t.Log("start to test the cursor pagination and the sorting of GET /v2/events")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	const eventsCount = 12
+	start := time.Now().Add(-time.Hour * 24 * 30).UTC().Truncate(time.Second)
+	ids := make([]int, 0, eventsCount)
+	for i := range eventsCount {
+		// the end dates are in the reverse order of the start dates
+		ids = append(ids, v2CreatePastInfo(t, r, fmt.Sprintf("cursor info %d", i),
+			start.Add(time.Hour*time.Duration(i)), start.Add(time.Hour*time.Duration(48-i))))
+	}
+
+	t.Log("the first page has the cursor of the next page")
+	resp := v2GetEventsPage(t, r, "limit=10&sort=start_date&order=asc")
+	require.Len(t, resp.Data, 10)
+	assert.Equal(t, ids[:10], eventIDs(resp.Data))
+	assert.Equal(t, eventsCount, resp.Pagination.TotalRecords)
+	require.NotEmpty(t, resp.Pagination.NextCursor)
+
+	t.Log("the new event before the cursor doesn't shift the next page")
+	v2CreatePastInfo(t, r, "cursor info new", start.Add(-time.Hour), start)
+
+	resp = v2GetEventsPage(t, r, "limit=10&sort=start_date&order=asc&cursor="+resp.Pagination.NextCursor)
+	assert.Equal(t, ids[10:], eventIDs(resp.Data))
+	assert.Equal(t, eventsCount+1, resp.Pagination.TotalRecords)
+	assert.Empty(t, resp.Pagination.NextCursor)
+	assert.Zero(t, resp.Pagination.PageIndex)
+
+	t.Log("the cursor of the page pagination continues with the cursor pagination")
+	resp = v2GetEventsPage(t, r, "limit=10&type=info&sort=end_date")
+	require.Len(t, resp.Data, 10)
+	assert.Equal(t, 1, resp.Pagination.PageIndex)
+	assert.Equal(t, 2, resp.Pagination.TotalPages)
+	assert.Equal(t, ids[:10], eventIDs(resp.Data))
+
+	resp = v2GetEventsPage(t, r, "limit=10&type=info&cursor="+resp.Pagination.NextCursor)
+	require.Len(t, resp.Data, 3)
+	assert.Equal(t, ids[10:], eventIDs(resp.Data[:2]))
+
+	t.Log("the cursor with another sort is rejected")
+	resp = v2GetEventsPage(t, r, "limit=10")
+	w := v2JSONRequest(t, r, http.MethodGet, "/v2/events?limit=10&sort=impact&cursor="+resp.Pagination.NextCursor, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("the modified events are returned for the incremental sync")
+	since := time.Now().UTC()
+	time.Sleep(time.Millisecond * 10)
+	w = v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", ids[3]), v2.PatchIncidentData{
+		Message:    "the description is changed",
+		Status:     event.InfoCompleted,
+		UpdateDate: time.Now().UTC(),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp = v2GetEventsPage(t, r, "sort=modified_at&order=asc&modified_since="+since.Format(time.RFC3339Nano))
+	assert.Equal(t, []int{ids[3]}, eventIDs(resp.Data))
+}
+
+func v2CreatePastInfo(t *testing.T, r *gin.Engine, title string, start, end time.Time) int {
+	t.Helper()
+
+	noImpact := 0
+	system := false
+	resp := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      title,
+		Impact:     &noImpact,
+		Components: []int{1},
+		StartDate:  start,
+		EndDate:    &end,
+		System:     &system,
+		Type:       event.TypeInformation,
+	})
+	require.NotNil(t, resp)
+
+	return resp.Result[0].IncidentID
+}
+
+func v2GetEventsPage(t *testing.T, r *gin.Engine, query string) *v2EventsCursorResponse {
+	t.Helper()
+
+	w := v2JSONRequest(t, r, http.MethodGet, "/v2/events?"+query, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	resp := &v2EventsCursorResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+
+	return resp
+}
+
+func eventIDs(events []*v2.Incident) []int {
+	ids := make([]int, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestV2GetEventsSearchHandler(t *testing.T) {
+	t.Log("start to test the full-text search of GET /v2/events")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	impact := 1
+	noImpact := 0
+	system := false
+	start := time.Now().Add(-time.Hour * 2).UTC()
+
+	respNetwork := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:       "Network degradation",
+		Description: "Packet loss on the <core> routers.",
+		Impact:      &impact,
+		Components:  []int{1},
+		StartDate:   start,
+		System:      &system,
+		Type:        event.TypeIncident,
+	})
+	require.NotNil(t, respNetwork)
+
+	respStorage := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "Storage latency",
+		Impact:     &impact,
+		Components: []int{2},
+		StartDate:  start.Add(time.Minute),
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.NotNil(t, respStorage)
+	storageID := respStorage.Result[0].IncidentID
+
+	w := v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", storageID), v2.PatchIncidentData{
+		Message:    "The DNS resolver of the storage nodes is restarted.",
+		Status:     event.IncidentFixing,
+		UpdateDate: time.Now().UTC(),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	end := time.Now().Add(-time.Hour).UTC()
+	respInfo := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:       "DNS migration",
+		Description: "The name servers are moved to the new cluster.",
+		Impact:      &noImpact,
+		Components:  []int{3},
+		StartDate:   start,
+		EndDate:     &end,
+		System:      &system,
+		Type:        event.TypeInformation,
+	})
+	require.NotNil(t, respInfo)
+	infoID := respInfo.Result[0].IncidentID
+
+	t.Log("the title matches are ranked higher than the update matches")
+	resp := v2SearchEvents(t, r, "dns", "")
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, infoID, resp.Data[0].ID)
+	assert.Equal(t, storageID, resp.Data[1].ID)
+	require.NotNil(t, resp.Pagination)
+	assert.Equal(t, 2, resp.Pagination.TotalRecords)
+
+	require.NotNil(t, resp.Data[0].Highlight)
+	assert.Equal(t, "<mark>DNS</mark> migration", resp.Data[0].Highlight.Title)
+	assert.Empty(t, resp.Data[0].Highlight.Description)
+	assert.Empty(t, resp.Data[0].Highlight.Updates)
+
+	t.Log("the update snippet references the update of the event")
+	storage := resp.Data[1]
+	require.NotNil(t, storage.Highlight)
+	require.Len(t, storage.Highlight.Updates, 1)
+	upd := storage.Highlight.Updates[0]
+	assert.Contains(t, upd.Text, "<mark>DNS</mark> resolver")
+	require.Less(t, upd.ID, len(storage.Updates))
+	assert.Equal(t, "The DNS resolver of the storage nodes is restarted.", storage.Updates[upd.ID].Text)
+
+	t.Log("the search is combined with the filters")
+	resp = v2SearchEvents(t, r, "dns", "&type=incident")
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, storageID, resp.Data[0].ID)
+
+	t.Log("the words are matched by their stems")
+	resp = v2SearchEvents(t, r, "restarting resolvers", "")
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, storageID, resp.Data[0].ID)
+
+	t.Log("the snippets are HTML escaped")
+	resp = v2SearchEvents(t, r, "packet", "")
+	require.Len(t, resp.Data, 1)
+	require.NotNil(t, resp.Data[0].Highlight)
+	assert.Equal(t, "Network degradation", resp.Data[0].Highlight.Title)
+	assert.Contains(t, resp.Data[0].Highlight.Description, "<mark>Packet</mark> loss on the &lt;core&gt;")
+
+	t.Log("the excluded words and the pagination")
+	resp = v2SearchEvents(t, r, "dns -migration", "")
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, storageID, resp.Data[0].ID)
+
+	resp = v2SearchEvents(t, r, "dns", "&limit=10&page=2")
+	assert.Empty(t, resp.Data)
+	require.NotNil(t, resp.Pagination)
+	assert.Equal(t, 2, resp.Pagination.TotalRecords)
+
+	t.Log("the empty search returns all events without highlights")
+	resp = v2SearchEvents(t, r, "  ", "")
+	require.Len(t, resp.Data, 3)
+	for _, e := range resp.Data {
+		assert.Nil(t, e.Highlight)
+	}
+
+	resp = v2SearchEvents(t, r, "kubernetes", "")
+	assert.Empty(t, resp.Data)
+}
+
+func v2SearchEvents(t *testing.T, r *gin.Engine, search, filters string) *V2EventsListResponse {
+	t.Helper()
+
+	w := v2JSONRequest(t, r, http.MethodGet, "/v2/events?search="+url.QueryEscape(search)+filters, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &V2EventsListResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+
+	return resp
+}
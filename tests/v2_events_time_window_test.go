@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+func TestV2GetEventsTimeWindowHandler(t *testing.T) {
+	t.Log("start to test the overlap time window and the point in time filters")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	day := time.Hour * 24
+
+	inside := v2CreatePastInfo(t, r, "inside the window", now.Add(-10*day), now.Add(-8*day))
+	overlapsEnd := v2CreatePastInfo(t, r, "overlaps the end of the window", now.Add(-6*day), now.Add(-day))
+	v2CreatePastInfo(t, r, "before the window", now.Add(-30*day), now.Add(-25*day))
+
+	impact := 2
+	system := false
+	resp := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "open incident started before the window",
+		Impact:     &impact,
+		Components: []int{2},
+		StartDate:  now.Add(-20 * day),
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.NotNil(t, resp)
+	open := resp.Result[0].IncidentID
+
+	window := fmt.Sprintf("start_date=%s&end_date=%s",
+		now.Add(-12*day).Format(time.RFC3339), now.Add(-5*day).Format(time.RFC3339))
+
+	t.Log("the window without the overlap returns only the events inside the window")
+	page := v2GetEventsPage(t, r, window)
+	assert.Equal(t, []int{inside}, eventIDs(page.Data))
+
+	t.Log("the overlap returns the events, which were active at any time of the window")
+	page = v2GetEventsPage(t, r, window+"&overlap=true&sort=start_date&order=asc")
+	assert.Equal(t, []int{open, inside, overlapsEnd}, eventIDs(page.Data))
+
+	page = v2GetEventsPage(t, r, "overlap=true&sort=start_date&order=asc&start_date="+
+		now.Add(-2*day).Format(time.RFC3339))
+	assert.Equal(t, []int{open, overlapsEnd}, eventIDs(page.Data))
+
+	t.Log("the point in time returns the events, which were active at the time")
+	page = v2GetEventsPage(t, r, "sort=start_date&order=asc&at="+now.Add(-9*day).Format(time.RFC3339))
+	assert.Equal(t, []int{open, inside}, eventIDs(page.Data))
+
+	page = v2GetEventsPage(t, r, "at="+now.Add(-40*day).Format(time.RFC3339))
+	assert.Empty(t, page.Data)
+
+	w := v2JSONRequest(t, r, http.MethodGet, "/v2/events?at="+now.Format(time.RFC3339)+"&"+window, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("the feeds use the overlap time window")
+	ids := v2GetFeedItemIDs(t, r, window)
+	require.NotEmpty(t, ids)
+	assert.True(t, strings.HasSuffix(ids[0], fmt.Sprintf("/incidents/%d", open)), ids[0])
+
+	ids = v2GetFeedItemIDs(t, r, "at="+now.Add(-22*day).Format(time.RFC3339))
+	assert.Empty(t, ids)
+
+	w = v2JSONRequest(t, r, http.MethodGet, "/v2/feed.json?at=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func v2GetFeedItemIDs(t *testing.T, r *gin.Engine, query string) []string {
+	t.Helper()
+
+	w := v2JSONRequest(t, r, http.MethodGet, "/v2/feed.json?"+query, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var feed struct {
+		Items []struct {
+			ID string `json:"id"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+
+	ids := make([]string, len(feed.Items))
+	for i, item := range feed.Items {
+		ids[i] = item.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/checker"
+	"github.com/stackmon/otc-status-dashboard/internal/conf"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const v2MaintenanceSeriesEndpoint = "/v2/maintenance-series"
+
+func TestV2MaintenanceSeriesHandler(t *testing.T) {
+	t.Log("start to test recurring maintenances for /v2/maintenance-series")
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	ch, err := checker.New(&conf.Config{DB: databaseURL, MaintenanceHorizon: "504h"}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ch.Close() })
+
+	start := time.Now().Add(time.Hour * 24).Truncate(time.Hour).UTC()
+
+	t.Log("check the series validation")
+	series := &v2.MaintenanceSeriesData{
+		Title:      "weekly database patching",
+		Components: []int{1},
+		Recurrence: "FREQ=HOURLY",
+		StartDate:  start,
+		EndDate:    start.Add(time.Hour * 2),
+	}
+	w := v2WebhookRequest(t, r, http.MethodPost, v2MaintenanceSeriesEndpoint, series)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	series.Recurrence = "FREQ=WEEKLY"
+	series.EndDate = start.Add(-time.Hour)
+	w = v2WebhookRequest(t, r, http.MethodPost, v2MaintenanceSeriesEndpoint, series)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("create the weekly series")
+	series.Recurrence = "RRULE:FREQ=WEEKLY;COUNT=10"
+	series.EndDate = start.Add(time.Hour * 2)
+	w = v2WebhookRequest(t, r, http.MethodPost, v2MaintenanceSeriesEndpoint, series)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	created := &v2.MaintenanceSeries{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=10", created.Recurrence)
+	assert.NotEmpty(t, created.Schedule)
+	seriesURL := fmt.Sprintf("%s/%d", v2MaintenanceSeriesEndpoint, created.ID)
+
+	t.Log("the checker creates the occurrences within the horizon")
+	require.NoError(t, ch.CheckOnce())
+	occurrences := v2GetSeriesOccurrences(t, r, created.ID)
+	require.Len(t, occurrences, 3)
+	for i, occ := range occurrences {
+		assert.Equal(t, event.TypeMaintenance, occ.Type)
+		assert.Equal(t, event.MaintenancePlanned, occ.Status)
+		assert.Equal(t, start.AddDate(0, 0, 7*i), occ.StartDate.UTC())
+		assert.Equal(t, created.Schedule, occ.Recurrence)
+	}
+
+	t.Log("the repeated check doesn't duplicate the occurrences")
+	require.NoError(t, ch.CheckOnce())
+	require.Len(t, v2GetSeriesOccurrences(t, r, created.ID), 3)
+
+	t.Log("the occurrence changed separately is detached from the series")
+	detachedTitle := "database patching, extended"
+	detachedURL := fmt.Sprintf("%s/%d", v2EventsEndpoint, occurrences[1].ID)
+	w = v2WebhookRequest(t, r, http.MethodPatch, detachedURL, &v2.PatchIncidentData{
+		Title:      &detachedTitle,
+		Message:    "the maintenance takes longer",
+		Status:     event.MaintenanceModified,
+		UpdateDate: time.Now().UTC(),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	t.Log("the change of the series is applied to the attached occurrences only")
+	title := "weekly database patching, new window"
+	w = v2WebhookRequest(t, r, http.MethodPatch, seriesURL, &v2.PatchMaintenanceSeriesData{Title: &title})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, ch.CheckOnce())
+
+	occurrences = v2GetSeriesOccurrences(t, r, created.ID)
+	require.Len(t, occurrences, 3)
+	assert.Equal(t, title, occurrences[0].Title)
+	assert.Equal(t, detachedTitle, occurrences[1].Title)
+	assert.Equal(t, title, occurrences[2].Title)
+
+	t.Log("cancel the series")
+	w = v2WebhookRequest(t, r, http.MethodDelete, seriesURL, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.NoError(t, ch.CheckOnce())
+
+	for _, occ := range v2GetSeriesOccurrences(t, r, created.ID) {
+		assert.Equal(t, event.MaintenanceCancelled, occ.Status)
+	}
+
+	w = v2WebhookRequest(t, r, http.MethodPatch, seriesURL, &v2.PatchMaintenanceSeriesData{Title: &title})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = v2WebhookRequest(t, r, http.MethodGet, seriesURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	stored := &v2.MaintenanceSeries{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), stored))
+	assert.NotNil(t, stored.CancelledAt)
+
+	w = v2WebhookRequest(t, r, http.MethodGet, fmt.Sprintf("%s/%d", v2MaintenanceSeriesEndpoint, 100500), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func v2GetSeriesOccurrences(t *testing.T, r *gin.Engine, seriesID uint) []*v2.Incident {
+	t.Helper()
+
+	url := fmt.Sprintf("%s?series_id=%d&type=maintenance", v2EventsEndpoint, seriesID)
+	w := v2WebhookRequest(t, r, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp V2EventsListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	slices.SortFunc(resp.Data, func(a, b *v2.Incident) int { return a.StartDate.Compare(b.StartDate) })
+
+	return resp.Data
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+)
+
+const v2RegionsEndpoint = "/v2/regions"
+
+func TestV2RegionsHandler(t *testing.T) {
+	t.Log("start to test /v2/regions")
+	r, _, _ := initTests(t)
+
+	t.Log("check the default regions")
+	w := v2JSONRequest(t, r, http.MethodGet, v2RegionsEndpoint, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Data []*db.Region `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	names := make([]string, 0, len(list.Data))
+	for _, region := range list.Data {
+		names = append(names, region.Name)
+	}
+	assert.Subset(t, names, []string{"EU-DE", "EU-NL", "EU-CH2", "Global"})
+
+	t.Log("create a region")
+	w = v2JSONRequest(t, r, http.MethodPost, v2RegionsEndpoint, &v2.RegionData{Name: "EU-TEST", Description: "test"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	created := &db.Region{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+	assert.Equal(t, "EU-TEST", created.Name)
+
+	w = v2JSONRequest(t, r, http.MethodPost, v2RegionsEndpoint, &v2.RegionData{Name: "EU-TEST"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	t.Log("create a component in the unknown region, should fail")
+	component := &v2.PostComponentData{
+		Name: "Region Test Service",
+		Attributes: []v2.ComponentAttribute{
+			{Name: "type", Value: "rts"},
+			{Name: "region", Value: "EU-UNKNOWN"},
+			{Name: "category", Value: "Test"},
+		},
+	}
+	w = v2JSONRequest(t, r, http.MethodPost, "/v2/components", component)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "component attribute region is missing or invalid")
+
+	t.Log("create a component in the new region")
+	component.Attributes[1].Value = "EU-TEST"
+	w = v2JSONRequest(t, r, http.MethodPost, "/v2/components", component)
+	require.Equal(t, http.StatusCreated, w.Code)
+	comp := &v2.Component{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), comp))
+
+	t.Log("rename the region, the component attribute should be renamed too")
+	regionURL := fmt.Sprintf("%s/%d", v2RegionsEndpoint, created.ID)
+	newName := "EU-TEST2"
+	w = v2JSONRequest(t, r, http.MethodPatch, regionURL, &v2.PatchRegionData{Name: &newName})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = v2JSONRequest(t, r, http.MethodGet, fmt.Sprintf("/v2/components/%d", comp.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "EU-TEST2")
+
+	t.Log("delete the region, which is used by the component, should fail")
+	w = v2JSONRequest(t, r, http.MethodDelete, regionURL, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	t.Log("delete the component, the region is used only by the deleted component and can be deleted")
+	w = v2JSONRequest(t, r, http.MethodDelete, fmt.Sprintf("/v2/components/%d", comp.ID), nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = v2JSONRequest(t, r, http.MethodDelete, regionURL, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	t.Log("delete an unused region")
+	w = v2JSONRequest(t, r, http.MethodPost, v2RegionsEndpoint, &v2.RegionData{Name: "EU-UNUSED"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	unused := &db.Region{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), unused))
+
+	unusedURL := fmt.Sprintf("%s/%d", v2RegionsEndpoint, unused.ID)
+	w = v2JSONRequest(t, r, http.MethodDelete, unusedURL, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = v2JSONRequest(t, r, http.MethodGet, unusedURL, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func v2JSONRequest(t *testing.T, r *gin.Engine, method, url string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, reader)
+	r.ServeHTTP(w, req)
+
+	return w
+}
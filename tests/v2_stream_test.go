@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/stream"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
+)
+
+func TestV2StreamHandler(t *testing.T) {
+	t.Log("start to test the events stream for /v2/stream")
+	truncateIncidents(t)
+	r, dbInst, _ := initTests(t)
+
+	broker := stream.NewBroker(dbInst, zap.NewNop())
+	r.GET("/v2/stream", v2.GetStreamHandler(broker, dbInst, zap.NewNop()))
+
+	lastID, err := dbInst.GetLastStreamMessageID()
+	require.NoError(t, err)
+
+	t.Log("create an incident and a maintenance")
+	impact := 1
+	system := false
+	resultInc := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "stream incident",
+		Impact:     &impact,
+		Components: []int{5},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.NotNil(t, resultInc)
+
+	mntImpact := 0
+	mntStart := time.Now().Add(time.Hour).UTC()
+	mntEnd := mntStart.Add(time.Hour)
+	resultMnt := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:       "stream maintenance",
+		Description: "stream maintenance description",
+		Impact:      &mntImpact,
+		Components:  []int{6},
+		StartDate:   mntStart,
+		EndDate:     &mntEnd,
+		System:      &system,
+		Type:        event.TypeMaintenance,
+	})
+	require.NotNil(t, resultMnt)
+
+	t.Log("resume the stream with the maintenance filter")
+	body := readStream(t, r, fmt.Sprintf("/v2/stream?type=%s", event.TypeMaintenance), lastID)
+	assert.Contains(t, body, fmt.Sprintf("event: %s\n", webhook.ActionCreated))
+	assert.Contains(t, body, "stream maintenance")
+	assert.NotContains(t, body, "stream incident")
+
+	t.Log("resume the stream with the component filter")
+	body = readStream(t, r, "/v2/stream?components=5", lastID)
+	assert.Contains(t, body, "stream incident")
+	assert.NotContains(t, body, "stream maintenance")
+
+	t.Log("check the wrong Last-Event-ID")
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v2/stream", nil)
+	req.Header.Set("Last-Event-ID", "wrong")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	truncateIncidents(t)
+}
+
+func readStream(t *testing.T, r http.Handler, url string, lastID uint) string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Last-Event-ID", fmt.Sprintf("%d", lastID))
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream"))
+
+	return w.Body.String()
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/email"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+)
+
+const v2SubscriptionsEndpoint = "/v2/subscriptions"
+
+func TestV2SubscriptionsHandler(t *testing.T) {
+	t.Log("start to test the email subscriptions for /v2/subscriptions")
+	truncateIncidents(t)
+	truncateSubscribers(t)
+	r, dbInst, _ := initTests(t)
+
+	t.Log("check the subscription validation")
+	for _, data := range []*v2.SubscriptionData{
+		{Email: "user@example.com"},
+		{Email: "not an email", Components: []int{3}},
+		{Email: "User <user@example.com>", Components: []int{3}},
+		{Email: "user@example.com", Components: []int{999}},
+		{Email: "user@example.com", Regions: []string{"unknown"}},
+	} {
+		w := v2JSONRequest(t, r, http.MethodPost, v2SubscriptionsEndpoint, data)
+		assert.Equal(t, http.StatusBadRequest, w.Code, data)
+	}
+
+	t.Log("subscribe to the component, the repeated request doesn't send the confirmation again")
+	subData := &v2.SubscriptionData{Email: "user@example.com", Components: []int{3}}
+	w := v2JSONRequest(t, r, http.MethodPost, v2SubscriptionsEndpoint, subData)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	w = v2JSONRequest(t, r, http.MethodPost, v2SubscriptionsEndpoint, subData)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	subscribers, err := dbInst.GetSubscribersByEmail("user@example.com")
+	require.NoError(t, err)
+	require.Len(t, subscribers, 1)
+	assert.Nil(t, subscribers[0].ConfirmedAt)
+	subscriberID := subscribers[0].ID
+	assert.Equal(t, []string{string(email.KindConfirmation)}, v2PendingEmailKinds(t, dbInst, subscriberID))
+
+	t.Log("confirm the subscription")
+	w = v2JSONRequest(t, r, http.MethodPost, v2SubscriptionsEndpoint+"/confirm?token=unknown", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = v2JSONRequest(t, r, http.MethodPost, v2SubscriptionsEndpoint+"/confirm", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("the link of the message returns the page, the subscription is confirmed only by the form")
+	confirmURL := fmt.Sprintf("%s/confirm?token=%s", v2SubscriptionsEndpoint, subscribers[0].ConfirmToken)
+	w = v2JSONRequest(t, r, http.MethodGet, confirmURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<form method="post" action="?token=`+subscribers[0].ConfirmToken+`">`)
+	subscribers, err = dbInst.GetSubscribersByEmail("user@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, subscribers[0].ConfirmedAt)
+
+	w = v2JSONRequest(t, r, http.MethodPost, confirmURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	confirmed := &v2.Subscription{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), confirmed))
+	assert.Equal(t, []uint{3}, confirmed.Components)
+	assert.NotNil(t, confirmed.ConfirmedAt)
+
+	t.Log("the unconfirmed subscription of another email doesn't receive the messages")
+	w = v2JSONRequest(t, r, http.MethodPost, v2SubscriptionsEndpoint, &v2.SubscriptionData{
+		Email: "another@example.com", Regions: []string{"EU-DE"},
+	})
+	require.Equal(t, http.StatusAccepted, w.Code)
+	pending, err := dbInst.GetSubscribersByEmail("another@example.com")
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	t.Log("the incident of the subscribed component is notified, the other components are skipped")
+	impact := 1
+	system := false
+	resp := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "subscription incident",
+		Impact:     &impact,
+		Components: []int{3},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.NotNil(t, resp)
+	require.Len(t, resp.Result, 1)
+	incidentID := resp.Result[0].IncidentID
+
+	require.NotNil(t, v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "another component incident",
+		Impact:     &impact,
+		Components: []int{1},
+		StartDate:  time.Now().Add(-time.Hour).UTC(),
+		System:     &system,
+		Type:       event.TypeIncident,
+	}))
+	assert.Equal(t,
+		[]string{string(email.KindConfirmation), string(email.KindIncidentOpened)},
+		v2PendingEmailKinds(t, dbInst, subscriberID),
+	)
+	assert.Equal(t, []string{string(email.KindConfirmation)}, v2PendingEmailKinds(t, dbInst, pending[0].ID))
+
+	t.Log("the resolved incident is notified")
+	w = v2JSONRequest(t, r, http.MethodPatch, fmt.Sprintf("/v2/events/%d", incidentID), &v2.PatchIncidentData{
+		Message:    "The incident is resolved.",
+		Status:     event.IncidentResolved,
+		UpdateDate: time.Now().UTC(),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t,
+		[]string{
+			string(email.KindConfirmation), string(email.KindIncidentOpened), string(email.KindIncidentResolved),
+		},
+		v2PendingEmailKinds(t, dbInst, subscriberID),
+	)
+
+	t.Log("unsubscribe, the pending messages are removed")
+	unsubscribeURL := fmt.Sprintf("%s/unsubscribe?token=%s", v2SubscriptionsEndpoint, subscribers[0].UnsubscribeToken)
+	w = v2JSONRequest(t, r, http.MethodGet, unsubscribeURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, v2PendingEmailKinds(t, dbInst, subscriberID))
+	w = v2JSONRequest(t, r, http.MethodPost, unsubscribeURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = v2JSONRequest(t, r, http.MethodPost, unsubscribeURL, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, v2PendingEmailKinds(t, dbInst, subscriberID))
+}
+
+func v2PendingEmailKinds(t *testing.T, dbInst *db.DB, subscriberID uint) []string {
+	t.Helper()
+
+	gormDB, err := gorm.Open(gormpostgres.Open(databaseURL), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	// the messages are read without the claim, so they are still pending for the sender
+	kinds := make([]string, 0)
+	r := gormDB.Model(&db.EmailMessage{}).
+		Where("subscriber_id = ? AND status = ?", subscriberID, db.EmailMessagePending).
+		Order("id").
+		Pluck("kind", &kinds)
+	require.NoError(t, r.Error)
+
+	return kinds
+}
@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	v1 "github.com/stackmon/otc-status-dashboard/internal/api/v1"
+	v2 "github.com/stackmon/otc-status-dashboard/internal/api/v2"
+	"github.com/stackmon/otc-status-dashboard/internal/conf"
+	"github.com/stackmon/otc-status-dashboard/internal/db"
+	"github.com/stackmon/otc-status-dashboard/internal/event"
+	"github.com/stackmon/otc-status-dashboard/internal/webhook"
+)
+
+const v2WebhooksEndpoint = "/v2/webhooks"
+
+func TestV2WebhooksHandler(t *testing.T) {
+	t.Log("start to test webhooks for /v2/webhooks")
+	truncateWebhooks(t)
+	r, _, _ := initTests(t)
+
+	var receivedSignature string
+	var receivedBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		receivedSignature = req.Header.Get(webhook.SignatureHeader)
+		receivedBody, _ = io.ReadAll(req.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Log("check the webhook validation")
+	w := v2WebhookRequest(t, r, http.MethodPost, v2WebhooksEndpoint, &v2.WebhookData{URL: "ftp://example.com"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = v2WebhookRequest(t, r, http.MethodPost, v2WebhooksEndpoint, &v2.WebhookData{
+		URL:        srv.URL,
+		EventTypes: []string{"unknown"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Log("create a webhook for maintenances")
+	w = v2WebhookRequest(t, r, http.MethodPost, v2WebhooksEndpoint, &v2.WebhookData{
+		URL:        srv.URL,
+		EventTypes: []string{event.TypeMaintenance},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	created := &v2.Webhook{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+	assert.True(t, created.Active)
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, []string{event.TypeMaintenance}, created.EventTypes)
+
+	webhookURL := fmt.Sprintf("%s/%d", v2WebhooksEndpoint, created.ID)
+	w = v2WebhookRequest(t, r, http.MethodGet, webhookURL, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	stored := &v2.Webhook{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), stored))
+	assert.Empty(t, stored.Secret, "the secret should be returned only after the creation")
+
+	t.Log("create an incident and a maintenance, only the maintenance should be delivered")
+	impact := 1
+	system := false
+	startDate := time.Now().Add(-time.Hour).UTC()
+	resultInc := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:      "webhook incident",
+		Impact:     &impact,
+		Components: []int{3},
+		StartDate:  startDate,
+		System:     &system,
+		Type:       event.TypeIncident,
+	})
+	require.NotNil(t, resultInc)
+
+	mntImpact := 0
+	mntStart := time.Now().Add(time.Hour).UTC()
+	mntEnd := mntStart.Add(time.Hour)
+	resultMnt := v2CreateEvent(t, r, &v2.IncidentData{
+		Title:       "webhook maintenance",
+		Description: "webhook maintenance description",
+		Impact:      &mntImpact,
+		Components:  []int{4},
+		StartDate:   mntStart,
+		EndDate:     &mntEnd,
+		System:      &system,
+		Type:        event.TypeMaintenance,
+	})
+	require.NotNil(t, resultMnt)
+
+	deliveries := v2GetWebhookDeliveries(t, r, created.ID, "")
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, db.WebhookDeliveryPending, deliveries[0].Status)
+	assert.Equal(t, string(webhook.ActionCreated), deliveries[0].Action)
+	assert.Equal(t, uint(resultMnt.Result[0].IncidentID), deliveries[0].IncidentID)
+
+	t.Log("send pending deliveries")
+	sender, err := webhook.New(&conf.Config{DB: databaseURL}, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, sender.SendPending())
+
+	assert.Equal(t, webhook.Sign(created.Secret, receivedBody), receivedSignature)
+	payload := &webhook.Payload{}
+	require.NoError(t, json.Unmarshal(receivedBody, payload))
+	assert.Equal(t, webhook.ActionCreated, payload.Action)
+	assert.Equal(t, uint(resultMnt.Result[0].IncidentID), payload.Event.ID)
+	assert.Equal(t, event.MaintenancePlanned, payload.Event.Status)
+
+	deliveries = v2GetWebhookDeliveries(t, r, created.ID, db.WebhookDeliveryDelivered)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+
+	t.Log("disable and delete the webhook")
+	active := false
+	w = v2WebhookRequest(t, r, http.MethodPatch, webhookURL, &v2.PatchWebhookData{Active: &active})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), stored))
+	assert.False(t, stored.Active)
+
+	w = v2WebhookRequest(t, r, http.MethodDelete, webhookURL, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = v2WebhookRequest(t, r, http.MethodGet, webhookURL, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	truncateIncidents(t)
+}
+
+func TestV1ComponentStatusWebhooks(t *testing.T) {
+	t.Log("start to test webhook deliveries for the incidents of /v1/component_status")
+	truncateWebhooks(t)
+	truncateIncidents(t)
+	r, _, _ := initTests(t)
+
+	w := v2WebhookRequest(t, r, http.MethodPost, v2WebhooksEndpoint, &v2.WebhookData{
+		URL:        "http://localhost/webhook",
+		EventTypes: []string{event.TypeIncident},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	created := &v2.Webhook{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), created))
+
+	t.Log("create an incident by the component status")
+	compStatus := &v1.ComponentStatusPost{
+		Name:       "Distributed Cache Service",
+		Impact:     1,
+		Text:       "v1 webhook incident",
+		Attributes: []*v1.ComponentAttribute{{Name: "region", Value: "EU-NL"}},
+	}
+	incID, _ := createIncidentByComponentV1(t, r, compStatus)
+	require.NotZero(t, incID)
+
+	deliveries := v2GetWebhookDeliveries(t, r, created.ID, db.WebhookDeliveryPending)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, string(webhook.ActionCreated), deliveries[0].Action)
+	assert.Equal(t, uint(incID), deliveries[0].IncidentID)
+
+	t.Log("raise the impact of the incident, the update should be delivered")
+	compStatus.Impact = 2
+	incIDRaised, _ := createIncidentByComponentV1(t, r, compStatus)
+	assert.Equal(t, incID, incIDRaised)
+
+	deliveries = v2GetWebhookDeliveries(t, r, created.ID, db.WebhookDeliveryPending)
+	require.Len(t, deliveries, 2)
+	actions := []string{deliveries[0].Action, deliveries[1].Action}
+	assert.ElementsMatch(t, []string{string(webhook.ActionCreated), string(webhook.ActionUpdated)}, actions)
+
+	truncateWebhooks(t)
+	truncateIncidents(t)
+}
+
+func v2WebhookRequest(t *testing.T, r *gin.Engine, method, url string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, reader)
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func v2GetWebhookDeliveries(t *testing.T, r *gin.Engine, id uint, status string) []*db.WebhookDelivery {
+	t.Helper()
+
+	url := fmt.Sprintf("%s/%d/deliveries", v2WebhooksEndpoint, id)
+	if status != "" {
+		url = fmt.Sprintf("%s?status=%s", url, status)
+	}
+
+	w := v2WebhookRequest(t, r, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []*db.WebhookDelivery `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return resp.Data
+}